@@ -0,0 +1,444 @@
+// Package awbridge lets Panasonic AW protocol controllers operate cameras of
+// other vendors.
+//
+// A bridge implements panasonic.AWHandler, to be served by a
+// panasonic.CameraServer to remote panels like the AW-RP50 or AW-RP150.
+package awbridge
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"sync"
+
+	"puzzlekraken.com/broadcastkit/panasonic"
+	"puzzlekraken.com/broadcastkit/sony"
+)
+
+// Default F-number range of Sony cameras, in 1/100 F-stops, used until the
+// camera reports its ExposureIrisRange.
+const (
+	defaultIrisMin = 180
+	defaultIrisMax = 1600
+)
+
+// Range of the Sony ExposureGain, in dB. AW gains outside of it are clamped,
+// so the common 0 dB selects the lowest gain of the camera.
+const (
+	sonyGainMin = 6
+	sonyGainMax = 39
+)
+
+// wbGainStep is the number of Sony white balance gain steps in one AW step,
+// mapping the AW R and B gain range of ±30 onto the Sony range of ±990.
+const wbGainStep = 33
+
+// notifyEndpoints are the Sony endpoints subscribed for AW notifications.
+var notifyEndpoints = []sony.Endpoint{
+	sony.PtzfEndpoint,
+	sony.ImagingEndpoint,
+	sony.SystemEndpoint,
+}
+
+// Sony translates AW requests into sony.CameraClient calls.
+//
+// Pan, tilt, zoom, focus, presets, iris, gain, white balance, tally and power
+// are translated. Other requests are answered with AWErrUnsupported, as a
+// Panasonic camera of a different model would. Queries and batches are
+// answered from inquiries of the Sony camera.
+//
+// AW presets are numbered from 0, the Sony preset of the same position is
+// numbered from 1.
+//
+// Sony must not be copied after first use.
+type Sony struct {
+	Client *sony.CameraClient
+
+	lock    sync.Mutex
+	irisMin int
+	irisMax int
+}
+
+func (s *Sony) irisRange() (int, int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.irisMin <= 0 || s.irisMax <= s.irisMin {
+		return defaultIrisMin, defaultIrisMax
+	}
+	return s.irisMin, s.irisMax
+}
+
+// irisToSony converts the AW iris scale to a Sony F-number.
+//
+// The AW scale is linear in stops, closed at 0 and open at ScaleUnitMax.
+func (s *Sony) irisToSony(iris panasonic.ScaleUnit) int {
+	lo, hi := s.irisRange()
+	t := clamp(float64(iris)/float64(panasonic.ScaleUnitMax), 0, 1)
+	return int(math.Round(float64(hi) * math.Pow(float64(lo)/float64(hi), t)))
+}
+
+// irisToAW converts a Sony F-number to the AW iris scale.
+func (s *Sony) irisToAW(f int) panasonic.ScaleUnit {
+	lo, hi := s.irisRange()
+	if f <= 0 {
+		return 0
+	}
+	t := math.Log(float64(f)/float64(hi)) / math.Log(float64(lo)/float64(hi))
+	return panasonic.ScaleUnit(math.Round(clamp(t, 0, 1) * float64(panasonic.ScaleUnitMax)))
+}
+
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
+
+// rescale maps v from the range 0 to from onto the range 0 to to.
+func rescale(v, from, to int) int {
+	return int(math.Round(clamp(float64(v), 0, float64(from)) * float64(to) / float64(from)))
+}
+
+// gainToSony converts an AW gain of 0 to 48 dB to the Sony gain range.
+func gainToSony(d panasonic.Decibel) int {
+	return int(clamp(float64(d), sonyGainMin, sonyGainMax))
+}
+
+func panTiltToSony(m panasonic.MoveUnit) sony.SteppedPosition {
+	return sony.SteppedPosition(math.Round(float64(m) / panasonic.MoveUnitByDegree * sony.SteppedPositionByDegree))
+}
+
+func panTiltToAW(p sony.SteppedPosition) panasonic.MoveUnit {
+	return panasonic.MoveUnit(math.Round(float64(p) / sony.SteppedPositionByDegree * panasonic.MoveUnitByDegree))
+}
+
+// speedToSony converts an AW preset speed of 1 to 30 to a Sony speed step.
+// Zero is the default speed of the camera, which is the fastest.
+func speedToSony(s panasonic.SpeedUnit) sony.SpeedStep {
+	if s.Speed <= 0 {
+		return 50
+	}
+	return sony.SpeedStep(max(1, rescale(s.Speed, 30, 50)))
+}
+
+// continuousToSony converts a signed AW continuous speed of ±49 to a Sony
+// speed step, keeping slow movements from rounding to a stop.
+func continuousToSony(c panasonic.ContinuousSpeed) sony.SpeedStep {
+	if c == 0 {
+		return 0
+	}
+	return sony.SpeedStep(max(1, rescale(int(math.Abs(float64(c))), 49, 50)))
+}
+
+func direction(pan, tilt panasonic.ContinuousSpeed) sony.Direction {
+	switch {
+	case pan > 0 && tilt > 0:
+		return sony.UpRightDirection
+	case pan > 0 && tilt < 0:
+		return sony.DownRightDirection
+	case pan < 0 && tilt > 0:
+		return sony.UpLeftDirection
+	case pan < 0 && tilt < 0:
+		return sony.DownLeftDirection
+	case pan > 0:
+		return sony.RightDirection
+	case pan < 0:
+		return sony.LeftDirection
+	case tilt > 0:
+		return sony.UpDirection
+	case tilt < 0:
+		return sony.DownDirection
+	default:
+		return sony.StopDirection
+	}
+}
+
+func switchOf(t panasonic.Toggle) sony.Switch {
+	if t == panasonic.On {
+		return sony.SwitchOn
+	}
+	return sony.SwitchOff
+}
+
+func toggleOf(s sony.Switch) panasonic.Toggle {
+	if s == sony.SwitchOn {
+		return panasonic.On
+	}
+	return panasonic.Off
+}
+
+// set sends parameters to the camera, refusing the request if any of them is
+// out of the Sony range.
+func (s *Sony) set(ctx context.Context, req panasonic.AWRequest, ep sony.Endpoint, ps ...sony.Parameter) (panasonic.AWResponse, error) {
+	for _, p := range ps {
+		if !p.Valid() {
+			return nil, panasonic.NewAWError(panasonic.AWErrUnacceptable, req)
+		}
+	}
+	if err := s.Client.SetCtx(ctx, ep, ps); err != nil {
+		return nil, err
+	}
+	return req.Response(), nil
+}
+
+// inquire returns the current parameters of ep, translated to AW responses.
+func (s *Sony) inquire(ctx context.Context, ep ...sony.Endpoint) ([]panasonic.AWResponse, error) {
+	ps, err := s.Client.InqCtx(ctx, ep...)
+	if err != nil && ps == nil {
+		return nil, err
+	}
+	// Parameters failing to parse do not affect the ones translated.
+	return s.Translate(ps), nil
+}
+
+// query answers req with the first inquired response of the same type as the
+// expected response.
+//
+// Alternate queries are answered with the primary response type, which the
+// caller converts.
+func (s *Sony) query(ctx context.Context, req panasonic.AWRequest, ep sony.Endpoint) (panasonic.AWResponse, error) {
+	res, err := s.inquire(ctx, ep)
+	if err != nil {
+		return nil, err
+	}
+	var want reflect.Type
+	switch req.(type) {
+	case panasonic.AWZoomQueryAltenate:
+		want = reflect.TypeOf(panasonic.AWZoomTo{})
+	case panasonic.AWFocusQueryAlternate:
+		want = reflect.TypeOf(panasonic.AWFocusTo{})
+	default:
+		want = reflect.TypeOf(req.Response())
+	}
+	for _, r := range res {
+		if reflect.TypeOf(r) == want {
+			return r, nil
+		}
+	}
+	return nil, panasonic.NewAWError(panasonic.AWErrBusy, req)
+}
+
+func (s *Sony) AWCommand(req panasonic.AWRequest) (panasonic.AWResponse, error) {
+	return s.AWCommandCtx(context.Background(), req)
+}
+
+func (s *Sony) AWCommandCtx(ctx context.Context, req panasonic.AWRequest) (panasonic.AWResponse, error) {
+	if !req.Acceptable() {
+		return nil, panasonic.NewAWError(panasonic.AWErrUnacceptable, req)
+	}
+	switch r := req.(type) {
+	case panasonic.AWPanTilt:
+		return s.set(ctx, req, sony.PtzfEndpoint, sony.PanTiltMoveParam{
+			Direction:       direction(r.Pan, r.Tilt),
+			HorizontalSpeed: continuousToSony(r.Pan),
+			VerticalSpeed:   continuousToSony(r.Tilt),
+		})
+	case panasonic.AWPanTiltTo:
+		return s.set(ctx, req, sony.PtzfEndpoint, sony.AbsolutePanTiltParam{
+			Pan:   panTiltToSony(r.Pan),
+			Tilt:  panTiltToSony(r.Tilt),
+			Speed: 50,
+		})
+	case panasonic.AWPanTiltSpeedTo:
+		return s.set(ctx, req, sony.PtzfEndpoint, sony.AbsolutePanTiltParam{
+			Pan:   panTiltToSony(r.Pan),
+			Tilt:  panTiltToSony(r.Tilt),
+			Speed: speedToSony(r.Speed),
+		})
+	case panasonic.AWZoom:
+		dir := sony.StopZoomDirection
+		switch {
+		case r.Zoom > 0:
+			dir = sony.TeleDirection
+		case r.Zoom < 0:
+			dir = sony.WideDirection
+		}
+		return s.set(ctx, req, sony.PtzfEndpoint, sony.ZoomMoveParam{
+			Direction: dir,
+			Speed:     sony.ZoomSpeed(rescale(int(math.Abs(float64(r.Zoom))), 49, int(sony.ZoomSpeedMax))),
+		})
+	case panasonic.AWZoomTo:
+		// Sony has absolute zoom only combined with pan, tilt and focus.
+		p, err := s.ptzf(ctx)
+		if err != nil {
+			return nil, err
+		}
+		p.Zoom = sony.SteppedRange(rescale(int(r.Zoom), int(panasonic.ScaleUnitMax), int(sony.SteppedRangeZoomMax)))
+		return s.set(ctx, req, sony.PtzfEndpoint, p)
+	case panasonic.AWFocusTo:
+		return s.set(ctx, req, sony.PtzfEndpoint,
+			sony.FocusModeParam{Mode: sony.FocusModeManual},
+			sony.AbsoluteFocusParam{Position: sony.SteppedRange(rescale(int(r.Focus), int(panasonic.ScaleUnitMax), int(sony.SteppedRangeFocusMax)))},
+		)
+	case panasonic.AWPresetRecall:
+		return s.set(ctx, req, sony.PresetpositionEndpoint, sony.PresetCallParam{Preset: sony.Preset(r.Preset + 1)})
+	case panasonic.AWIrisTo:
+		return s.set(ctx, req, sony.ImagingEndpoint,
+			sony.ExposureAutoIrisParam{Auto: sony.SwitchOff},
+			sony.ExposureIrisParam{Iris: s.irisToSony(r.Iris)},
+		)
+	case panasonic.AWIris:
+		// The legacy iris command is absolute on the range 1 to 99.
+		iris := panasonic.ScaleUnit(rescale(int(r.Iris)-1, 98, int(panasonic.ScaleUnitMax)))
+		return s.set(ctx, req, sony.ImagingEndpoint,
+			sony.ExposureAutoIrisParam{Auto: sony.SwitchOff},
+			sony.ExposureIrisParam{Iris: s.irisToSony(iris)},
+		)
+	case panasonic.AWAutoIris:
+		return s.set(ctx, req, sony.ImagingEndpoint, sony.ExposureAutoIrisParam{Auto: switchOf(r.Enabled)})
+	case panasonic.AWGain:
+		if r.Gain == panasonic.DecibelAuto {
+			return s.set(ctx, req, sony.ImagingEndpoint, sony.ExposureAGCEnableParam{Enable: sony.SwitchOn})
+		}
+		return s.set(ctx, req, sony.ImagingEndpoint,
+			sony.ExposureAGCEnableParam{Enable: sony.SwitchOff},
+			sony.ExposureGainParam{Gain: gainToSony(r.Gain)},
+		)
+	case panasonic.AWColorTemp:
+		return s.set(ctx, req, sony.ImagingEndpoint, sony.WhiteBalanceColorTempParam{Kelvin: r.Temp.K()})
+	case panasonic.AWRGainControl:
+		return s.set(ctx, req, sony.ImagingEndpoint, sony.WhiteBalanceCrGainParam{Gain: r.Gain * wbGainStep})
+	case panasonic.AWBGainControl:
+		return s.set(ctx, req, sony.ImagingEndpoint, sony.WhiteBalanceCbGainParam{Gain: r.Gain * wbGainStep})
+	case panasonic.AWTallySet:
+		return s.set(ctx, req, sony.SystemEndpoint, sony.TallyLampRedParam{Lamp: switchOf(r.TallyLight)})
+	case panasonic.AWPower:
+		standby := sony.SwitchOff
+		if r.Power == panasonic.PowerStandby {
+			standby = sony.SwitchOn
+		}
+		return s.set(ctx, req, sony.SystemEndpoint, sony.PowerStandbyParam{Standby: standby})
+
+	case panasonic.AWPanTiltQuery:
+		return s.query(ctx, req, sony.PtzfEndpoint)
+	case panasonic.AWZoomQuery:
+		return s.query(ctx, req, sony.PtzfEndpoint)
+	case panasonic.AWZoomQueryAltenate:
+		res, err := s.query(ctx, req, sony.PtzfEndpoint)
+		if z, ok := res.(panasonic.AWZoomTo); ok {
+			return panasonic.AWZoomResponseAlternate{Zoom: z.Zoom}, nil
+		}
+		return res, err
+	case panasonic.AWFocusQuery:
+		return s.query(ctx, req, sony.PtzfEndpoint)
+	case panasonic.AWFocusQueryAlternate:
+		res, err := s.query(ctx, req, sony.PtzfEndpoint)
+		if f, ok := res.(panasonic.AWFocusTo); ok {
+			return panasonic.AWFocusResponseAlternate{Focus: f.Focus}, nil
+		}
+		return res, err
+	case panasonic.AWIrisQuery, panasonic.AWAutoIrisQuery, panasonic.AWGainQuery,
+		panasonic.AWColorTempQuery, panasonic.AWRGainQuery, panasonic.AWBGainQuery:
+		return s.query(ctx, req, sony.ImagingEndpoint)
+	case panasonic.AWTallyQuery, panasonic.AWPowerQuery:
+		return s.query(ctx, req, sony.SystemEndpoint)
+	default:
+		return nil, panasonic.NewAWError(panasonic.AWErrUnsupported, req)
+	}
+}
+
+func (s *Sony) ptzf(ctx context.Context) (sony.AbsolutePTZFParam, error) {
+	ps, err := s.Client.InqCtx(ctx, sony.PtzfEndpoint)
+	for _, p := range ps {
+		if p, ok := p.(sony.AbsolutePTZFParam); ok {
+			return p, nil
+		}
+	}
+	if err == nil {
+		err = errors.New("broadcastkit/awbridge: camera did not report AbsolutePTZF")
+	}
+	return sony.AbsolutePTZFParam{}, err
+}
+
+func (s *Sony) AWBatch() ([]panasonic.AWResponse, error) {
+	return s.AWBatchCtx(context.Background())
+}
+
+// AWBatchCtx synthesizes the camdata page from the state of the camera.
+func (s *Sony) AWBatchCtx(ctx context.Context) ([]panasonic.AWResponse, error) {
+	return s.inquire(ctx, sony.PtzfEndpoint, sony.ImagingEndpoint, sony.SystemEndpoint, sony.NetworkEndpoint)
+}
+
+// Translate converts Sony parameters to the AW responses reporting the same
+// state. Parameters without an AW equivalent are dropped.
+func (s *Sony) Translate(ps []sony.Parameter) []panasonic.AWResponse {
+	// The iris range must be known before any iris is translated.
+	for _, p := range ps {
+		if p, ok := p.(sony.ExposureIrisRangeParam); ok && p.Min > 0 && p.Min < p.Max {
+			s.lock.Lock()
+			s.irisMin, s.irisMax = p.Min, p.Max
+			s.lock.Unlock()
+		}
+	}
+	agc := false
+	for _, p := range ps {
+		if p, ok := p.(sony.ExposureAGCEnableParam); ok {
+			agc = p.Enable == sony.SwitchOn
+		}
+	}
+	var res []panasonic.AWResponse
+	for _, p := range ps {
+		switch p := p.(type) {
+		case sony.AbsolutePTZFParam:
+			res = append(res,
+				panasonic.AWPanTiltTo{Pan: panTiltToAW(p.Pan), Tilt: panTiltToAW(p.Tilt)},
+				panasonic.AWZoomTo{Zoom: panasonic.ScaleUnit(rescale(int(p.Zoom), int(sony.SteppedRangeZoomMax), int(panasonic.ScaleUnitMax)))},
+				panasonic.AWFocusTo{Focus: panasonic.ScaleUnit(rescale(int(p.Focus), int(sony.SteppedRangeFocusMax), int(panasonic.ScaleUnitMax)))},
+			)
+		case sony.ExposureIrisParam:
+			res = append(res, panasonic.AWIrisTo{Iris: s.irisToAW(p.Iris)})
+		case sony.ExposureAutoIrisParam:
+			res = append(res, panasonic.AWAutoIris{Enabled: toggleOf(p.Auto)})
+		case sony.ExposureAGCEnableParam:
+			if agc {
+				res = append(res, panasonic.AWGain{Gain: panasonic.DecibelAuto})
+			}
+		case sony.ExposureGainParam:
+			if !agc {
+				res = append(res, panasonic.AWGain{Gain: panasonic.Decibel(p.Gain)})
+			}
+		case sony.WhiteBalanceColorTempParam:
+			res = append(res, panasonic.AWColorTemp{Temp: panasonic.ToColorTemp(p.Kelvin)})
+		case sony.WhiteBalanceCrGainParam:
+			res = append(res, panasonic.AWRGainControl{Gain: int(math.Round(float64(p.Gain) / wbGainStep))})
+		case sony.WhiteBalanceCbGainParam:
+			res = append(res, panasonic.AWBGainControl{Gain: int(math.Round(float64(p.Gain) / wbGainStep))})
+		case sony.TallyLampRedParam:
+			res = append(res, panasonic.AWTallySet{TallyLight: toggleOf(p.Lamp)})
+		case sony.PowerStandbyParam:
+			power := panasonic.PowerOn
+			if p.Standby == sony.SwitchOn {
+				power = panasonic.PowerStandby
+			}
+			res = append(res, panasonic.AWPower{Power: power})
+		case sony.CameraNameParam:
+			res = append(res, panasonic.AWTitle{Title: string(p.Name)})
+		}
+	}
+	return res
+}
+
+// Notify forwards changes of the Sony camera to the AW notification sessions
+// of n, until ctx is done or the subscription fails.
+//
+// Notify always returns a non-nil error.
+func (s *Sony) Notify(ctx context.Context, n *panasonic.NotifyServer) error {
+	id, err := s.Client.Subscribe(notifyEndpoints...)
+	if err != nil {
+		return err
+	}
+	defer s.Client.Unsubscribe(id)
+	for {
+		ps, err := s.Client.PullInq(ctx, id)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil && ps == nil {
+			return err
+		}
+		for _, res := range s.Translate(ps) {
+			n.SendAll(res)
+		}
+	}
+}
+
+var _ panasonic.AWHandler = (*Sony)(nil)
+var _ panasonic.AWHandlerCtx = (*Sony)(nil)
@@ -0,0 +1,151 @@
+package awbridge
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"puzzlekraken.com/broadcastkit/panasonic"
+	"puzzlekraken.com/broadcastkit/sony"
+)
+
+// fakeSony answers inquiries with state and records the last set request.
+type fakeSony struct {
+	lock  sync.Mutex
+	state url.Values
+	set   url.Values
+}
+
+func (f *fakeSony) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if r.URL.Path == "/command/inquiry.cgi" {
+		io.WriteString(w, f.state.Encode())
+		return
+	}
+	f.set = r.URL.Query()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// bridgeServer serves a Sony bridge to AW clients over HTTP.
+func bridgeServer(t *testing.T, f *fakeSony) *httptest.Server {
+	cam := httptest.NewServer(f)
+	t.Cleanup(cam.Close)
+	b := &Sony{Client: &sony.CameraClient{Remote: netip.MustParseAddrPort(cam.Listener.Addr().String())}}
+	aw := httptest.NewServer(&panasonic.CameraServer{AWHandler: b})
+	t.Cleanup(aw.Close)
+	return aw
+}
+
+func awGet(t *testing.T, srv *httptest.Server, path string, cmd string) string {
+	t.Helper()
+	q := url.Values{"cmd": {cmd}, "res": {"1"}}
+	res, err := http.Get(srv.URL + path + "?" + q.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s %s: %s", path, cmd, res.Status)
+	}
+	return string(body)
+}
+
+func TestSonyCommands(t *testing.T) {
+	f := &fakeSony{state: url.Values{"AbsolutePTZF": {"00937,00000,0000,1000"}}}
+	srv := bridgeServer(t, f)
+	tests := []struct {
+		path string
+		cmd  string
+		key  string
+		want string
+	}{
+		{"/cgi-bin/aw_ptz", "#PTS9950", "PanTiltMove", "right,50,0"},
+		{"/cgi-bin/aw_ptz", "#PTS5001", "PanTiltMove", "down,0,50"},
+		{"/cgi-bin/aw_ptz", "#PTS5050", "PanTiltMove", "stop,0,0"},
+		{"/cgi-bin/aw_ptz", "#Z01", "ZoomMove", "wide,32766"},
+		{"/cgi-bin/aw_ptz", "#AXZFFF", "AbsolutePTZF", "00937,00000,4000,1000"},
+		{"/cgi-bin/aw_ptz", "#R05", "PresetCall", "6"},
+		{"/cgi-bin/aw_ptz", "#D31", "ExposureAutoIris", "on"},
+		{"/cgi-bin/aw_ptz", "#AXIFFF", "ExposureIris", "180"},
+		{"/cgi-bin/aw_ptz", "#AXI555", "ExposureIris", "1600"},
+		{"/cgi-bin/aw_ptz", "#DA1", "TallyLampRed", "on"},
+		{"/cgi-bin/aw_ptz", "#O0", "PowerStandby", "on"},
+		{"/cgi-bin/aw_cam", "OGU:14", "ExposureGain", "12"},
+		{"/cgi-bin/aw_cam", "OGU:08", "ExposureGain", "6"},
+		{"/cgi-bin/aw_cam", "OGU:38", "ExposureGain", "39"},
+		{"/cgi-bin/aw_cam", "OSD:B1:01E", "WhiteBalanceColorTemp", "5400"},
+		{"/cgi-bin/aw_cam", "ORG:28", "WhiteBalanceCrGain", "330"},
+		{"/cgi-bin/aw_cam", "OBG:14", "WhiteBalanceCbGain", "-330"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cmd, func(t *testing.T) {
+			awGet(t, srv, tt.path, tt.cmd)
+			f.lock.Lock()
+			got := f.set.Get(tt.key)
+			f.lock.Unlock()
+			if got != tt.want {
+				t.Errorf("%s = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSonyQueries(t *testing.T) {
+	f := &fakeSony{state: url.Values{
+		"AbsolutePTZF":       {"00937,00000,4000,0000"},
+		"ExposureIrisRange":  {"180,1600"},
+		"ExposureIris":       {"1600"},
+		"ExposureAGCEnable":  {"off"},
+		"ExposureGain":       {"12"},
+		"WhiteBalanceCrGain": {"330"},
+		"WhiteBalanceCbGain": {"-330"},
+		"PowerStandby":       {"off"},
+	}}
+	srv := bridgeServer(t, f)
+	tests := []struct {
+		path string
+		cmd  string
+		want panasonic.AWResponse
+	}{
+		{"/cgi-bin/aw_ptz", "#APC", panasonic.AWPanTiltTo{Pan: 1212}},
+		{"/cgi-bin/aw_ptz", "#AXZ", panasonic.AWZoomTo{Zoom: panasonic.ScaleUnitMax}},
+		{"/cgi-bin/aw_ptz", "#GZ", panasonic.AWZoomResponseAlternate{Zoom: panasonic.ScaleUnitMax}},
+		{"/cgi-bin/aw_ptz", "#AXI", panasonic.AWIrisTo{Iris: 0}},
+		{"/cgi-bin/aw_ptz", "#O", panasonic.AWPower{Power: panasonic.PowerOn}},
+		{"/cgi-bin/aw_cam", "QGU", panasonic.AWGain{Gain: 12}},
+		{"/cgi-bin/aw_cam", "QGR", panasonic.AWRGainControl{Gain: 10}},
+		{"/cgi-bin/aw_cam", "QGB", panasonic.AWBGainControl{Gain: -10}},
+		{"/cgi-bin/aw_ptz", "#LC1", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cmd, func(t *testing.T) {
+			got := awGet(t, srv, tt.path, tt.cmd)
+			want := "eR1:" + tt.cmd[:min(len(tt.cmd), 3)]
+			if tt.want != nil {
+				want = panasonic.FormatAWResponse(tt.want)
+			}
+			if got != want {
+				t.Errorf("%s = %q, want %q", tt.cmd, got, want)
+			}
+		})
+	}
+
+	res, err := http.Get(srv.URL + "/live/camdata.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	for _, want := range []string{"aPC", "axz", "axi", "p1", "OGU:0x14"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("camdata = %q, missing %q", body, want)
+		}
+	}
+}
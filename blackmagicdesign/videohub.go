@@ -5,18 +5,39 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
+
+	"puzzlekraken.com/broadcastkit/internal/wirelog"
+	"puzzlekraken.com/broadcastkit/metrics"
 )
 
 // VideohubSocket is a wrapper for the Blackmagic Videohub protocol.
 // Use VideohubDial to create a new connection.
 type VideohubSocket struct {
-	Conn  io.ReadWriteCloser
-	rlock sync.Mutex
-	scan  *bufio.Scanner
+	Conn io.ReadWriteCloser
+	// Logger, if set, receives every raw block at debug level and the blocks
+	// that failed to parse as warnings.
+	Logger *slog.Logger
+	// Metrics, if set, receives every written block as a command, named by
+	// its header and timed until written, and every block read as a
+	// notification. Replies arrive through Read, so their latency is not
+	// included.
+	Metrics metrics.Recorder
+	rlock   sync.Mutex
+	scan    *bufio.Scanner
+}
+
+// device names the remote end of Conn for Metrics.
+func (c *VideohubSocket) device() string {
+	if conn, ok := c.Conn.(net.Conn); ok {
+		return conn.RemoteAddr().String()
+	}
+	return ""
 }
 
 // VideohubBlock is the basic unit of data exchanged with a Videohub device.
@@ -98,13 +119,23 @@ func (l *VideohubListener) Addr() net.Addr {
 // Write writes a VideohubBlock to the connection.
 // Write can be made to time-out by SetDeadline or SetWriteDeadline.
 // See also: net.Conn.Write()
-func (c *VideohubSocket) Write(m VideohubBlock) error {
+func (c *VideohubSocket) Write(m VideohubBlock) (err error) {
+	if c.Metrics != nil {
+		defer func(start time.Time) {
+			var kind string
+			if err != nil {
+				kind = "SystemError"
+			}
+			c.Metrics.Command(c.device(), strings.TrimSuffix(m.header(), ":"), time.Since(start), kind)
+		}(time.Now())
+	}
 	var buf bytes.Buffer
 	buf.WriteString(m.header())
 	buf.WriteByte('\n')
 	m.dump(&buf)
 	buf.WriteByte('\n')
-	_, err := c.Conn.Write(buf.Bytes())
+	wirelog.Send(c.Logger, buf.Bytes())
+	_, err = c.Conn.Write(buf.Bytes())
 	if err != nil {
 		return fmt.Errorf("broadcastkit/blackmagicdesign: socket write: %w", err)
 	}
@@ -138,6 +169,10 @@ func (c *VideohubSocket) Read() (VideohubBlock, error) {
 		}
 
 		r := c.scan.Bytes()
+		wirelog.Recv(c.Logger, r)
+		if c.Metrics != nil {
+			c.Metrics.Notification(c.device(), metrics.Received)
+		}
 		header, body, ok := bytes.Cut(r, []byte("\n"))
 		if !ok {
 			// Specification states clients should ignore blocks that they
@@ -158,6 +193,7 @@ func (c *VideohubSocket) Read() (VideohubBlock, error) {
 
 		err := msg.parse(body)
 		if err != nil {
+			wirelog.Or(c.Logger).Warn("videohub parse", "header", string(header), "err", err)
 			return msg, fmt.Errorf("broadcastkit/blackmagicdesign: videohub parse: %w", err)
 		}
 
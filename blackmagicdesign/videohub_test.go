@@ -6,6 +6,8 @@ import (
 	"io"
 	"reflect"
 	"testing"
+
+	"puzzlekraken.com/broadcastkit/capture"
 )
 
 type closerBuffer struct {
@@ -320,3 +322,92 @@ var testBacon = []VideohubBlock{
 	},
 	&EndPreludeBlock{},
 }
+
+func TestVideohubSocket_Capture(t *testing.T) {
+	// The capture splits blocks across reads, like TCP segments do.
+	events, err := capture.ReadFile("testdata/videohub.capture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := VideohubSocket{
+		Conn: capture.Conn(events),
+	}
+	prelude := []VideohubBlock{
+		&ProtocolPreambleBlock{
+			Version: struct {
+				Major int
+				Minor int
+			}{
+				Major: 2,
+				Minor: 8,
+			},
+		},
+		&VideohubDeviceBlock{
+			DevicePresent:          DevicePresentTrue,
+			ModelName:              "Blackmagic Videohub 12G 10x10",
+			FriendlyName:           "Studio Hub",
+			UniqueID:               "7C2E0D021A5B",
+			VideoInputs:            4,
+			VideoProcessingUnits:   0,
+			VideoOutputs:           4,
+			VideoMonitoringOutputs: 0,
+			SerialPorts:            0,
+		},
+		&InputLabelsBlock{
+			Labels: Labels{0: "CAM 1", 1: "CAM 2", 2: "GFX", 3: "BLACK"},
+		},
+		&OutputLabelsBlock{
+			Labels: Labels{0: "PGM", 1: "MON 1", 2: "MON 2", 3: "REC"},
+		},
+		&VideoOutputLocksBlock{
+			Locks: Locks{0: LockUnlocked, 1: LockUnlocked, 2: LockUnlocked, 3: LockLocked},
+		},
+		&VideoOutputRoutingBlock{
+			Routing: Routing{0: 0, 1: 1, 2: 2, 3: 0},
+		},
+		&ConfigurationBlock{
+			TakeMode: false,
+		},
+		&EndPreludeBlock{},
+	}
+	for _, want := range prelude {
+		msg, err := v.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(msg, want) {
+			t.Fatalf("message %#v does not match %#v", msg, want)
+		}
+	}
+
+	exchange := []struct {
+		send VideohubBlock
+		want []VideohubBlock
+	}{
+		{
+			send: &VideoOutputRoutingBlock{Routing: Routing{1: 2}},
+			want: []VideohubBlock{&AckBlock{}, &VideoOutputRoutingBlock{Routing: Routing{1: 2}}},
+		},
+		{
+			send: &PingBlock{},
+			want: []VideohubBlock{&AckBlock{}},
+		},
+	}
+	for _, ex := range exchange {
+		if err := v.Write(ex.send); err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range ex.want {
+			msg, err := v.Read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(msg, want) {
+				t.Fatalf("message %#v does not match %#v", msg, want)
+			}
+		}
+	}
+	if _, err := v.Read(); !errors.Is(err, io.EOF) {
+		t.Fatalf("Videohub.Read() expected EOF, got %v", err)
+	}
+}
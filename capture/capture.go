@@ -0,0 +1,186 @@
+// Package capture records protocol sessions with devices and replays them as
+// fake devices.
+//
+// A capture file is a sequence of JSON objects, one per line. The first line is
+// a header, every other line is an Event. The format is stable and meant to be
+// checked into version control, new fields may be added but existing ones keep
+// their meaning:
+//
+//	{"capture":1,"start":"2024-05-01T10:00:00Z"}
+//	{"t":1520,"dir":"send","data":"VIDEO OUTPUT ROUTING:\n0 1\n\n"}
+//	{"t":3012,"dir":"recv","data":"ACK\n\n"}
+//	{"t":8120,"dir":"request","method":"GET","url":"/cgi-bin/aw_ptz?cmd=%23O&res=1"}
+//	{"t":9033,"dir":"response","status":200,"header":{"Content-Type":["text/plain"]},"data":"p1"}
+//
+// Times are microseconds since the start of the recording. Payloads which are
+// not valid UTF-8 are stored in the base64 field instead of data. HTTP bodies
+// over the recording limit, like clip downloads, only keep their beginning and
+// are marked "truncated":true.
+package capture
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+	"unicode/utf8"
+)
+
+// Version is the format version written into the header of capture files.
+const Version = 1
+
+// Direction tells which way an Event went, seen from the client.
+type Direction string
+
+const (
+	// Send is stream data written by the client to the device.
+	Send Direction = "send"
+	// Recv is stream data read by the client from the device.
+	Recv Direction = "recv"
+	// Request is an HTTP request sent to the device.
+	Request Direction = "request"
+	// Response is the HTTP response to the preceding Request.
+	Response Direction = "response"
+)
+
+// header is the first line of a capture file.
+type header struct {
+	Capture int       `json:"capture"`
+	Start   time.Time `json:"start"`
+}
+
+// Event is a single unit of recorded traffic.
+type Event struct {
+	// Time is the offset from the start of the recording.
+	Time      time.Duration
+	Direction Direction
+	// Data is the stream chunk, or the HTTP body.
+	Data []byte
+	// Method and URL are set for requests. URL is the request URI, the host
+	// is not recorded.
+	Method string
+	URL    string
+	// Status and Header are set for responses.
+	Status int
+	Header http.Header
+	// Truncated is set for HTTP bodies over the recording limit, Data only
+	// holds their beginning.
+	Truncated bool
+}
+
+// wireEvent is the on-disk form of Event.
+type wireEvent struct {
+	T      int64       `json:"t"`
+	Dir    Direction   `json:"dir"`
+	Method string      `json:"method,omitempty"`
+	URL    string      `json:"url,omitempty"`
+	Status int         `json:"status,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Data   *string     `json:"data,omitempty"`
+	Base64 []byte      `json:"base64,omitempty"`
+	Trunc  bool        `json:"truncated,omitempty"`
+}
+
+func (e Event) MarshalJSON() ([]byte, error) {
+	w := wireEvent{
+		T:      e.Time.Microseconds(),
+		Dir:    e.Direction,
+		Method: e.Method,
+		URL:    e.URL,
+		Status: e.Status,
+		Header: e.Header,
+		Trunc:  e.Truncated,
+	}
+	if utf8.Valid(e.Data) {
+		if len(e.Data) > 0 {
+			s := string(e.Data)
+			w.Data = &s
+		}
+	} else {
+		w.Base64 = e.Data
+	}
+	return json.Marshal(w)
+}
+
+func (e *Event) UnmarshalJSON(b []byte) error {
+	var w wireEvent
+	if err := json.Unmarshal(b, &w); err != nil {
+		return err
+	}
+	*e = Event{
+		Time:      time.Duration(w.T) * time.Microsecond,
+		Direction: w.Dir,
+		Method:    w.Method,
+		URL:       w.URL,
+		Status:    w.Status,
+		Header:    w.Header,
+		Data:      w.Base64,
+		Truncated: w.Trunc,
+	}
+	if w.Data != nil {
+		e.Data = []byte(*w.Data)
+	}
+	return nil
+}
+
+// Read parses a capture file.
+func Read(r io.Reader) ([]Event, error) {
+	scan := bufio.NewScanner(r)
+	// Lines hold whole HTTP bodies, like camera screenshots.
+	scan.Buffer(nil, 64<<20)
+	if !scan.Scan() {
+		if err := scan.Err(); err != nil {
+			return nil, fmt.Errorf("broadcastkit/capture: %w", err)
+		}
+		return nil, errors.New("broadcastkit/capture: empty capture")
+	}
+	var h header
+	if err := json.Unmarshal(scan.Bytes(), &h); err != nil || h.Capture == 0 {
+		return nil, errors.New("broadcastkit/capture: missing capture header")
+	}
+	if h.Capture > Version {
+		return nil, fmt.Errorf("broadcastkit/capture: unsupported capture version: %d", h.Capture)
+	}
+	var events []Event
+	for line := 2; scan.Scan(); line++ {
+		if len(scan.Bytes()) == 0 {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(scan.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("broadcastkit/capture: line %d: %w", line, err)
+		}
+		events = append(events, e)
+	}
+	if err := scan.Err(); err != nil {
+		return nil, fmt.Errorf("broadcastkit/capture: %w", err)
+	}
+	return events, nil
+}
+
+// ReadFile parses the capture file at path.
+func ReadFile(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Stream concatenates the data of all events in direction dir.
+//
+// It is handy to feed the received side of a capture to a parser.
+func Stream(events []Event, dir Direction) []byte {
+	var b []byte
+	for _, e := range events {
+		if e.Direction == dir {
+			b = append(b, e.Data...)
+		}
+	}
+	return b
+}
@@ -0,0 +1,213 @@
+package capture
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type memConn struct {
+	r io.Reader
+	w bytes.Buffer
+}
+
+func (c *memConn) Read(p []byte) (int, error)  { return c.r.Read(p) }
+func (c *memConn) Write(p []byte) (int, error) { return c.w.Write(p) }
+
+func TestRecordRead(t *testing.T) {
+	var file bytes.Buffer
+	rec, err := NewRecorder(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev := &memConn{r: strings.NewReader("ACK\n\n")}
+	conn := rec.Conn(dev)
+	conn.Write([]byte("PING:\n\n"))
+	io.ReadAll(conn)
+	rec.Record(Event{Direction: Recv, Data: []byte{0xc0, 0x00, 0xff}})
+	if err := rec.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := Read(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []Event
+	for _, e := range events {
+		e.Time = 0
+		got = append(got, e)
+	}
+	want := []Event{
+		{Direction: Send, Data: []byte("PING:\n\n")},
+		{Direction: Recv, Data: []byte("ACK\n\n")},
+		{Direction: Recv, Data: []byte{0xc0, 0x00, 0xff}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Read() = %+v, want %+v", got, want)
+	}
+	if s := Stream(events, Recv); !bytes.Equal(s, []byte("ACK\n\n\xc0\x00\xff")) {
+		t.Errorf("Stream() = %q", s)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty", ""},
+		{"no header", `{"t":0,"dir":"send","data":"x"}` + "\n"},
+		{"future version", `{"capture":99,"start":"2024-05-01T10:00:00Z"}` + "\n"},
+		{"bad event", `{"capture":1,"start":"2024-05-01T10:00:00Z"}` + "\n{\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Read(strings.NewReader(tt.input)); err == nil {
+				t.Errorf("Read() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestReplayConn(t *testing.T) {
+	events := []Event{
+		{Direction: Recv, Data: []byte("HELLO\n")},
+		{Direction: Send, Data: []byte("GET A\n")},
+		{Direction: Recv, Data: []byte("A=1\n")},
+	}
+
+	conn := Conn(events)
+	buf := make([]byte, 64)
+	n, err := conn.Read(buf)
+	if err != nil || string(buf[:n]) != "HELLO\n" {
+		t.Fatalf("Read() = %q, %v, want %q", buf[:n], err, "HELLO\n")
+	}
+	done := make(chan string)
+	go func() {
+		n, _ := conn.Read(buf)
+		done <- string(buf[:n])
+	}()
+	conn.Write([]byte("GET "))
+	select {
+	case s := <-done:
+		t.Fatalf("Read() = %q before the command was complete", s)
+	default:
+	}
+	conn.Write([]byte("A\n"))
+	if s := <-done; s != "A=1\n" {
+		t.Errorf("Read() = %q, want %q", s, "A=1\n")
+	}
+	if _, err := conn.Read(buf); err != io.EOF {
+		t.Errorf("Read() error = %v, want EOF", err)
+	}
+
+	conn = Conn(events)
+	if _, err := conn.Write([]byte("GET B\n")); !errors.Is(err, ErrMismatch) {
+		t.Errorf("Write() error = %v, want ErrMismatch", err)
+	}
+}
+
+func TestServer(t *testing.T) {
+	events := []Event{
+		{Direction: Request, Method: "GET", URL: "/cgi-bin/aw_ptz?cmd=%23O&res=1"},
+		{Direction: Response, Status: 200, Header: http.Header{"Content-Type": {"text/plain"}}, Data: []byte("p0")},
+		{Direction: Request, Method: "GET", URL: "/cgi-bin/aw_ptz?cmd=%23O&res=1"},
+		{Direction: Response, Status: 200, Data: []byte("p1")},
+		{Direction: Request, Method: "GET", URL: "/command/inquiry.cgi?inq=ptzf&_=1714557600000"},
+		{Direction: Response, Status: 200, Data: []byte("AbsolutePTZF=0,0,0,0")},
+	}
+
+	get := func(c *http.Client, base, uri string) (int, string) {
+		t.Helper()
+		res, err := c.Get(base + uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		b, _ := io.ReadAll(res.Body)
+		return res.StatusCode, string(b)
+	}
+
+	httpSrv := httptest.NewServer(NewServer(events))
+	defer httpSrv.Close()
+	clients := []struct {
+		name   string
+		client *http.Client
+		base   string
+	}{
+		{"handler", httpSrv.Client(), httpSrv.URL},
+		{"transport", &http.Client{Transport: NewServer(events)}, "http://192.0.2.1"},
+	}
+	for _, c := range clients {
+		t.Run(c.name, func(t *testing.T) {
+			tests := []struct {
+				uri    string
+				status int
+				body   string
+			}{
+				{"/cgi-bin/aw_ptz?cmd=%23O&res=1", 200, "p0"},
+				{"/cgi-bin/aw_ptz?cmd=%23O&res=1", 200, "p1"},
+				{"/cgi-bin/aw_ptz?cmd=%23O&res=1", 200, "p1"},
+				{"/command/inquiry.cgi?inq=ptzf&_=1760000000000", 200, "AbsolutePTZF=0,0,0,0"},
+				{"/cgi-bin/aw_ptz?cmd=%23P&res=1", 404, "404 page not found\n"},
+			}
+			for _, tt := range tests {
+				status, body := get(c.client, c.base, tt.uri)
+				if status != tt.status || body != tt.body {
+					t.Errorf("GET %s = %d %q, want %d %q", tt.uri, status, body, tt.status, tt.body)
+				}
+			}
+		})
+	}
+}
+
+func TestRecordRoundTripper(t *testing.T) {
+	defer func(n int) { maxRecordedBody = n }(maxRecordedBody)
+	maxRecordedBody = 8
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.Query().Get("body"))
+	}))
+	defer srv.Close()
+
+	var file bytes.Buffer
+	rec, err := NewRecorder(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: rec.RoundTripper(nil)}
+	for _, body := range []string{"short", "a body over the limit"} {
+		res, err := client.Get(srv.URL + "/clip?body=" + strings.ReplaceAll(body, " ", "+"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil || string(b) != body {
+			t.Errorf("body = %q, %v, want %q", b, err, body)
+		}
+	}
+
+	events, err := Read(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []Event
+	for _, e := range events {
+		if e.Direction == Response {
+			got = append(got, Event{Direction: Response, Data: e.Data, Truncated: e.Truncated})
+		}
+	}
+	want := []Event{
+		{Direction: Response, Data: []byte("short")},
+		{Direction: Response, Data: []byte("a body o"), Truncated: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("recorded responses = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,159 @@
+package capture
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Recorder writes traffic to a capture file.
+//
+// A Recorder is meant for a single device session. Connections and round
+// trippers returned by it share the file and the clock.
+//
+// Recorder is safe to use from multiple goroutines.
+type Recorder struct {
+	lock  sync.Mutex
+	w     io.Writer
+	enc   *json.Encoder
+	start time.Time
+	err   error
+}
+
+// NewRecorder starts a recording into w by writing the capture header.
+func NewRecorder(w io.Writer) (*Recorder, error) {
+	r := &Recorder{
+		w:     w,
+		enc:   json.NewEncoder(w),
+		start: time.Now(),
+	}
+	if err := r.enc.Encode(header{Capture: Version, Start: r.start.UTC()}); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Record appends events to the capture, stamping them with the current time.
+//
+// Events passed together are kept adjacent in the file. After the first write
+// error, the recording stops and Err reports the error.
+func (r *Recorder) Record(events ...Event) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.err != nil {
+		return
+	}
+	t := time.Since(r.start)
+	for _, e := range events {
+		e.Time = t
+		if err := r.enc.Encode(e); err != nil {
+			r.err = err
+			return
+		}
+	}
+}
+
+// Err returns the first error encountered writing the capture.
+func (r *Recorder) Err() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.err
+}
+
+// Conn wraps rw, recording everything read and written through it.
+//
+// The returned connection can be set as the Conn of VideohubSocket, ScpSocket
+// or MetusSocket. Closing it closes rw, if rw is an io.Closer.
+func (r *Recorder) Conn(rw io.ReadWriter) io.ReadWriteCloser {
+	return &recordConn{rw: rw, rec: r}
+}
+
+type recordConn struct {
+	rw  io.ReadWriter
+	rec *Recorder
+}
+
+func (c *recordConn) Read(p []byte) (int, error) {
+	n, err := c.rw.Read(p)
+	if n > 0 {
+		c.rec.Record(Event{Direction: Recv, Data: bytes.Clone(p[:n])})
+	}
+	return n, err
+}
+
+func (c *recordConn) Write(p []byte) (int, error) {
+	n, err := c.rw.Write(p)
+	if n > 0 {
+		c.rec.Record(Event{Direction: Send, Data: bytes.Clone(p[:n])})
+	}
+	return n, err
+}
+
+func (c *recordConn) Close() error {
+	if cl, ok := c.rw.(io.Closer); ok {
+		return cl.Close()
+	}
+	return nil
+}
+
+// SetDeadline is passed through, so timeouts of the wrapped net.Conn keep
+// working.
+func (c *recordConn) SetDeadline(t time.Time) error {
+	if d, ok := c.rw.(interface{ SetDeadline(time.Time) error }); ok {
+		return d.SetDeadline(t)
+	}
+	return nil
+}
+
+// maxRecordedBody is the size of the largest HTTP body recorded whole, well
+// below the line limit of Read.
+var maxRecordedBody = 16 << 20
+
+// RoundTripper wraps rt, recording every request and its response. If rt is
+// nil, http.DefaultTransport is used.
+//
+// Response bodies are read completely before they are returned to the caller,
+// up to 16 MiB. Larger bodies, like clip downloads, are passed on without
+// buffering them whole, and only their first 16 MiB are recorded.
+func (r *Recorder) RoundTripper(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &recordTransport{rt: rt, rec: r}
+}
+
+type recordTransport struct {
+	rt  http.RoundTripper
+	rec *Recorder
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.rt.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, int64(maxRecordedBody)+1))
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	truncated := len(body) > maxRecordedBody
+	if truncated {
+		res.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), res.Body), res.Body}
+		body = body[:maxRecordedBody]
+	} else {
+		res.Body.Close()
+		res.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	t.rec.Record(
+		Event{Direction: Request, Method: req.Method, URL: req.URL.RequestURI()},
+		Event{Direction: Response, Status: res.StatusCode, Header: res.Header.Clone(), Data: body, Truncated: truncated},
+	)
+	return res, nil
+}
@@ -0,0 +1,215 @@
+package capture
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrMismatch is returned when the client diverges from the recorded session.
+var ErrMismatch = errors.New("broadcastkit/capture: traffic differs from capture")
+
+// Conn returns a fake device replaying the stream events of a capture.
+//
+// Reads return the recorded received data in order. The data received after a
+// send in the recording is only delivered after the client has written that
+// much, so replies never overtake their commands. Writes are compared with the
+// recorded sent data and fail with ErrMismatch on the first difference. Chunk
+// boundaries need not match the recording, and timing is not replayed.
+//
+// After the recorded data is exhausted, Read returns io.EOF.
+func Conn(events []Event) io.ReadWriteCloser {
+	c := &replayConn{}
+	c.cond.L = &c.lock
+	for _, e := range events {
+		switch e.Direction {
+		case Send:
+			c.send = append(c.send, e.Data...)
+		case Recv:
+			if len(e.Data) > 0 {
+				c.recv = append(c.recv, replayChunk{after: len(c.send), data: e.Data})
+			}
+		}
+	}
+	return c
+}
+
+type replayChunk struct {
+	after int // bytes the client must have sent before the chunk
+	data  []byte
+}
+
+type replayConn struct {
+	lock   sync.Mutex
+	cond   sync.Cond
+	send   []byte
+	sent   int
+	recv   []replayChunk
+	err    error
+	closed bool
+}
+
+func (c *replayConn) Read(p []byte) (int, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for {
+		switch {
+		case c.closed:
+			return 0, io.ErrClosedPipe
+		case c.err != nil:
+			return 0, c.err
+		case len(c.recv) == 0:
+			return 0, io.EOF
+		case c.sent >= c.recv[0].after:
+			n := copy(p, c.recv[0].data)
+			c.recv[0].data = c.recv[0].data[n:]
+			if len(c.recv[0].data) == 0 {
+				c.recv = c.recv[1:]
+			}
+			return n, nil
+		}
+		c.cond.Wait()
+	}
+}
+
+func (c *replayConn) Write(p []byte) (int, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	defer c.cond.Broadcast()
+	if c.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if c.err != nil {
+		return 0, c.err
+	}
+	want := c.send[c.sent:]
+	n := 0
+	for n < len(p) && n < len(want) && p[n] == want[n] {
+		n++
+	}
+	c.sent += n
+	if n < len(p) {
+		c.err = fmt.Errorf("%w: sent %q at offset %d, recorded %q", ErrMismatch, p[n:], c.sent, want[n:min(len(want), n+len(p))])
+		return n, c.err
+	}
+	return n, nil
+}
+
+func (c *replayConn) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.closed = true
+	c.cond.Broadcast()
+	return nil
+}
+
+// Server replays the HTTP events of a capture.
+//
+// Server is both an http.Handler, to serve a fake device over the network, and
+// an http.RoundTripper, to plug into a client directly. Each request is
+// answered with the response recorded for the next unused request of the same
+// method and URL. When all of them are used, the last response is repeated.
+// Requests never recorded are answered with 404 Not Found.
+//
+// The "_" query parameter, used by clients to defeat caches, is ignored when
+// matching since its value changes on every request.
+//
+// Server is safe to use from multiple goroutines.
+type Server struct {
+	lock  sync.Mutex
+	pairs map[string][]Event
+	used  map[string]int
+}
+
+// NewServer returns a Server replaying events.
+func NewServer(events []Event) *Server {
+	s := &Server{
+		pairs: make(map[string][]Event),
+		used:  make(map[string]int),
+	}
+	for i, e := range events {
+		if e.Direction != Request || i+1 >= len(events) || events[i+1].Direction != Response {
+			continue
+		}
+		k := e.Method + " " + stripCacheKill(e.URL)
+		s.pairs[k] = append(s.pairs[k], events[i+1])
+	}
+	return s
+}
+
+// lookup returns the response for the request, or false if none is recorded.
+func (s *Server) lookup(method, uri string) (Event, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	k := method + " " + stripCacheKill(uri)
+	list := s.pairs[k]
+	if len(list) == 0 {
+		return Event{}, false
+	}
+	i := min(s.used[k], len(list)-1)
+	s.used[k]++
+	return list[i], true
+}
+
+// stripCacheKill removes the "_" parameter from the query of a request URI.
+func stripCacheKill(uri string) string {
+	path, query, ok := strings.Cut(uri, "?")
+	if !ok {
+		return uri
+	}
+	var keep []string
+	for _, p := range strings.Split(query, "&") {
+		if p != "_" && !strings.HasPrefix(p, "_=") {
+			keep = append(keep, p)
+		}
+	}
+	if len(keep) == 0 {
+		return path
+	}
+	return path + "?" + strings.Join(keep, "&")
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e, ok := s.lookup(r.Method, r.URL.RequestURI())
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	for k, v := range e.Header {
+		if k == "Content-Length" {
+			continue
+		}
+		w.Header()[k] = v
+	}
+	w.WriteHeader(e.Status)
+	w.Write(e.Data)
+}
+
+func (s *Server) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Body != nil {
+		r.Body.Close()
+	}
+	e, ok := s.lookup(r.Method, r.URL.RequestURI())
+	if !ok {
+		e = Event{Status: http.StatusNotFound, Data: []byte("404 page not found\n")}
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(e.Status) + " " + http.StatusText(e.Status),
+		StatusCode:    e.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.Data)),
+		ContentLength: int64(len(e.Data)),
+		Request:       r,
+	}, nil
+}
+
+var _ http.Handler = (*Server)(nil)
+var _ http.RoundTripper = (*Server)(nil)
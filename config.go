@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// deviceConfig is the connection setup of a device.
+type deviceConfig struct {
+	Address  string `json:"address"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// Log is the level of protocol logging, empty for none.
+	Log string `json:"log,omitempty"`
+}
+
+// config is the content of the config file, keyed by device name.
+//
+//	{
+//		"sony": {"address": "10.0.0.20", "username": "admin", "password": "..."},
+//		"videohub": {"address": "10.0.0.30", "log": "debug"}
+//	}
+type config map[string]deviceConfig
+
+// loadConfig reads the config file at path.
+//
+// If path is empty, broadcastkit/config.json in the user config directory is
+// read if it exists.
+func loadConfig(path string) (config, error) {
+	explicit := path != ""
+	if !explicit {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return config{}, nil
+		}
+		path = filepath.Join(dir, "broadcastkit", "config.json")
+	}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	var c config
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// resolve looks up host[:port] and returns an IPv4 address, using port if the
+// address has none.
+func resolve(addr string, port uint16) (netip.AddrPort, error) {
+	host, p, err := net.SplitHostPort(addr)
+	if err != nil {
+		host, p = addr, strconv.Itoa(int(port))
+	}
+	a, err := net.ResolveTCPAddr("tcp4", net.JoinHostPort(host, p))
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	ap := a.AddrPort()
+	return netip.AddrPortFrom(ap.Addr().Unmap(), ap.Port()), nil
+}
+
+// newLogger returns a logger writing to w at the named level, tagged with the
+// device name, or nil if level is empty.
+func newLogger(level string, device string, w io.Writer) (*slog.Logger, error) {
+	if level == "" {
+		return nil, nil
+	}
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("log level: %w", err)
+	}
+	h := slog.NewTextHandler(w, &slog.HandlerOptions{Level: l})
+	return slog.New(h).With("device", device), nil
+}
+
+// printer writes results either as text or as JSON lines.
+type printer struct {
+	json bool
+	w    io.Writer
+}
+
+// print writes v as JSON, or text followed by a newline.
+func (p printer) print(v any, text string) error {
+	if p.json {
+		return json.NewEncoder(p.w).Encode(v)
+	}
+	_, err := fmt.Fprintln(p.w, text)
+	return err
+}
+
+// typeName returns the unqualified name of the type of v.
+func typeName(v any) string {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// normalizeName folds case and drops blanks for matching names typed on the
+// command line against names like "ME1KEY1 F".
+func normalizeName(s string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "_", "", "-", "").Replace(s))
+}
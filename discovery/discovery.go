@@ -0,0 +1,120 @@
+// Package discovery finds broadcast devices on the local network.
+//
+// Every vendor is searched by its own mechanism, implemented as a Searcher:
+// Panasonic cameras and switchers answer the Easy IP Setup search, Sony
+// cameras answer SSDP, Blackmagic devices announce themselves over mDNS and
+// Yamaha mixers are probed over their SCP port. Search runs them together.
+//
+// The Addr of a found Device includes the control port, so it can be passed
+// to the matching client:
+//
+//	for _, d := range devices {
+//		switch d.Kind {
+//		case discovery.Camera:
+//			if d.Vendor == discovery.Panasonic {
+//				cam := &panasonic.CameraClient{Remote: d.Addr}
+//			}
+//		case discovery.Router:
+//			hub, err := blackmagicdesign.DialVideohub(d.Addr.String())
+//		}
+//	}
+package discovery
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"slices"
+	"sync"
+)
+
+// Vendor is the manufacturer of a device.
+type Vendor string
+
+const (
+	Panasonic  Vendor = "panasonic"
+	Sony       Vendor = "sony"
+	Blackmagic Vendor = "blackmagicdesign"
+	Yamaha     Vendor = "yamaha"
+)
+
+// Kind is the role of a device, telling which client controls it. It is empty
+// for devices this library has no client for.
+type Kind string
+
+const (
+	Camera   Kind = "camera"
+	Switcher Kind = "switcher"
+	Router   Kind = "router"
+	Mixer    Kind = "mixer"
+)
+
+// Device is a device found on the network.
+//
+// Model, Name and MAC are only set if the discovery mechanism reports them.
+type Device struct {
+	Vendor Vendor
+	Kind   Kind
+	Model  string
+	Name   string
+	MAC    net.HardwareAddr
+	// Addr is the address and control port of the device.
+	Addr netip.AddrPort
+}
+
+// Searcher is a discovery mechanism.
+type Searcher interface {
+	// Search looks for devices until ctx is done, or the mechanism has no
+	// more addresses to try, calling found for each.
+	// A device may be reported more than once. found is not called
+	// concurrently.
+	Search(ctx context.Context, found func(Device)) error
+}
+
+// DefaultSearchers returns the searchers used by Search when none are given,
+// with the standard ports and addresses of each mechanism.
+func DefaultSearchers() []Searcher {
+	return []Searcher{
+		&EasyIP{},
+		&SSDP{},
+		&MDNS{},
+		&SCPProbe{},
+	}
+}
+
+// Search runs searchers concurrently until ctx is done and returns the
+// devices found, each once. Bound the search with a timeout on ctx; a few
+// seconds are enough on a local network.
+//
+// If no searchers are passed, DefaultSearchers are used. Devices found are
+// returned along with the errors of searchers which failed.
+func Search(ctx context.Context, searchers ...Searcher) ([]Device, error) {
+	if len(searchers) == 0 {
+		searchers = DefaultSearchers()
+	}
+	var lock sync.Mutex
+	var devices []Device
+	found := func(d Device) {
+		lock.Lock()
+		defer lock.Unlock()
+		i := slices.IndexFunc(devices, func(o Device) bool {
+			return o.Vendor == d.Vendor && o.Addr == d.Addr
+		})
+		if i < 0 {
+			devices = append(devices, d)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(searchers))
+	for i, s := range searchers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = s.Search(ctx, found)
+		}()
+	}
+	wg.Wait()
+	return devices, errors.Join(errs...)
+}
@@ -0,0 +1,249 @@
+package discovery
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// standIn answers every UDP packet received on a loopback port with the
+// packets returned by reply.
+func standIn(t *testing.T, reply func(req []byte) [][]byte) netip.AddrPort {
+	t.Helper()
+	conn, err := net.ListenUDP("udp4", net.UDPAddrFromAddrPort(netip.MustParseAddrPort("127.0.0.1:0")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 9000)
+		for {
+			n, from, err := conn.ReadFromUDPAddrPort(buf)
+			if err != nil {
+				return
+			}
+			for _, b := range reply(buf[:n]) {
+				conn.WriteToUDPAddrPort(b, from)
+			}
+		}
+	}()
+	return netip.MustParseAddrPort(conn.LocalAddr().String())
+}
+
+// searchOne runs a searcher shortly and returns what it found.
+func searchOne(t *testing.T, s Searcher) []Device {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	devices, err := Search(ctx, s)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	return devices
+}
+
+func easyIPReplyPacket(mac net.HardwareAddr, ip netip.Addr, port uint16, model, title string) []byte {
+	b := make([]byte, 82)
+	b[1] = 0x01
+	binary.BigEndian.PutUint16(b[2:], uint16(len(b)))
+	binary.BigEndian.PutUint16(b[4:], easyIPReply)
+	copy(b[12:], mac)
+	ip4 := ip.As4()
+	copy(b[18:], ip4[:])
+	binary.BigEndian.PutUint16(b[30:], port)
+	copy(b[34:50], model)
+	copy(b[50:82], title)
+	return b
+}
+
+func TestEasyIP(t *testing.T) {
+	cameraMAC := net.HardwareAddr{0x00, 0x80, 0x45, 0x12, 0x34, 0x56}
+	switcherMAC := net.HardwareAddr{0x00, 0x80, 0x45, 0xab, 0xcd, 0xef}
+	target := standIn(t, func(req []byte) [][]byte {
+		if len(req) < 6 || binary.BigEndian.Uint16(req[4:]) != easyIPSearch {
+			return nil
+		}
+		return [][]byte{
+			req, // searches of other clients are heard too
+			easyIPReplyPacket(cameraMAC, netip.MustParseAddr("192.0.2.10"), 80, "AW-UE150", "CAM 1"),
+			easyIPReplyPacket(switcherMAC, netip.MustParseAddr("192.0.2.20"), 80, "AV-HS6000", "MAIN"),
+			[]byte("garbage"),
+		}
+	})
+
+	got := searchOne(t, &EasyIP{Target: target, Listen: netip.MustParseAddrPort("127.0.0.1:0")})
+	want := []Device{
+		{Vendor: Panasonic, Kind: Camera, Model: "AW-UE150", Name: "CAM 1", MAC: cameraMAC, Addr: netip.MustParseAddrPort("192.0.2.10:80")},
+		{Vendor: Panasonic, Kind: Switcher, Model: "AV-HS6000", Name: "MAIN", MAC: switcherMAC, Addr: netip.MustParseAddrPort("192.0.2.20:62000")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Search() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSSDP(t *testing.T) {
+	descriptions := map[string]string{
+		"/sony.xml":  `<?xml version="1.0"?><root xmlns="urn:schemas-upnp-org:device-1-0"><device><friendlyName>STUDIO A</friendlyName><manufacturer>Sony Corporation</manufacturer><modelName>ILME-FR7</modelName></device></root>`,
+		"/other.xml": `<?xml version="1.0"?><root xmlns="urn:schemas-upnp-org:device-1-0"><device><friendlyName>Printer</friendlyName><manufacturer>ACME</manufacturer><modelName>P1</modelName></device></root>`,
+	}
+	web := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, descriptions[r.URL.Path])
+	}))
+	defer web.Close()
+
+	target := standIn(t, func(req []byte) [][]byte {
+		r, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(req)))
+		if err != nil || r.Method != "M-SEARCH" || r.Header.Get("Man") != `"ssdp:discover"` {
+			return nil
+		}
+		var replies [][]byte
+		for _, path := range []string{"/sony.xml", "/other.xml"} {
+			replies = append(replies, []byte("HTTP/1.1 200 OK\r\n"+
+				"CACHE-CONTROL: max-age=1800\r\n"+
+				"LOCATION: "+web.URL+path+"\r\n"+
+				"ST: "+r.Header.Get("St")+"\r\n"+
+				"USN: uuid:00000000-0000-1010-8000-0080451234ab::upnp:rootdevice\r\n\r\n"))
+		}
+		return replies
+	})
+
+	got := searchOne(t, &SSDP{Target: target})
+	want := []Device{
+		{Vendor: Sony, Kind: Camera, Model: "ILME-FR7", Name: "STUDIO A", Addr: netip.MustParseAddrPort("127.0.0.1:80")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Search() = %+v, want %+v", got, want)
+	}
+}
+
+// dnsAppendRecord appends a resource record with uncompressed names.
+func dnsAppendRecord(b []byte, name string, typ uint16, rdata []byte) []byte {
+	b = dnsAppendName(b, name)
+	b = binary.BigEndian.AppendUint16(b, typ)
+	b = binary.BigEndian.AppendUint16(b, 0x8001) // cache flush, class IN
+	b = binary.BigEndian.AppendUint32(b, 120)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(rdata)))
+	return append(b, rdata...)
+}
+
+func TestMDNS(t *testing.T) {
+	const service = "_blackmagic._tcp.local."
+	const instance = "Videohub 12G._blackmagic._tcp.local."
+	target := standIn(t, func(req []byte) [][]byte {
+		records, err := dnsParse(req)
+		if err != nil || len(records) != 0 {
+			return nil
+		}
+		// Answer in two messages, with the service records using a
+		// compressed name pointing into the PTR record.
+		first := make([]byte, 12)
+		binary.BigEndian.PutUint16(first[2:], 0x8400)
+		binary.BigEndian.PutUint16(first[6:], 1)
+		first = dnsAppendRecord(first, service, dnsTypePTR, dnsAppendName(nil, instance))
+
+		second := make([]byte, 12)
+		binary.BigEndian.PutUint16(second[2:], 0x8400)
+		binary.BigEndian.PutUint16(second[6:], 3)
+		srv := []byte{0, 0, 0, 0, 0x27, 0x06} // port 9990
+		srv = dnsAppendName(srv, "videohub.local.")
+		second = dnsAppendRecord(second, instance, dnsTypeSRV, srv)
+		var txt []byte
+		for _, kv := range []string{"class=Videohub", "name=Studio Hub", "unique id=7C2E0D021A5B"} {
+			txt = append(txt, byte(len(kv)))
+			txt = append(txt, kv...)
+		}
+		second = append(second, 0xc0, 12) // the instance name of the SRV record
+		second = binary.BigEndian.AppendUint16(second, dnsTypeTXT)
+		second = binary.BigEndian.AppendUint16(second, 0x8001)
+		second = binary.BigEndian.AppendUint32(second, 120)
+		second = binary.BigEndian.AppendUint16(second, uint16(len(txt)))
+		second = append(second, txt...)
+		second = dnsAppendRecord(second, "videohub.local.", dnsTypeA, []byte{192, 0, 2, 30})
+		return [][]byte{first, second}
+	})
+
+	got := searchOne(t, &MDNS{Target: target})
+	want := []Device{
+		{
+			Vendor: Blackmagic,
+			Kind:   Router,
+			Model:  "Videohub",
+			Name:   "Studio Hub",
+			MAC:    net.HardwareAddr{0x7c, 0x2e, 0x0d, 0x02, 0x1a, 0x5b},
+			Addr:   netip.MustParseAddrPort("192.0.2.30:9990"),
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Search() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSCPProbe(t *testing.T) {
+	lis, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				line, err := bufio.NewReader(conn).ReadString('\n')
+				if err != nil || line != "devinfo productname\n" {
+					return
+				}
+				fmt.Fprint(conn, "NOTIFY set MIXER:Current/InCh/Fader/Level 0 0 -1200\n")
+				fmt.Fprint(conn, "OK devinfo productname \"TF5\"\n")
+			}()
+		}
+	}()
+	port := netip.MustParseAddrPort(lis.Addr().String()).Port()
+
+	got := searchOne(t, &SCPProbe{Prefix: netip.MustParsePrefix("127.0.0.0/30"), Port: port})
+	want := []Device{
+		{Vendor: Yamaha, Kind: Mixer, Model: "TF5", Addr: netip.AddrPortFrom(netip.MustParseAddr("127.0.0.1"), port)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Search() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDNSParse_Malformed(t *testing.T) {
+	loop := make([]byte, 12)
+	binary.BigEndian.PutUint16(loop[6:], 1)
+	loop = append(loop, 0xc0, 12) // name pointing at itself
+
+	truncated := dnsQuery("_x._tcp.local.")
+	binary.BigEndian.PutUint16(truncated[6:], 1)
+	truncated = append(truncated, 5, 'a')
+
+	tests := []struct {
+		name string
+		msg  []byte
+	}{
+		{"empty", nil},
+		{"short header", make([]byte, 11)},
+		{"pointer loop", loop},
+		{"truncated record", truncated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := dnsParse(tt.msg); err == nil {
+				t.Errorf("dnsParse() error = nil, want error")
+			}
+		})
+	}
+}
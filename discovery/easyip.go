@@ -0,0 +1,166 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// EasyIP searches Panasonic cameras and switchers with the UDP protocol of the
+// Easy IP Setup software.
+//
+// The search is broadcast to port 10670, and devices broadcast their replies
+// to port 10669 of the network, which is why the replies are received on a
+// fixed port by default.
+type EasyIP struct {
+	// Target is where the search is sent, 255.255.255.255:10670 by default.
+	Target netip.AddrPort
+	// Listen is the local address the search is sent from and replies are
+	// received on, 0.0.0.0:10669 by default.
+	Listen netip.AddrPort
+	// Interval is the period of repeating the search, as UDP may be lost.
+	// It is one second by default.
+	Interval time.Duration
+}
+
+const (
+	easyIPSearch = 0x000d
+	easyIPReply  = 0x000e
+)
+
+// easyIPRequest is the search packet.
+//
+// The protocol is not documented, the layout follows reverse engineered
+// captures. Format is:
+// -  2 bytes constant 00 01
+// -  2 bytes packet length, uint16 big endian
+// -  2 bytes operation, 00 0d for search
+// -  6 bytes zero
+// -  6 bytes MAC of the searcher, may be zero
+// -  4 bytes IPv4 of the searcher, may be zero
+// - 20 bytes zero
+func easyIPRequest() []byte {
+	b := make([]byte, 42)
+	b[1] = 0x01
+	binary.BigEndian.PutUint16(b[2:], uint16(len(b)))
+	binary.BigEndian.PutUint16(b[4:], easyIPSearch)
+	return b
+}
+
+// easyIPParse reads a reply packet. Packets which are not replies, like the
+// searches of other clients on the network, are reported as not ok.
+//
+// Format is:
+// -  2 bytes constant 00 01
+// -  2 bytes packet length, uint16 big endian
+// -  2 bytes operation, 00 0e for reply
+// -  6 bytes unknown
+// -  6 bytes MAC of the device
+// -  4 bytes IPv4 address, subnet mask and gateway each
+// -  2 bytes HTTP port, uint16 big endian
+// -  2 bytes unknown
+// - 16 bytes model name, padded with zero bytes
+// - 32 bytes device title, padded with zero bytes
+func easyIPParse(b []byte) (Device, bool) {
+	if len(b) < 82 || b[1] != 0x01 || binary.BigEndian.Uint16(b[4:]) != easyIPReply {
+		return Device{}, false
+	}
+	if int(binary.BigEndian.Uint16(b[2:])) != len(b) {
+		return Device{}, false
+	}
+	ip := netip.AddrFrom4([4]byte(b[18:22]))
+	port := binary.BigEndian.Uint16(b[30:])
+	d := Device{
+		Vendor: Panasonic,
+		Kind:   Camera,
+		Model:  cString(b[34:50]),
+		Name:   cString(b[50:82]),
+		MAC:    net.HardwareAddr(bytes.Clone(b[12:18])),
+	}
+	if strings.HasPrefix(d.Model, "AV-HS") {
+		// Switchers are controlled over their own TCP port, not HTTP.
+		d.Kind = Switcher
+		port = 62000
+	} else if port == 0 {
+		port = 80
+	}
+	d.Addr = netip.AddrPortFrom(ip, port)
+	return d, true
+}
+
+// cString returns the text of b up to the first zero byte.
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(string(b))
+}
+
+func (s *EasyIP) Search(ctx context.Context, found func(Device)) error {
+	target := s.Target
+	if !target.IsValid() {
+		target = netip.AddrPortFrom(netip.AddrFrom4([4]byte{255, 255, 255, 255}), 10670)
+	}
+	listen := s.Listen
+	if !listen.IsValid() {
+		listen = netip.AddrPortFrom(netip.IPv4Unspecified(), 10669)
+	}
+	interval := s.Interval
+	if interval == 0 {
+		interval = time.Second
+	}
+
+	conn, err := net.ListenUDP("udp4", net.UDPAddrFromAddrPort(listen))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return udpSearch(ctx, conn, interval, func() error {
+		_, err := conn.WriteToUDPAddrPort(easyIPRequest(), target)
+		return err
+	}, func(b []byte, _ netip.AddrPort) {
+		if d, ok := easyIPParse(b); ok {
+			found(d)
+		}
+	})
+}
+
+// udpSearch repeats send every interval and passes the received packets to
+// recv until ctx is done.
+func udpSearch(ctx context.Context, conn *net.UDPConn, interval time.Duration, send func() error, recv func([]byte, netip.AddrPort)) error {
+	stop := context.AfterFunc(ctx, func() { conn.SetReadDeadline(time.Unix(1, 0)) })
+	defer stop()
+
+	if err := send(); err != nil {
+		return err
+	}
+	next := time.Now().Add(interval)
+	buf := make([]byte, 9000)
+	for {
+		conn.SetReadDeadline(next)
+		if ctx.Err() != nil {
+			return nil
+		}
+		n, from, err := conn.ReadFromUDPAddrPort(buf)
+		if ctx.Err() != nil {
+			return nil
+		}
+		var timeout net.Error
+		if errors.As(err, &timeout) && timeout.Timeout() {
+			if err := send(); err != nil {
+				return err
+			}
+			next = time.Now().Add(interval)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		recv(buf[:n], netip.AddrPortFrom(from.Addr().Unmap(), from.Port()))
+	}
+}
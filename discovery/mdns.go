@@ -0,0 +1,295 @@
+package discovery
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// MDNS searches Blackmagic devices with multicast DNS service discovery.
+//
+// Blackmagic devices register the _blackmagic._tcp service with TXT records
+// describing them. The query is sent from an ephemeral port, so responders
+// answer with unicast to the searcher.
+type MDNS struct {
+	// Target is where the query is sent, 224.0.0.251:5353 by default.
+	Target netip.AddrPort
+	// Service is the service type queried, _blackmagic._tcp.local. by
+	// default.
+	Service string
+	// Interval is the period of repeating the query, one second by default.
+	Interval time.Duration
+}
+
+const (
+	dnsTypeA   = 1
+	dnsTypePTR = 12
+	dnsTypeTXT = 16
+	dnsTypeSRV = 33
+)
+
+// dnsRecord is a resource record of the types we use.
+type dnsRecord struct {
+	Name string
+	Type uint16
+	// Target is the domain name of PTR and SRV records.
+	Target string
+	Port   uint16
+	TXT    []string
+	A      netip.Addr
+}
+
+// dnsQuery returns a query for the PTR records of name.
+func dnsQuery(name string) []byte {
+	b := make([]byte, 12, 64)
+	binary.BigEndian.PutUint16(b[4:], 1) // one question
+	b = dnsAppendName(b, name)
+	b = binary.BigEndian.AppendUint16(b, dnsTypePTR)
+	b = binary.BigEndian.AppendUint16(b, 1) // class IN
+	return b
+}
+
+func dnsAppendName(b []byte, name string) []byte {
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0)
+}
+
+var errDNSFormat = errors.New("broadcastkit/discovery: malformed DNS message")
+
+// dnsParse returns the records of all sections of a DNS message.
+func dnsParse(b []byte) ([]dnsRecord, error) {
+	if len(b) < 12 {
+		return nil, errDNSFormat
+	}
+	qd := int(binary.BigEndian.Uint16(b[4:]))
+	rr := int(binary.BigEndian.Uint16(b[6:])) + int(binary.BigEndian.Uint16(b[8:])) + int(binary.BigEndian.Uint16(b[10:]))
+	off := 12
+	for range qd {
+		_, n, err := dnsName(b, off)
+		if err != nil {
+			return nil, err
+		}
+		off = n + 4
+	}
+	var records []dnsRecord
+	for range rr {
+		name, n, err := dnsName(b, off)
+		if err != nil {
+			return nil, err
+		}
+		if n+10 > len(b) {
+			return nil, errDNSFormat
+		}
+		r := dnsRecord{Name: name, Type: binary.BigEndian.Uint16(b[n:])}
+		size := int(binary.BigEndian.Uint16(b[n+8:]))
+		start := n + 10
+		end := start + size
+		if end > len(b) {
+			return nil, errDNSFormat
+		}
+		data := b[start:end]
+		switch r.Type {
+		case dnsTypeA:
+			if size == 4 {
+				r.A = netip.AddrFrom4([4]byte(data))
+			}
+		case dnsTypePTR:
+			r.Target, _, err = dnsName(b, start)
+		case dnsTypeSRV:
+			if size < 7 {
+				return nil, errDNSFormat
+			}
+			r.Port = binary.BigEndian.Uint16(data[4:])
+			r.Target, _, err = dnsName(b, start+6)
+		case dnsTypeTXT:
+			for len(data) > 0 {
+				l := int(data[0])
+				if 1+l > len(data) {
+					return nil, errDNSFormat
+				}
+				r.TXT = append(r.TXT, string(data[1:1+l]))
+				data = data[1+l:]
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+		off = end
+	}
+	return records, nil
+}
+
+// dnsName decodes the possibly compressed domain name at off. It returns the
+// name with a trailing dot and the offset after the name.
+func dnsName(b []byte, off int) (string, int, error) {
+	var name strings.Builder
+	end := -1
+	for jumps := 0; ; {
+		if off >= len(b) {
+			return "", 0, errDNSFormat
+		}
+		l := int(b[off])
+		switch {
+		case l == 0:
+			if end < 0 {
+				end = off + 1
+			}
+			if name.Len() == 0 {
+				name.WriteByte('.')
+			}
+			return name.String(), end, nil
+		case l&0xc0 == 0xc0:
+			if off+1 >= len(b) || jumps > 16 {
+				return "", 0, errDNSFormat
+			}
+			if end < 0 {
+				end = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(b[off:]) & 0x3fff)
+			jumps++
+		default:
+			if off+1+l > len(b) {
+				return "", 0, errDNSFormat
+			}
+			name.Write(b[off+1 : off+1+l])
+			name.WriteByte('.')
+			off += 1 + l
+		}
+	}
+}
+
+// mdnsInstance collects the records of one service instance, which may
+// arrive in separate responses.
+type mdnsInstance struct {
+	host     string
+	port     uint16
+	txt      map[string]string
+	addr     netip.Addr
+	reported bool
+}
+
+func (s *MDNS) Search(ctx context.Context, found func(Device)) error {
+	target := s.Target
+	if !target.IsValid() {
+		target = netip.MustParseAddrPort("224.0.0.251:5353")
+	}
+	service := s.Service
+	if service == "" {
+		service = "_blackmagic._tcp.local."
+	}
+	if !strings.HasSuffix(service, ".") {
+		service += "."
+	}
+	interval := s.Interval
+	if interval == 0 {
+		interval = time.Second
+	}
+
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	query := dnsQuery(service)
+	instances := make(map[string]*mdnsInstance)
+	hosts := make(map[string]netip.Addr)
+	instance := func(name string) *mdnsInstance {
+		i := instances[name]
+		if i == nil {
+			i = &mdnsInstance{}
+			instances[name] = i
+		}
+		return i
+	}
+	return udpSearch(ctx, conn, interval, func() error {
+		_, err := conn.WriteToUDPAddrPort(query, target)
+		return err
+	}, func(b []byte, from netip.AddrPort) {
+		records, err := dnsParse(b)
+		if err != nil {
+			return
+		}
+		for _, r := range records {
+			switch r.Type {
+			case dnsTypePTR:
+				if strings.EqualFold(r.Name, service) {
+					instance(r.Target)
+				}
+			case dnsTypeSRV:
+				i := instance(r.Name)
+				i.host, i.port = r.Target, r.Port
+			case dnsTypeTXT:
+				i := instance(r.Name)
+				i.txt = make(map[string]string)
+				for _, kv := range r.TXT {
+					k, v, _ := strings.Cut(kv, "=")
+					i.txt[strings.ToLower(k)] = v
+				}
+			case dnsTypeA:
+				hosts[strings.ToLower(r.Name)] = r.A
+			}
+		}
+		for name, i := range instances {
+			if i.reported || i.port == 0 || !strings.HasSuffix(strings.ToLower(name), strings.ToLower(service)) {
+				continue
+			}
+			i.addr = hosts[strings.ToLower(i.host)]
+			if !i.addr.IsValid() {
+				// Responders without an address record are reached at
+				// the address they answered from.
+				i.addr = from.Addr()
+			}
+			i.reported = true
+			found(blackmagicDevice(name, service, i))
+		}
+	})
+}
+
+// blackmagicDevice builds a Device from a service instance.
+func blackmagicDevice(name string, service string, i *mdnsInstance) Device {
+	d := Device{
+		Vendor: Blackmagic,
+		Model:  i.txt["class"],
+		Name:   i.txt["name"],
+		Addr:   netip.AddrPortFrom(i.addr, i.port),
+	}
+	if d.Name == "" {
+		d.Name = strings.TrimSuffix(name, "."+service)
+	}
+	// Other classes, like ATEM switchers, have no client in this library.
+	if strings.EqualFold(i.txt["class"], "videohub") {
+		d.Kind = Router
+	}
+	if model := i.txt["model"]; model != "" {
+		d.Model = model
+	}
+	// The unique id of Blackmagic devices is their MAC address in hex.
+	if mac, err := net.ParseMAC(hexMAC(i.txt["unique id"])); err == nil {
+		d.MAC = mac
+	}
+	return d
+}
+
+// hexMAC inserts colons into 12 hex digits, so net.ParseMAC accepts them.
+func hexMAC(s string) string {
+	if len(s) != 12 {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < 12; i += 2 {
+		if i > 0 {
+			b.WriteByte(':')
+		}
+		b.WriteString(s[i : i+2])
+	}
+	return b.String()
+}
@@ -0,0 +1,143 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+
+	"puzzlekraken.com/broadcastkit/yamaha"
+)
+
+// SCPProbe searches Yamaha mixers by asking every address of a network for
+// its product name over the Simple Control Protocol.
+//
+// Yamaha mixers do not answer a search protocol of their own, so the probe
+// connects to each address in turn. Only the model is reported, SCP does not
+// tell the name or MAC of the mixer.
+type SCPProbe struct {
+	// Prefix is the network to scan. If invalid, the IPv4 networks of the
+	// local interfaces are scanned, networks larger than /24 narrowed to the
+	// /24 of the interface address.
+	Prefix netip.Prefix
+	// Port is the SCP port, 49280 by default.
+	Port uint16
+	// Timeout bounds the probe of a single address, 500 milliseconds by
+	// default.
+	Timeout time.Duration
+}
+
+// scpProbeWorkers is the number of addresses probed in parallel.
+const scpProbeWorkers = 64
+
+func (s *SCPProbe) Search(ctx context.Context, found func(Device)) error {
+	port := s.Port
+	if port == 0 {
+		port = 49280
+	}
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = 500 * time.Millisecond
+	}
+	prefixes := []netip.Prefix{s.Prefix}
+	if !s.Prefix.IsValid() {
+		var err error
+		if prefixes, err = localPrefixes(); err != nil {
+			return err
+		}
+	}
+
+	addrs := make(chan netip.Addr)
+	go func() {
+		defer close(addrs)
+		for _, p := range prefixes {
+			for a := p.Masked().Addr(); p.Contains(a); a = a.Next() {
+				select {
+				case addrs <- a:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	for range scpProbeWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for a := range addrs {
+				addr := netip.AddrPortFrom(a, port)
+				if model, ok := scpProductName(ctx, addr, timeout); ok {
+					lock.Lock()
+					found(Device{Vendor: Yamaha, Kind: Mixer, Model: model, Addr: addr})
+					lock.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// scpProductName asks the mixer at addr for its product name.
+func scpProductName(ctx context.Context, addr netip.AddrPort, timeout time.Duration) (string, bool) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp4", addr.String())
+	if err != nil {
+		return "", false
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	s := &yamaha.ScpSocket{Conn: conn}
+	if err := s.Write(&yamaha.InfoMessage{Action: "devinfo", Address: "productname"}); err != nil {
+		return "", false
+	}
+	for {
+		reply, msg, err := s.Read()
+		if err != nil {
+			return "", false
+		}
+		// Skip notifications of parameter changes until the reply.
+		if info, ok := msg.(*yamaha.InfoMessage); ok && reply && info.Action == "devinfo" {
+			return info.Value, true
+		}
+	}
+}
+
+// localPrefixes returns the IPv4 networks of the local interfaces which are
+// up, excluding loopback. Networks larger than /24 are narrowed to the /24
+// of the interface address.
+func localPrefixes() ([]netip.Prefix, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	var prefixes []netip.Prefix
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			p, err := netip.ParsePrefix(a.String())
+			if err != nil || !p.Addr().Is4() {
+				continue
+			}
+			if p.Bits() < 24 {
+				p = netip.PrefixFrom(p.Addr(), 24)
+			}
+			prefixes = append(prefixes, p.Masked())
+		}
+	}
+	return prefixes, nil
+}
@@ -0,0 +1,127 @@
+package discovery
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SSDP searches Sony cameras with the Simple Service Discovery Protocol of
+// UPnP.
+//
+// Every responder is asked for its device description, and only those made
+// by Sony are reported. The description is fetched once per location.
+type SSDP struct {
+	// Target is where the search is sent, 239.255.255.250:1900 by default.
+	Target netip.AddrPort
+	// ST is the search target, upnp:rootdevice by default.
+	ST string
+	// Interval is the period of repeating the search, one second by default.
+	Interval time.Duration
+	// Client fetches the device descriptions, http.DefaultClient if nil.
+	Client *http.Client
+}
+
+// ssdpDescription is the part of a UPnP device description we use.
+type ssdpDescription struct {
+	Device struct {
+		FriendlyName string `xml:"friendlyName"`
+		Manufacturer string `xml:"manufacturer"`
+		ModelName    string `xml:"modelName"`
+	} `xml:"device"`
+}
+
+func (s *SSDP) Search(ctx context.Context, found func(Device)) error {
+	target := s.Target
+	if !target.IsValid() {
+		target = netip.MustParseAddrPort("239.255.255.250:1900")
+	}
+	st := s.ST
+	if st == "" {
+		st = "upnp:rootdevice"
+	}
+	interval := s.Interval
+	if interval == 0 {
+		interval = time.Second
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	msearch := fmt.Sprintf("M-SEARCH * HTTP/1.1\r\n"+
+		"HOST: %s\r\n"+
+		"MAN: \"ssdp:discover\"\r\n"+
+		"MX: 1\r\n"+
+		"ST: %s\r\n\r\n", target, st)
+	seen := make(map[string]bool)
+	return udpSearch(ctx, conn, interval, func() error {
+		_, err := conn.WriteToUDPAddrPort([]byte(msearch), target)
+		return err
+	}, func(b []byte, from netip.AddrPort) {
+		res, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(b)), nil)
+		if err != nil || res.StatusCode != http.StatusOK {
+			return
+		}
+		location := res.Header.Get("Location")
+		if location == "" || seen[location] {
+			return
+		}
+		seen[location] = true
+		if d, ok := ssdpDescribe(ctx, client, location, from.Addr()); ok {
+			found(d)
+		}
+	})
+}
+
+// ssdpDescribe fetches the device description at location and returns the
+// device if it is a Sony camera.
+func ssdpDescribe(ctx context.Context, client *http.Client, location string, from netip.Addr) (Device, bool) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", location, nil)
+	if err != nil {
+		return Device{}, false
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return Device{}, false
+	}
+	defer res.Body.Close()
+	var desc ssdpDescription
+	if err := xml.NewDecoder(res.Body).Decode(&desc); err != nil {
+		return Device{}, false
+	}
+	if !strings.Contains(strings.ToLower(desc.Device.Manufacturer), "sony") {
+		return Device{}, false
+	}
+	// The description may be served on another port, but the camera
+	// control API is on the standard HTTP port.
+	addr := from
+	if u, err := url.Parse(location); err == nil {
+		if a, err := netip.ParseAddr(u.Hostname()); err == nil {
+			addr = a.Unmap()
+		}
+	}
+	return Device{
+		Vendor: Sony,
+		Kind:   Camera,
+		Model:  desc.Device.ModelName,
+		Name:   desc.Device.FriendlyName,
+		Addr:   netip.AddrPortFrom(addr, 80),
+	}, true
+}
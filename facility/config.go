@@ -0,0 +1,145 @@
+// Package facility opens and supervises the devices of a facility declared in
+// a config file.
+//
+// The config file is JSON, listing the devices by the name applications use
+// to look them up:
+//
+//	{
+//		"devices": {
+//			"cam1": {"type": "sony", "address": "10.0.0.20", "username": "admin", "password": "...", "name": "Camera 1"},
+//			"cam2": {"type": "panasonic", "address": "10.0.0.21", "name": "Camera 2"},
+//			"vision": {"type": "switcher", "address": "10.0.0.5"},
+//			"hub": {"type": "videohub", "address": "10.0.0.30"},
+//			"desk": {"type": "yamaha", "address": "10.0.0.40"},
+//			"ingest": {"type": "metus", "address": "10.0.0.50"}
+//		}
+//	}
+//
+// Addresses may include a port, the default port of the device type is used
+// otherwise.
+package facility
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+)
+
+// Type is the kind of a device, which decides the client used for it.
+type Type string
+
+const (
+	Sony      Type = "sony"      // *sony.CameraClient
+	Panasonic Type = "panasonic" // *panasonic.CameraClient
+	Switcher  Type = "switcher"  // *panasonic.SwitcherClient
+	Videohub  Type = "videohub"  // *blackmagicdesign.VideohubSocket
+	Yamaha    Type = "yamaha"    // *yamaha.ScpSocket
+	Metus     Type = "metus"     // *metus.MetusSocket
+)
+
+// defaultPort returns the control port of devices of type t.
+func (t Type) defaultPort() (uint16, bool) {
+	switch t {
+	case Sony, Panasonic:
+		return 80, true
+	case Switcher:
+		return 62000, true
+	case Videohub:
+		return 9990, true
+	case Yamaha:
+		return 49280, true
+	case Metus:
+		return 32106, true
+	default:
+		return 0, false
+	}
+}
+
+// hasAccount reports whether devices of type t take a username and password.
+func (t Type) hasAccount() bool {
+	return t == Sony || t == Panasonic
+}
+
+// DeviceConfig declares a single device.
+type DeviceConfig struct {
+	Type    Type   `json:"type"`
+	Address string `json:"address"`
+	// Username and Password are the account of Sony and Panasonic cameras.
+	// Sony clients are created with them, the Panasonic account is found
+	// with Userinfo.
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// Name is the friendly name shown to operators.
+	Name string `json:"name,omitempty"`
+}
+
+// Userinfo returns the account of the device, like for
+// panasonic.CameraClient.SetTitle, or nil if no username is set.
+func (d DeviceConfig) Userinfo() *url.Userinfo {
+	if d.Username == "" {
+		return nil
+	}
+	return url.UserPassword(d.Username, d.Password)
+}
+
+// Config is the content of a facility config file.
+type Config struct {
+	Devices map[string]DeviceConfig `json:"devices"`
+}
+
+// ParseConfig reads and validates a config.
+func ParseConfig(r io.Reader) (Config, error) {
+	var c Config
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return Config{}, fmt.Errorf("broadcastkit/facility: %w", err)
+	}
+	var errs []error
+	for name, d := range c.Devices {
+		if _, ok := d.Type.defaultPort(); !ok {
+			errs = append(errs, fmt.Errorf("broadcastkit/facility: device %s: unknown type %q", name, d.Type))
+		}
+		if d.Address == "" {
+			errs = append(errs, fmt.Errorf("broadcastkit/facility: device %s: missing address", name))
+		}
+		if (d.Username != "" || d.Password != "") && !d.Type.hasAccount() {
+			errs = append(errs, fmt.Errorf("broadcastkit/facility: device %s: type %q takes no username or password", name, d.Type))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return Config{}, err
+	}
+	return c, nil
+}
+
+// LoadConfig reads and validates the config file at path.
+func LoadConfig(path string) (Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Config{}, err
+	}
+	defer f.Close()
+	return ParseConfig(f)
+}
+
+// resolve looks up the address of d and adds the default port if missing.
+func (d DeviceConfig) resolve() (netip.AddrPort, error) {
+	port, _ := d.Type.defaultPort()
+	host, p, err := net.SplitHostPort(d.Address)
+	if err != nil {
+		host, p = d.Address, strconv.Itoa(int(port))
+	}
+	a, err := net.ResolveTCPAddr("tcp4", net.JoinHostPort(host, p))
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	ap := a.AddrPort()
+	return netip.AddrPortFrom(ap.Addr().Unmap(), ap.Port()), nil
+}
@@ -0,0 +1,461 @@
+package facility
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"puzzlekraken.com/broadcastkit/blackmagicdesign"
+	"puzzlekraken.com/broadcastkit/internal/wirelog"
+	"puzzlekraken.com/broadcastkit/metrics"
+	"puzzlekraken.com/broadcastkit/metus"
+	"puzzlekraken.com/broadcastkit/panasonic"
+	"puzzlekraken.com/broadcastkit/sony"
+	"puzzlekraken.com/broadcastkit/yamaha"
+)
+
+// ErrNotConnected is returned by Get for socket devices whose connection is
+// down. The Manager keeps trying to reconnect them.
+var ErrNotConnected = errors.New("broadcastkit/facility: device not connected")
+
+// Manager opens the devices of a config file and keeps them usable.
+//
+// HTTP clients and clients that reconnect on their own are created once.
+// Videohub and Yamaha sockets are dialed by the Manager and re-dialed when a
+// health check finds them broken, so applications should look them up with
+// Get for every use instead of keeping them. The Manager only writes to the
+// sockets, reading them is up to the application.
+//
+// Set the fields before the first call to Load or Run.
+type Manager struct {
+	// Path is the config file.
+	Path string
+	// CheckInterval is the period of health checks, 10 seconds by default.
+	CheckInterval time.Duration
+	// ReloadInterval is the period of looking for changes of the config
+	// file, 2 seconds by default.
+	ReloadInterval time.Duration
+	// Logger, if set, receives config reloads and health changes. The
+	// clients log to it too, with the device name attached.
+	Logger *slog.Logger
+	// Metrics, if set, is passed to the clients which support it.
+	Metrics metrics.Recorder
+
+	lock    sync.Mutex
+	devices map[string]*device
+	modTime time.Time
+	size    int64
+}
+
+// device is an opened device.
+type device struct {
+	conf   DeviceConfig
+	client any // nil while a socket is not connected
+	health Health
+}
+
+// Health is the state of a device as of the last health check.
+type Health struct {
+	Type Type
+	Name string
+	// Healthy is true if the last check succeeded. Devices are assumed
+	// healthy until the first check.
+	Healthy bool
+	// Err is the error of the last check.
+	Err error
+	// Since is the time Healthy last changed.
+	Since time.Time
+	// Checked is the time of the last check.
+	Checked time.Time
+}
+
+// Load reads the config file and applies it.
+//
+// Devices whose configuration did not change keep their clients. Removed and
+// changed devices are closed. If the file is invalid, the devices are left
+// as they were.
+func (m *Manager) Load() error {
+	info, err := os.Stat(m.Path)
+	if err != nil {
+		return err
+	}
+	conf, err := LoadConfig(m.Path)
+	if err != nil {
+		return err
+	}
+	m.lock.Lock()
+	m.modTime, m.size = info.ModTime(), info.Size()
+	m.lock.Unlock()
+	m.apply(conf)
+	return nil
+}
+
+// apply opens, keeps and closes devices to match conf.
+//
+// Sockets of new devices are dialed without holding the lock, so unreachable
+// devices do not block Get and Health.
+func (m *Manager) apply(conf Config) {
+	log := wirelog.Or(m.Logger)
+	m.lock.Lock()
+	if m.devices == nil {
+		m.devices = make(map[string]*device)
+	}
+	for name, d := range m.devices {
+		if c, ok := conf.Devices[name]; ok && c == d.conf {
+			continue
+		}
+		closeClient(d.client)
+		delete(m.devices, name)
+		log.Info("device closed", "device", name)
+	}
+	var added []string
+	for name := range conf.Devices {
+		if _, ok := m.devices[name]; !ok {
+			added = append(added, name)
+		}
+	}
+	m.lock.Unlock()
+
+	opened := make(map[string]*device, len(added))
+	for _, name := range added {
+		c := conf.Devices[name]
+		d := &device{
+			conf:   c,
+			health: Health{Type: c.Type, Name: c.Name, Healthy: true, Since: time.Now()},
+		}
+		client, err := m.open(name, c)
+		if err != nil {
+			d.health.Healthy, d.health.Err = false, err
+			log.Warn("device open", "device", name, "err", err)
+		} else {
+			log.Info("device opened", "device", name, "type", c.Type)
+		}
+		d.client = client
+		opened[name] = d
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for name, d := range opened {
+		if _, ok := m.devices[name]; ok || m.devices == nil {
+			// Added by a concurrent load, or the Manager was closed.
+			closeClient(d.client)
+			continue
+		}
+		m.devices[name] = d
+	}
+}
+
+// open creates the client of a device. Sockets are dialed.
+func (m *Manager) open(name string, c DeviceConfig) (any, error) {
+	remote, err := c.resolve()
+	if err != nil {
+		return nil, err
+	}
+	var logger *slog.Logger
+	if m.Logger != nil {
+		logger = m.Logger.With("device", name)
+	}
+	switch c.Type {
+	case Sony:
+		return &sony.CameraClient{
+			Remote:   remote,
+			Username: c.Username,
+			Password: c.Password,
+			Logger:   logger,
+			Metrics:  m.Metrics,
+		}, nil
+	case Panasonic:
+		return &panasonic.CameraClient{Remote: remote, Logger: logger, Metrics: m.Metrics}, nil
+	case Switcher:
+		return &panasonic.SwitcherClient{Remote: remote, Logger: logger, Metrics: m.Metrics}, nil
+	case Metus:
+		return &metus.MetusSocket{Remote: remote, Logger: logger, Metrics: m.Metrics}, nil
+	case Videohub:
+		conn, err := net.DialTimeout("tcp4", remote.String(), dialTimeout)
+		if err != nil {
+			return nil, err
+		}
+		return &blackmagicdesign.VideohubSocket{Conn: conn, Logger: logger, Metrics: m.Metrics}, nil
+	case Yamaha:
+		conn, err := net.DialTimeout("tcp4", remote.String(), dialTimeout)
+		if err != nil {
+			return nil, err
+		}
+		return &yamaha.ScpSocket{Conn: conn, Logger: logger, Metrics: m.Metrics}, nil
+	default:
+		return nil, fmt.Errorf("broadcastkit/facility: unknown type %q", c.Type)
+	}
+}
+
+const dialTimeout = 3 * time.Second
+
+func closeClient(client any) {
+	switch c := client.(type) {
+	case *blackmagicdesign.VideohubSocket:
+		c.Close()
+	case *yamaha.ScpSocket:
+		c.Close()
+	case *metus.MetusSocket:
+		c.Close()
+	}
+}
+
+// Get returns the client of the named device. See Type for the client types.
+func (m *Manager) Get(name string) (any, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	d, ok := m.devices[name]
+	if !ok {
+		return nil, fmt.Errorf("broadcastkit/facility: unknown device %q", name)
+	}
+	if d.client == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotConnected, name)
+	}
+	return d.client, nil
+}
+
+// Get returns the client of the named device as type T, for example
+// *sony.CameraClient.
+func Get[T any](m *Manager, name string) (T, error) {
+	client, err := m.Get(name)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	t, ok := client.(T)
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("broadcastkit/facility: device %q is %T, not %T", name, client, zero)
+	}
+	return t, nil
+}
+
+// Names returns the names of the configured devices in order.
+func (m *Manager) Names() []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	names := make([]string, 0, len(m.devices))
+	for name := range m.devices {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Config returns the configuration of the named device.
+func (m *Manager) Config(name string) (DeviceConfig, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	d, ok := m.devices[name]
+	if !ok {
+		return DeviceConfig{}, false
+	}
+	return d.conf, true
+}
+
+// Health returns the health of every device by name.
+func (m *Manager) Health() map[string]Health {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	h := make(map[string]Health, len(m.devices))
+	for name, d := range m.devices {
+		h[name] = d.health
+	}
+	return h
+}
+
+// Run loads the config file if it was not loaded yet, then checks the health
+// of the devices and reloads the file when it changes, until ctx is done.
+// The devices are closed when Run returns.
+func (m *Manager) Run(ctx context.Context) error {
+	m.lock.Lock()
+	loaded := m.devices != nil
+	m.lock.Unlock()
+	if !loaded {
+		if err := m.Load(); err != nil {
+			return err
+		}
+	}
+	defer m.Close()
+
+	checkInterval := m.CheckInterval
+	if checkInterval == 0 {
+		checkInterval = 10 * time.Second
+	}
+	reloadInterval := m.ReloadInterval
+	if reloadInterval == 0 {
+		reloadInterval = 2 * time.Second
+	}
+	check := time.NewTicker(checkInterval)
+	defer check.Stop()
+	reload := time.NewTicker(reloadInterval)
+	defer reload.Stop()
+
+	m.check(ctx, checkInterval)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-check.C:
+			m.check(ctx, checkInterval)
+		case <-reload.C:
+			m.reloadIfChanged()
+		}
+	}
+}
+
+// reloadIfChanged loads the config file if its size or time of modification
+// changed since the last load.
+func (m *Manager) reloadIfChanged() {
+	info, err := os.Stat(m.Path)
+	if err != nil {
+		wirelog.Or(m.Logger).Warn("config stat", "err", err)
+		return
+	}
+	m.lock.Lock()
+	changed := !info.ModTime().Equal(m.modTime) || info.Size() != m.size
+	m.lock.Unlock()
+	if !changed {
+		return
+	}
+	if err := m.Load(); err != nil {
+		wirelog.Or(m.Logger).Warn("config reload, keeping the previous devices", "err", err)
+		return
+	}
+	wirelog.Or(m.Logger).Info("config reloaded")
+}
+
+// check runs the health checks of all devices in parallel, each bounded by
+// timeout.
+func (m *Manager) check(ctx context.Context, timeout time.Duration) {
+	m.lock.Lock()
+	devices := make(map[string]*device, len(m.devices))
+	for name, d := range m.devices {
+		devices[name] = d
+	}
+	m.lock.Unlock()
+
+	var wg sync.WaitGroup
+	for name, d := range devices {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			m.checkDevice(ctx, name, d)
+		}()
+	}
+	wg.Wait()
+}
+
+// checkDevice probes a device and records the result. Broken sockets are
+// closed and re-dialed.
+func (m *Manager) checkDevice(ctx context.Context, name string, d *device) {
+	m.lock.Lock()
+	client := d.client
+	m.lock.Unlock()
+
+	var err error
+	if client == nil {
+		client, err = m.open(name, d.conf)
+	} else {
+		err = probe(ctx, client)
+		if err != nil && isSocket(client) {
+			closeClient(client)
+			client, err = m.open(name, d.conf)
+		}
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.devices[name] != d {
+		// The device was replaced by a reload in the meantime.
+		closeClient(client)
+		return
+	}
+	d.client = client
+	now := time.Now()
+	if healthy := err == nil; healthy != d.health.Healthy {
+		d.health.Healthy = healthy
+		d.health.Since = now
+		log := wirelog.Or(m.Logger)
+		if healthy {
+			log.Info("device healthy", "device", name)
+		} else {
+			log.Warn("device unhealthy", "device", name, "err", err)
+		}
+	}
+	d.health.Err = err
+	d.health.Checked = now
+}
+
+func isSocket(client any) bool {
+	switch client.(type) {
+	case *blackmagicdesign.VideohubSocket, *yamaha.ScpSocket:
+		return true
+	}
+	return false
+}
+
+// probe checks that a device answers.
+func probe(ctx context.Context, client any) error {
+	switch c := client.(type) {
+	case *sony.CameraClient:
+		ps, err := c.InqCtx(ctx, sony.SystemEndpoint)
+		if len(ps) > 0 {
+			// Parameters the library does not know are not a failure.
+			return nil
+		}
+		return err
+	case *panasonic.CameraClient:
+		// Not every model has the batch page, a single query works on all
+		// of them. The probe is bounded by the HTTP timeout of the client,
+		// not by ctx.
+		_, err := c.AWCommand(panasonic.AWPowerQuery{})
+		return err
+	case *panasonic.SwitcherClient:
+		// Bounded by the network timeout of the client, not by ctx.
+		_, err := c.QueryBus(panasonic.BUS_ME1PGM)
+		return err
+	case *metus.MetusSocket:
+		_, err := c.StatusAllCtx(ctx)
+		return err
+	case *blackmagicdesign.VideohubSocket:
+		return probeWrite(ctx, c.Conn, func() error { return c.Write(&blackmagicdesign.PingBlock{}) })
+	case *yamaha.ScpSocket:
+		return probeWrite(ctx, c.Conn, func() error { return c.Write(&yamaha.HeartbeatMessage{}) })
+	default:
+		return fmt.Errorf("broadcastkit/facility: no health check for %T", client)
+	}
+}
+
+// probeWrite calls write with the write deadline of conn set to the deadline
+// of ctx. The deadline is cleared after, as the application shares conn.
+func probeWrite(ctx context.Context, conn any, write func() error) error {
+	deadline, ok := ctx.Deadline()
+	c, isConn := conn.(net.Conn)
+	if !ok || !isConn {
+		return write()
+	}
+	c.SetWriteDeadline(deadline)
+	defer c.SetWriteDeadline(time.Time{})
+	return write()
+}
+
+// Close closes the connections of all devices and forgets them.
+func (m *Manager) Close() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, d := range m.devices {
+		closeClient(d.client)
+	}
+	m.devices = nil
+	return nil
+}
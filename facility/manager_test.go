@@ -0,0 +1,300 @@
+package facility
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"puzzlekraken.com/broadcastkit/blackmagicdesign"
+	"puzzlekraken.com/broadcastkit/panasonic"
+	"puzzlekraken.com/broadcastkit/sony"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    Config
+		wantErr string
+	}{
+		{
+			name: "valid",
+			json: `{"devices":{"cam":{"type":"sony","address":"10.0.0.1","username":"admin","password":"pw","name":"Cam"}}}`,
+			want: Config{Devices: map[string]DeviceConfig{
+				"cam": {Type: Sony, Address: "10.0.0.1", Username: "admin", Password: "pw", Name: "Cam"},
+			}},
+		},
+		{
+			name:    "unknown type",
+			json:    `{"devices":{"x":{"type":"atem","address":"10.0.0.1"}}}`,
+			wantErr: `unknown type "atem"`,
+		},
+		{
+			name:    "missing address",
+			json:    `{"devices":{"x":{"type":"yamaha"}}}`,
+			wantErr: "missing address",
+		},
+		{
+			name:    "credentials of a switcher",
+			json:    `{"devices":{"x":{"type":"switcher","address":"10.0.0.5","password":"pw"}}}`,
+			wantErr: "takes no username or password",
+		},
+		{
+			name:    "unknown field",
+			json:    `{"devices":{"x":{"type":"yamaha","address":"10.0.0.1","port":1}}}`,
+			wantErr: "unknown field",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseConfig(strings.NewReader(tt.json))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ParseConfig() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseConfig() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeviceConfig_Userinfo(t *testing.T) {
+	if u := (DeviceConfig{Type: Panasonic}).Userinfo(); u != nil {
+		t.Errorf("Userinfo() = %v, want nil", u)
+	}
+	u := DeviceConfig{Type: Panasonic, Username: "admin", Password: "pw"}.Userinfo()
+	if pw, _ := u.Password(); u.Username() != "admin" || pw != "pw" {
+		t.Errorf("Userinfo() = %v, want admin:pw", u)
+	}
+}
+
+func TestDeviceConfig_Resolve(t *testing.T) {
+	tests := []struct {
+		conf DeviceConfig
+		want string
+	}{
+		{DeviceConfig{Type: Videohub, Address: "10.0.0.1"}, "10.0.0.1:9990"},
+		{DeviceConfig{Type: Yamaha, Address: "10.0.0.1:1234"}, "10.0.0.1:1234"},
+		{DeviceConfig{Type: Switcher, Address: "localhost"}, "127.0.0.1:62000"},
+	}
+	for _, tt := range tests {
+		got, err := tt.conf.resolve()
+		if err != nil {
+			t.Errorf("resolve(%q) error = %v", tt.conf.Address, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("resolve(%q) = %v, want %v", tt.conf.Address, got, tt.want)
+		}
+	}
+}
+
+// videohubStandIn accepts connections on a loopback port and discards
+// everything sent to it.
+func videohubStandIn(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(io.Discard, conn)
+				conn.Close()
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func writeConfig(t *testing.T, path string, devices map[string]string) {
+	t.Helper()
+	var entries []string
+	for name, dev := range devices {
+		entries = append(entries, fmt.Sprintf("%q: %s", name, dev))
+	}
+	data := `{"devices": {` + strings.Join(entries, ",") + `}}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestManager(t *testing.T) {
+	hub := videohubStandIn(t)
+	cam := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer cam.Close()
+	camAddr := strings.TrimPrefix(cam.URL, "http://")
+
+	path := filepath.Join(t.TempDir(), "facility.json")
+	writeConfig(t, path, map[string]string{
+		"hub": fmt.Sprintf(`{"type": "videohub", "address": %q}`, hub),
+		"cam": fmt.Sprintf(`{"type": "sony", "address": %q, "name": "Camera"}`, camAddr),
+	})
+	m := &Manager{Path: path}
+	if err := m.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	defer m.Close()
+
+	if got, want := m.Names(), []string{"cam", "hub"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+	sonyCam, err := Get[*sony.CameraClient](m, "cam")
+	if err != nil {
+		t.Fatalf("Get(cam) error = %v", err)
+	}
+	if _, err := Get[*blackmagicdesign.VideohubSocket](m, "hub"); err != nil {
+		t.Errorf("Get(hub) error = %v", err)
+	}
+	if _, err := Get[*panasonic.CameraClient](m, "cam"); err == nil {
+		t.Error("Get[*panasonic.CameraClient](cam) error = nil, want type error")
+	}
+	if _, err := m.Get("nope"); err == nil {
+		t.Error("Get(nope) error = nil, want unknown device")
+	}
+
+	m.check(context.Background(), time.Second)
+	for name, h := range m.Health() {
+		if !h.Healthy || h.Err != nil || h.Checked.IsZero() {
+			t.Errorf("Health()[%s] = %+v, want healthy", name, h)
+		}
+	}
+	if h := m.Health()["cam"]; h.Type != Sony || h.Name != "Camera" {
+		t.Errorf("Health()[cam] = %+v, want type sony and name Camera", h)
+	}
+
+	cam.Close()
+	m.check(context.Background(), time.Second)
+	if h := m.Health()["cam"]; h.Healthy || h.Err == nil {
+		t.Errorf("Health()[cam] after shutdown = %+v, want unhealthy", h)
+	}
+
+	// Change the camera name, drop the videohub, add a yamaha which does
+	// not answer.
+	writeConfig(t, path, map[string]string{
+		"cam":  fmt.Sprintf(`{"type": "sony", "address": %q, "name": "Camera"}`, camAddr),
+		"desk": `{"type": "yamaha", "address": "127.0.0.1:1"}`,
+	})
+	m.reloadIfChanged()
+	if got, want := m.Names(), []string{"cam", "desk"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() after reload = %v, want %v", got, want)
+	}
+	if c, _ := Get[*sony.CameraClient](m, "cam"); c != sonyCam {
+		t.Error("Get(cam) after reload returned a new client for an unchanged device")
+	}
+	if _, err := m.Get("desk"); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("Get(desk) error = %v, want ErrNotConnected", err)
+	}
+	if h := m.Health()["desk"]; h.Healthy {
+		t.Errorf("Health()[desk] = %+v, want unhealthy", h)
+	}
+
+	// An invalid file keeps the devices.
+	if err := os.WriteFile(path, []byte(`{"devices": {"x": {"type": "atem"}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	m.reloadIfChanged()
+	if got, want := m.Names(), []string{"cam", "desk"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() after invalid reload = %v, want %v", got, want)
+	}
+}
+
+func TestManager_Run(t *testing.T) {
+	hub := videohubStandIn(t)
+	path := filepath.Join(t.TempDir(), "facility.json")
+	writeConfig(t, path, map[string]string{
+		"hub": fmt.Sprintf(`{"type": "videohub", "address": %q}`, hub),
+	})
+	m := &Manager{Path: path, CheckInterval: 10 * time.Millisecond, ReloadInterval: 10 * time.Millisecond}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- m.Run(ctx) }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for m.Health()["hub"].Checked.IsZero() {
+		if time.Now().After(deadline) {
+			t.Fatal("no health check within 2s")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run() = %v, want nil", err)
+	}
+	if names := m.Names(); len(names) != 0 {
+		t.Errorf("Names() after Run = %v, want none", names)
+	}
+}
+
+// powerHandler answers the power query of a Panasonic camera.
+type powerHandler struct{}
+
+func (powerHandler) AWCommand(req panasonic.AWRequest) (panasonic.AWResponse, error) {
+	if _, ok := req.(panasonic.AWPowerQuery); ok {
+		return panasonic.AWPower{Power: panasonic.PowerOn}, nil
+	}
+	return nil, panasonic.NewAWError(panasonic.AWErrUnsupported, req)
+}
+
+func (powerHandler) AWBatch() ([]panasonic.AWResponse, error) {
+	return nil, nil
+}
+
+func TestManager_Probe(t *testing.T) {
+	hub := videohubStandIn(t)
+	cam := httptest.NewServer(&panasonic.CameraServer{
+		AWHandler: powerHandler{},
+		Profile:   &panasonic.ModelProfile{Model: "AW-X", NoBatch: true},
+	})
+	defer cam.Close()
+
+	path := filepath.Join(t.TempDir(), "facility.json")
+	writeConfig(t, path, map[string]string{
+		"hub": fmt.Sprintf(`{"type": "videohub", "address": %q}`, hub),
+		"cam": fmt.Sprintf(`{"type": "panasonic", "address": %q}`, strings.TrimPrefix(cam.URL, "http://")),
+	})
+	m := &Manager{Path: path}
+	if err := m.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	defer m.Close()
+
+	m.check(context.Background(), 50*time.Millisecond)
+	for name, h := range m.Health() {
+		if !h.Healthy {
+			t.Errorf("Health()[%s] = %+v, want healthy", name, h)
+		}
+	}
+
+	// The deadline of the probe must not outlive it.
+	time.Sleep(100 * time.Millisecond)
+	h, err := Get[*blackmagicdesign.VideohubSocket](m, "hub")
+	if err != nil {
+		t.Fatalf("Get(hub) error = %v", err)
+	}
+	if err := h.Write(&blackmagicdesign.PingBlock{}); err != nil {
+		t.Errorf("Write() after the probe error = %v", err)
+	}
+}
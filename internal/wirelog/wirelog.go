@@ -0,0 +1,122 @@
+// Package wirelog holds the log/slog plumbing shared by the protocol packages.
+//
+// Every client and server type has an optional Logger field. Nothing is logged
+// while it is nil. The levels are used consistently across devices, so the
+// verbosity of each device is controlled by the level of its own handler:
+//
+//   - Debug: raw frames in and out of the device
+//   - Info: connects, reconnects and retries
+//   - Warn: replies that failed to parse, dropped peers
+//   - Error: failures of the library itself, like recovered panics
+package wirelog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Discard is a Logger that drops every record.
+var Discard = slog.New(discardHandler{})
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (d discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return d }
+func (d discardHandler) WithGroup(string) slog.Handler           { return d }
+
+// Or returns l, or Discard if l is nil.
+func Or(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		return Discard
+	}
+	return l
+}
+
+// Send logs a frame written to the device.
+func Send(l *slog.Logger, b []byte) {
+	if l != nil {
+		l.Debug("send", "data", string(b))
+	}
+}
+
+// Recv logs a frame read from the device. Empty reads are skipped.
+func Recv(l *slog.Logger, b []byte) {
+	if l != nil && len(b) > 0 {
+		l.Debug("recv", "data", string(b))
+	}
+}
+
+// Transport returns rt wrapped to log every request and response, or rt itself
+// if l is nil. A nil rt means http.DefaultTransport.
+//
+// The beginning of textual response bodies is logged, binary ones like images
+// and untyped ones are only reported by size.
+func Transport(rt http.RoundTripper, l *slog.Logger) http.RoundTripper {
+	if l == nil {
+		return rt
+	}
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &transport{rt: rt, log: l}
+}
+
+type transport struct {
+	rt  http.RoundTripper
+	log *slog.Logger
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	t.log.DebugContext(ctx, "http request", "method", req.Method, "url", req.URL.Redacted())
+	res, err := t.rt.RoundTrip(req)
+	if err != nil {
+		t.log.InfoContext(ctx, "http failure", "url", req.URL.Redacted(), "err", err)
+		return nil, err
+	}
+	if !t.log.Enabled(ctx, slog.LevelDebug) {
+		return res, nil
+	}
+	if !textual(res.Header.Get("Content-Type")) {
+		t.log.DebugContext(ctx, "http response", "status", res.StatusCode, "size", res.ContentLength)
+		return res, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, int64(maxLoggedBody)+1))
+	if err != nil {
+		// Surface the read error to the caller after the data read so far.
+		res.Body.Close()
+		res.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), errReader{err}))
+	} else {
+		res.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), res.Body), res.Body}
+	}
+	truncated := len(body) > maxLoggedBody
+	if truncated {
+		body = body[:maxLoggedBody]
+	}
+	t.log.DebugContext(ctx, "http response", "status", res.StatusCode, "data", string(body), "truncated", truncated)
+	return res, nil
+}
+
+// maxLoggedBody is the size of the largest HTTP body logged whole. Only the
+// beginning of longer bodies is logged.
+var maxLoggedBody = 4 << 10
+
+// textual reports whether a body of content type ct is worth logging as text.
+// Bodies without a content type may be large downloads and are not.
+func textual(ct string) bool {
+	mt, _, _ := mime.ParseMediaType(ct)
+	return strings.HasPrefix(mt, "text/") || mt == "application/json" || mt == "application/x-www-form-urlencoded"
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
@@ -0,0 +1,85 @@
+package wirelog
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type staticTransport struct {
+	contentType string
+	body        string
+}
+
+func (t staticTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {t.contentType}},
+		Body:       io.NopCloser(strings.NewReader(t.body)),
+		Request:    req,
+	}, nil
+}
+
+func TestTransport(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantLogged  bool
+	}{
+		{"text", "text/plain", "p1", true},
+		{"untyped", "", "OK", false},
+		{"json", "application/json; charset=utf-8", `{"a":1}`, true},
+		{"image", "image/jpeg", "\xff\xd8\xff\xe0", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			l := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			c := http.Client{Transport: Transport(staticTransport{tt.contentType, tt.body}, l)}
+			res, err := c.Get("http://192.0.2.1/cgi-bin/aw_ptz?cmd=%23O&res=1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, _ := io.ReadAll(res.Body)
+			if string(b) != tt.body {
+				t.Errorf("body = %q, want %q", b, tt.body)
+			}
+			if !strings.Contains(out.String(), "cmd=%23O") {
+				t.Errorf("request not logged: %s", out.String())
+			}
+			if got := strings.Contains(out.String(), "data="); got != tt.wantLogged {
+				t.Errorf("body logged = %v, want %v: %s", got, tt.wantLogged, out.String())
+			}
+		})
+	}
+}
+
+func TestTransport_Truncated(t *testing.T) {
+	defer func(n int) { maxLoggedBody = n }(maxLoggedBody)
+	maxLoggedBody = 4
+	var out bytes.Buffer
+	l := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := http.Client{Transport: Transport(staticTransport{"text/plain", "0123456789"}, l)}
+	res, err := c.Get("http://192.0.2.1/command/inquiry.cgi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(res.Body)
+	if string(b) != "0123456789" {
+		t.Errorf("body = %q, want the whole body", b)
+	}
+	if !strings.Contains(out.String(), "data=0123 truncated=true") {
+		t.Errorf("prefix not logged: %s", out.String())
+	}
+}
+
+func TestTransport_NilLogger(t *testing.T) {
+	rt := staticTransport{}
+	if got := Transport(rt, nil); got != http.RoundTripper(rt) {
+		t.Errorf("Transport(rt, nil) = %v, want rt", got)
+	}
+}
@@ -1,47 +1,148 @@
+// Command broadcastkit controls broadcast hardware from the command line.
+//
+// Usage:
+//
+//	broadcastkit [-config file] [-json] <device> [-addr host[:port]] [-user name] [-password secret] [-log level] <command> [arguments]
+//
+// Devices and their commands:
+//
+//	sony      inq <endpoint>... | set <endpoint> <key=value>... | watch <endpoint>...
+//	panasonic aw send <command>... | aw batch | aw screenshot [-resolution n] [-o file] | aw notify
+//	switcher  bus get <bus>... | bus set <bus> <source>
+//	videohub  dump | route <output> <input> | label input|output <n> <label>
+//	yamaha    get <address> [x [y]] | set [-string] <address> <x> <y> <value> | monitor
+//	metus     start -all | start <encoder> | stop -all | stop <encoder> | status [encoder]
+//
+// Addresses and credentials are taken from the flags, or from the section of
+// the device in the JSON config file. Passwords can also be passed in the
+// environment as BROADCASTKIT_<DEVICE>_PASSWORD to keep them out of the
+// process list. With -json, every result is written as a single line of JSON.
+// With -log, or a "log" entry in the config, the protocol traffic of the device
+// is logged to stderr; debug shows every frame on the wire.
 package main
 
 import (
-	"net/netip"
-	"time"
-
-	"puzzlekraken.com/broadcastkit/sony"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
 )
 
+// device is a top-level command for one kind of hardware.
+type device struct {
+	name     string
+	usage    string
+	commands map[string]func(ctx context.Context, e *env, args []string) error
+}
+
+var devices = []device{
+	sonyDevice,
+	panasonicDevice,
+	switcherDevice,
+	videohubDevice,
+	yamahaDevice,
+	metusDevice,
+}
+
+// env is the context shared by the commands of a device.
+type env struct {
+	deviceConfig
+	out printer
+	log *slog.Logger
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: broadcastkit [-config file] [-json] <device> [-addr host[:port]] [-user name] [-password secret] [-log level] <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "devices:")
+	for _, d := range devices {
+		fmt.Fprintf(w, "  %-10s %s\n", d.name, d.usage)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) error {
+	global := flag.NewFlagSet("broadcastkit", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	global.Usage = func() { usage(stderr) }
+	configPath := global.String("config", "", "path of the JSON config file")
+	jsonOut := global.Bool("json", false, "write results as JSON lines")
+	if err := global.Parse(args); err != nil {
+		return err
+	}
+	if global.NArg() == 0 {
+		usage(stderr)
+		return flag.ErrHelp
+	}
+
+	name := global.Arg(0)
+	i := slices.IndexFunc(devices, func(d device) bool { return d.name == name })
+	if i < 0 {
+		usage(stderr)
+		return fmt.Errorf("unknown device: %s", name)
+	}
+	dev := devices[i]
+
+	conf, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	e := &env{
+		deviceConfig: conf[dev.name],
+		out:          printer{json: *jsonOut, w: stdout},
+	}
+	if pw := os.Getenv("BROADCASTKIT_" + strings.ToUpper(dev.name) + "_PASSWORD"); pw != "" {
+		e.Password = pw
+	}
+
+	local := flag.NewFlagSet(dev.name, flag.ContinueOnError)
+	local.SetOutput(stderr)
+	local.Usage = func() {
+		fmt.Fprintf(stderr, "usage: broadcastkit %s [flags] %s\n", dev.name, dev.usage)
+		local.PrintDefaults()
+	}
+	local.StringVar(&e.Address, "addr", e.Address, "address of the device as host[:port]")
+	local.StringVar(&e.Username, "user", e.Username, "user name for authentication")
+	local.StringVar(&e.Password, "password", e.Password, "password for authentication")
+	local.StringVar(&e.Log, "log", e.Log, "log protocol traffic to stderr at `level` debug, info, warn or error")
+	if err := local.Parse(global.Args()[1:]); err != nil {
+		return err
+	}
+	if e.log, err = newLogger(e.Log, dev.name, stderr); err != nil {
+		return err
+	}
+	if local.NArg() == 0 {
+		local.Usage()
+		return flag.ErrHelp
+	}
+	cmd, ok := dev.commands[local.Arg(0)]
+	if !ok {
+		local.Usage()
+		return fmt.Errorf("unknown %s command: %s", dev.name, local.Arg(0))
+	}
+	if e.Address == "" {
+		return fmt.Errorf("no address for %s, use -addr or the config file", dev.name)
+	}
+	return cmd(ctx, e, local.Args()[1:])
+}
+
 func main() {
-	/*
-		cam := panasonic.CameraClient{
-			Remote: netip.AddrPortFrom(netip.MustParseAddr("192.168.5.53"), 80),
-		}
-		b, err := cam.AWBatch()
-		if err != nil {
-			panic(err)
-		}
-		for _, c := range b {
-			fmt.Printf("%#v\n", c)
-		}*/
-	cam := sony.CameraClient{
-		Remote:   netip.AddrPortFrom(netip.MustParseAddr("192.168.5.52"), 80),
-		Username: "admin",
-		Password: "Hummer1234",
-	}
-	for {
-		err := cam.SetPtzf(sony.AbsolutePanTiltParam{
-			Pan:   -30 * sony.SteppedPositionByDegree,
-			Tilt:  -10 * sony.SteppedPositionByDegree,
-			Speed: 50,
-		})
-		if err != nil {
-			panic(err)
-		}
-		time.Sleep(time.Second * 3)
-		err = cam.SetPtzf(sony.AbsolutePanTiltParam{
-			Pan:   +30 * sony.SteppedPositionByDegree,
-			Tilt:  +10 * sony.SteppedPositionByDegree,
-			Speed: 50,
-		})
-		if err != nil {
-			panic(err)
-		}
-		time.Sleep(time.Second * 3)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	err := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	switch {
+	case err == nil:
+	case errors.Is(err, flag.ErrHelp):
+		os.Exit(2)
+	case errors.Is(err, context.Canceled):
+		// Interrupted streaming commands are not failures.
+	default:
+		fmt.Fprintln(os.Stderr, "broadcastkit:", err)
+		os.Exit(1)
 	}
 }
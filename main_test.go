@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"puzzlekraken.com/broadcastkit/metus"
+	"puzzlekraken.com/broadcastkit/panasonic"
+	"puzzlekraken.com/broadcastkit/sony"
+)
+
+func TestLookupName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want panasonic.Bus
+		ok   bool
+	}{
+		{"ME1PGM", panasonic.BUS_ME1PGM, true},
+		{"me1key1-f", panasonic.BUS_ME1KEY1_F, true},
+		{"ME1KEY1 F", panasonic.BUS_ME1KEY1_F, true},
+		{"1", panasonic.Bus(1), true},
+		{"nope", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, ok := lookupName(panasonic.BusNameMap, tt.in)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("lookupName(%q) = %v, %v, want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRunMetus(t *testing.T) {
+	s := &metus.Server{}
+	s.AddEncoder("cam1", "")
+	s.AddEncoder("cam2", "")
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Serve(l)
+	defer s.Close()
+
+	// The address is taken from the config file, output is JSON.
+	conf := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(conf, []byte(`{"metus": {"address": "`+l.Addr().String()+`"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-config", conf, "-json", "metus", "start", "cam1"}, `{"cam1":"Runned"}` + "\n"},
+		{[]string{"-config", conf, "-json", "metus", "status"}, `{"cam1":"Runned","cam2":"Stopped"}` + "\n"},
+		{[]string{"-config", conf, "metus", "stop", "-all"}, "cam1=Stopped\ncam2=Stopped\n"},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if err := run(context.Background(), tt.args, &stdout, &stderr); err != nil {
+			t.Fatalf("run(%q) error = %v, stderr %s", tt.args, err, stderr.String())
+		}
+		if got := stdout.String(); got != tt.want {
+			t.Errorf("run(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+
+	// Stopping every recorder takes -all, never a missing or empty name.
+	if err := run(context.Background(), []string{"-config", conf, "metus", "start", "-all"}, io.Discard, io.Discard); err != nil {
+		t.Fatalf("start -all error = %v", err)
+	}
+	for _, args := range [][]string{{"stop"}, {"stop", ""}, {"stop", "-all", "cam1"}} {
+		if err := run(context.Background(), append([]string{"-config", conf, "metus"}, args...), io.Discard, io.Discard); err == nil {
+			t.Errorf("run(metus %q) error = nil, want an error", args)
+		}
+	}
+	for _, name := range []string{"cam1", "cam2"} {
+		if status, _, _, _ := s.Encoder(name); status != metus.StatusRunned {
+			t.Errorf("Encoder(%s) = %v, want %v", name, status, metus.StatusRunned)
+		}
+	}
+}
+
+func TestPrintParameters(t *testing.T) {
+	var ps []sony.Parameter
+	for _, kv := range [][2]string{{"Streaming", "on"}, {"TallyLampRed", "on"}, {"TallyLampRed", "off"}, {"TallyLampRed", "on"}} {
+		p, err := sony.ParseParameter(kv[0], kv[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		ps = append(ps, p)
+	}
+	var out bytes.Buffer
+	e := &env{out: printer{json: true, w: &out}}
+	if err := e.printParameters(ps); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"Streaming":"on","TallyLampRed":["on","off","on"]}` + "\n"; out.String() != want {
+		t.Errorf("printParameters() = %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,35 @@
+// Package metrics defines how protocol clients report device health.
+//
+// Clients and servers have an optional Metrics field taking a Recorder. The
+// prometheus subpackage provides a Recorder serving the measurements to a
+// Prometheus scraper.
+package metrics
+
+import "time"
+
+// Direction tells whether a notification was sent to or received from a device.
+type Direction string
+
+const (
+	Sent     Direction = "sent"
+	Received Direction = "received"
+)
+
+// Recorder receives measurements from protocol clients.
+//
+// Devices are identified by their address. Recorder methods are called from
+// the goroutines using the clients, so implementations must be safe for
+// concurrent use and should not block.
+type Recorder interface {
+	// Command reports a command which took d to complete. kind is empty on
+	// success, or classifies the failure by the error type, like AWError,
+	// SwitcherError, SystemError or "HTTP 503" for unexpected HTTP statuses.
+	Command(device string, command string, d time.Duration, kind string)
+	// Reconnect reports a connection opened to replace a lost one.
+	Reconnect(device string)
+	// Notification reports a single notification.
+	Notification(device string, dir Direction)
+	// NotifySessionErrors reports the count of consecutive delivery errors to
+	// a notification subscriber.
+	NotifySessionErrors(peer string, errors int)
+}
@@ -0,0 +1,207 @@
+// Package prometheus exports device metrics in the Prometheus text format.
+//
+// The exported families are:
+//
+//	broadcastkit_command_duration_seconds{device,command}    histogram
+//	broadcastkit_command_errors_total{device,command,kind}  counter
+//	broadcastkit_reconnects_total{device}                    counter
+//	broadcastkit_notifications_total{device,direction}       counter
+//	broadcastkit_notify_session_errors{peer}                 gauge
+package prometheus
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"puzzlekraken.com/broadcastkit/metrics"
+)
+
+// DefaultBuckets are the upper bounds in seconds of the latency histogram
+// buckets used when Exporter.Buckets is empty.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Exporter is a metrics.Recorder keeping measurements in memory. It is an
+// http.Handler serving them to be scraped.
+//
+// The zero value is ready to use. Buckets must not be changed after the first
+// measurement.
+type Exporter struct {
+	Buckets []float64
+
+	lock          sync.Mutex
+	durations     map[[2]string]*histogram
+	errors        map[[3]string]uint64
+	reconnects    map[string]uint64
+	notifications map[[2]string]uint64
+	sessionErrors map[string]int
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+func (e *Exporter) buckets() []float64 {
+	if len(e.Buckets) == 0 {
+		return DefaultBuckets
+	}
+	return e.Buckets
+}
+
+func (e *Exporter) Command(device string, command string, d time.Duration, kind string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.durations == nil {
+		e.durations = make(map[[2]string]*histogram)
+	}
+	k := [2]string{device, command}
+	h := e.durations[k]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(e.buckets()))}
+		e.durations[k] = h
+	}
+	s := d.Seconds()
+	if i, _ := slices.BinarySearch(e.buckets(), s); i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.sum += s
+	h.count++
+	if kind != "" {
+		if e.errors == nil {
+			e.errors = make(map[[3]string]uint64)
+		}
+		e.errors[[3]string{device, command, kind}]++
+	}
+}
+
+func (e *Exporter) Reconnect(device string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.reconnects == nil {
+		e.reconnects = make(map[string]uint64)
+	}
+	e.reconnects[device]++
+}
+
+func (e *Exporter) Notification(device string, dir metrics.Direction) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.notifications == nil {
+		e.notifications = make(map[[2]string]uint64)
+	}
+	e.notifications[[2]string{device, string(dir)}]++
+}
+
+func (e *Exporter) NotifySessionErrors(peer string, errors int) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.sessionErrors == nil {
+		e.sessionErrors = make(map[string]int)
+	}
+	e.sessionErrors[peer] = errors
+}
+
+// WriteTo writes all metrics in the Prometheus text format. Series are sorted
+// by their labels, so the output is stable.
+func (e *Exporter) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	b := bufio.NewWriter(cw)
+	e.lock.Lock()
+	e.write(b)
+	e.lock.Unlock()
+	err := b.Flush()
+	return cw.n, err
+}
+
+// write renders the metrics. Must be called with e.lock held.
+func (e *Exporter) write(b *bufio.Writer) {
+	header(b, "broadcastkit_command_duration_seconds", "histogram", "Latency of commands sent to devices.")
+	for _, k := range sortedKeys(e.durations) {
+		h := e.durations[k]
+		labels := `device="` + escape(k[0]) + `",command="` + escape(k[1]) + `"`
+		var cum uint64
+		for i, le := range e.buckets() {
+			cum += h.counts[i]
+			fmt.Fprintf(b, "broadcastkit_command_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels, formatFloat(le), cum)
+		}
+		fmt.Fprintf(b, "broadcastkit_command_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(b, "broadcastkit_command_duration_seconds_sum{%s} %s\n", labels, formatFloat(h.sum))
+		fmt.Fprintf(b, "broadcastkit_command_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+
+	header(b, "broadcastkit_command_errors_total", "counter", "Failed commands by error type.")
+	for _, k := range sortedKeys(e.errors) {
+		fmt.Fprintf(b, "broadcastkit_command_errors_total{device=\"%s\",command=\"%s\",kind=\"%s\"} %d\n", escape(k[0]), escape(k[1]), escape(k[2]), e.errors[k])
+	}
+
+	header(b, "broadcastkit_reconnects_total", "counter", "Connections opened to replace lost ones.")
+	for _, k := range sortedKeys(e.reconnects) {
+		fmt.Fprintf(b, "broadcastkit_reconnects_total{device=\"%s\"} %d\n", escape(k), e.reconnects[k])
+	}
+
+	header(b, "broadcastkit_notifications_total", "counter", "Notifications sent to or received from devices.")
+	for _, k := range sortedKeys(e.notifications) {
+		fmt.Fprintf(b, "broadcastkit_notifications_total{device=\"%s\",direction=\"%s\"} %d\n", escape(k[0]), escape(k[1]), e.notifications[k])
+	}
+
+	header(b, "broadcastkit_notify_session_errors", "gauge", "Consecutive delivery errors of notification subscribers.")
+	for _, k := range sortedKeys(e.sessionErrors) {
+		fmt.Fprintf(b, "broadcastkit_notify_session_errors{peer=\"%s\"} %d\n", escape(k), e.sessionErrors[k])
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	e.WriteTo(w)
+}
+
+func header(b *bufio.Writer, name, typ, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sortedKeys returns the keys of m in order. Array keys are ordered element
+// by element.
+func sortedKeys[K comparable, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b K) int {
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	})
+	return keys
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escape quotes a label value.
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+var _ metrics.Recorder = (*Exporter)(nil)
+var _ http.Handler = (*Exporter)(nil)
@@ -0,0 +1,84 @@
+package prometheus
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"puzzlekraken.com/broadcastkit/metrics"
+)
+
+func TestExporter(t *testing.T) {
+	e := &Exporter{Buckets: []float64{0.01, 0.1, 1}}
+	e.Command("10.0.0.20:80", "AWPanTilt", 5*time.Millisecond, "")
+	e.Command("10.0.0.20:80", "AWPanTilt", 100*time.Millisecond, "AWError")
+	e.Command("10.0.0.20:80", "AWPanTilt", 3*time.Second, "HTTP 503")
+	e.Command("10.0.0.5:62000", "SBUS", 20*time.Millisecond, "")
+	e.Reconnect("10.0.0.5:62000")
+	e.Reconnect("10.0.0.5:62000")
+	e.Notification("10.0.0.20:80", metrics.Received)
+	e.Notification("192.0.2.1:31004", metrics.Sent)
+	e.NotifySessionErrors("192.0.2.1:31004", 1)
+	e.NotifySessionErrors("192.0.2.1:31004", 0)
+	e.Command(`bad"name`, "x\ny", time.Millisecond, `a\b`)
+
+	want := `# HELP broadcastkit_command_duration_seconds Latency of commands sent to devices.
+# TYPE broadcastkit_command_duration_seconds histogram
+broadcastkit_command_duration_seconds_bucket{device="10.0.0.20:80",command="AWPanTilt",le="0.01"} 1
+broadcastkit_command_duration_seconds_bucket{device="10.0.0.20:80",command="AWPanTilt",le="0.1"} 2
+broadcastkit_command_duration_seconds_bucket{device="10.0.0.20:80",command="AWPanTilt",le="1"} 2
+broadcastkit_command_duration_seconds_bucket{device="10.0.0.20:80",command="AWPanTilt",le="+Inf"} 3
+broadcastkit_command_duration_seconds_sum{device="10.0.0.20:80",command="AWPanTilt"} 3.105
+broadcastkit_command_duration_seconds_count{device="10.0.0.20:80",command="AWPanTilt"} 3
+broadcastkit_command_duration_seconds_bucket{device="10.0.0.5:62000",command="SBUS",le="0.01"} 0
+broadcastkit_command_duration_seconds_bucket{device="10.0.0.5:62000",command="SBUS",le="0.1"} 1
+broadcastkit_command_duration_seconds_bucket{device="10.0.0.5:62000",command="SBUS",le="1"} 1
+broadcastkit_command_duration_seconds_bucket{device="10.0.0.5:62000",command="SBUS",le="+Inf"} 1
+broadcastkit_command_duration_seconds_sum{device="10.0.0.5:62000",command="SBUS"} 0.02
+broadcastkit_command_duration_seconds_count{device="10.0.0.5:62000",command="SBUS"} 1
+broadcastkit_command_duration_seconds_bucket{device="bad\"name",command="x\ny",le="0.01"} 1
+broadcastkit_command_duration_seconds_bucket{device="bad\"name",command="x\ny",le="0.1"} 1
+broadcastkit_command_duration_seconds_bucket{device="bad\"name",command="x\ny",le="1"} 1
+broadcastkit_command_duration_seconds_bucket{device="bad\"name",command="x\ny",le="+Inf"} 1
+broadcastkit_command_duration_seconds_sum{device="bad\"name",command="x\ny"} 0.001
+broadcastkit_command_duration_seconds_count{device="bad\"name",command="x\ny"} 1
+# HELP broadcastkit_command_errors_total Failed commands by error type.
+# TYPE broadcastkit_command_errors_total counter
+broadcastkit_command_errors_total{device="10.0.0.20:80",command="AWPanTilt",kind="AWError"} 1
+broadcastkit_command_errors_total{device="10.0.0.20:80",command="AWPanTilt",kind="HTTP 503"} 1
+broadcastkit_command_errors_total{device="bad\"name",command="x\ny",kind="a\\b"} 1
+# HELP broadcastkit_reconnects_total Connections opened to replace lost ones.
+# TYPE broadcastkit_reconnects_total counter
+broadcastkit_reconnects_total{device="10.0.0.5:62000"} 2
+# HELP broadcastkit_notifications_total Notifications sent to or received from devices.
+# TYPE broadcastkit_notifications_total counter
+broadcastkit_notifications_total{device="10.0.0.20:80",direction="received"} 1
+broadcastkit_notifications_total{device="192.0.2.1:31004",direction="sent"} 1
+# HELP broadcastkit_notify_session_errors Consecutive delivery errors of notification subscribers.
+# TYPE broadcastkit_notify_session_errors gauge
+broadcastkit_notify_session_errors{peer="192.0.2.1:31004"} 0
+`
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	got, _ := io.ReadAll(rec.Body)
+	if string(got) != want {
+		t.Errorf("ServeHTTP() =\n%s\nwant\n%s", got, want)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+}
+
+func TestExporter_Empty(t *testing.T) {
+	var b strings.Builder
+	n, err := (&Exporter{}).WriteTo(&b)
+	if err != nil || n != int64(b.Len()) {
+		t.Errorf("WriteTo() = %d, %v, want %d, nil", n, err, b.Len())
+	}
+	if strings.Contains(b.String(), "{") {
+		t.Errorf("WriteTo() wrote series without measurements:\n%s", b.String())
+	}
+}
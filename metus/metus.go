@@ -3,51 +3,251 @@ package metus
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
 	"net/netip"
+	"os"
 	"sync"
+	"time"
+
+	"puzzlekraken.com/broadcastkit/internal/wirelog"
+	"puzzlekraken.com/broadcastkit/metrics"
 )
 
+// networkTimeout bounds every command when the context has no deadline.
+const networkTimeout = 5 * time.Second
+
+// MetusSocket is a connection to the control port of a Metus Ingest instance.
+//
+// If Remote is set, the connection is dialed on first use and re-dialed after
+// a transport failure. Conn may be set directly instead, in which case a
+// broken connection is not recovered.
+//
+// MetusSocket must not be copied after first use.
+// MetusSocket is safe to use from multiple goroutines.
 type MetusSocket struct {
-	Conn io.ReadWriter
-	lock sync.Mutex
-	once sync.Once
-	buf  *bufio.Reader
+	Remote netip.AddrPort
+	Conn   io.ReadWriter
+	// Logger, if set, receives the commands and reply lines at debug level,
+	// and the reconnects at info level.
+	Logger *slog.Logger
+	// Metrics, if set, receives the latency and errors of commands and the
+	// reconnects.
+	Metrics metrics.Recorder
+	dialed  bool
+	lock    sync.Mutex
+	buf     *bufio.Reader
+}
+
+// ErrEmptyName is returned by the commands of a named encoder if the name is
+// empty. The commands of all encoders have their own methods, like StopAll.
+var ErrEmptyName = errors.New("broadcastkit/metus: empty encoder name")
+
+// ErrNotConnected is returned by commands of a MetusSocket without a Remote
+// whose connection was closed.
+var ErrNotConnected = errors.New("broadcastkit/metus: not connected")
+
+// ReplyError is an error reply sent by Metus Ingest.
+type ReplyError struct {
+	Reply string
+}
+
+func (e *ReplyError) Error() string {
+	return fmt.Sprintf("broadcastkit/metus: error reply: %s", e.Reply)
+}
+
+// SystemError is a failure of the transport below the Metus protocol.
+type SystemError struct {
+	parent error
+}
+
+func (e *SystemError) Error() string {
+	return fmt.Sprintf("broadcastkit/metus: system failure: %s", e.parent.Error())
+}
+
+func (e *SystemError) Unwrap() error {
+	return e.parent
+}
+
+// errorKind classifies err by type for metrics.Recorder.
+func errorKind(err error) string {
+	var reply *ReplyError
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &reply):
+		return "ReplyError"
+	default:
+		return "SystemError"
+	}
 }
 
 func Connect(address netip.AddrPort) (*MetusSocket, error) {
 	if !address.IsValid() {
 		return nil, errors.New("invalid address")
 	}
+	m := &MetusSocket{
+		Remote: address,
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if err := m.dial(context.Background()); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// dial opens a new connection to Remote.
+//
+// Must be called with m.lock held.
+func (m *MetusSocket) dial(ctx context.Context) error {
+	address := m.Remote
 	if address.Port() == 0 {
 		address = netip.AddrPortFrom(address.Addr(), 32106)
 	}
-	conn, err := net.Dial("tcp", address.String())
+	dialer := net.Dialer{Timeout: networkTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address.String())
 	if err != nil {
-		return nil, err
+		return &SystemError{err}
+	}
+	m.Conn = conn
+	m.buf = nil
+	if m.dialed && m.Metrics != nil {
+		m.Metrics.Reconnect(m.Remote.String())
 	}
-	return &MetusSocket{
-		Conn: conn,
-	}, nil
+	m.dialed = true
+	wirelog.Or(m.Logger).InfoContext(ctx, "connected", "remote", address)
+	return nil
 }
 
-func (m *MetusSocket) command(cmd []byte, emtpyEnd int) ([][]byte, error) {
-	m.once.Do(func() {
-		m.buf = bufio.NewReader(m.Conn)
-	})
+// drop closes and forgets a broken connection, so the next command redials.
+//
+// Connections set by the user are closed but kept, because there is no way to
+// replace them.
+//
+// Must be called with m.lock held.
+func (m *MetusSocket) drop() {
+	if c, ok := m.Conn.(io.Closer); ok {
+		c.Close()
+	}
+	if m.Remote.IsValid() {
+		m.Conn = nil
+	}
+	m.buf = nil
+}
+
+// Close closes the connection to Metus Ingest.
+//
+// A MetusSocket with a Remote reconnects upon the next command.
+func (m *MetusSocket) Close() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	var err error
+	if c, ok := m.Conn.(io.Closer); ok {
+		err = c.Close()
+	}
+	m.Conn = nil
+	m.buf = nil
+	return err
+}
+
+// command sends cmd and reads the reply, reconnecting if necessary.
+//
+// A connection which was idle before the command may have been closed by the
+// server in the meantime. When such a connection fails before any reply is
+// read, the command is retried once on a fresh connection. Commands which
+// may have reached the server are only retried if they are idempotent, so a
+// Start or Split never runs twice.
+//
+// Must be called with m.lock held.
+func (m *MetusSocket) command(ctx context.Context, cmd []byte, emtpyEnd int) (_ [][]byte, err error) {
+	// Commands are named by their verb, like Start.
+	verb, _, _ := bytes.Cut(bytes.TrimSpace(cmd), []byte(" "))
+	if m.Metrics != nil {
+		defer func(start time.Time) {
+			m.Metrics.Command(m.Remote.String(), string(verb), time.Since(start), errorKind(err))
+		}(time.Now())
+	}
+	reused := m.Conn != nil
+	lines, sent, replied, err := m.exchange(ctx, cmd, emtpyEnd)
+	var syserr *SystemError
+	retry := !sent || (!replied && idempotent[string(verb)])
+	if reused && retry && errors.As(err, &syserr) && m.Remote.IsValid() && ctx.Err() == nil {
+		wirelog.Or(m.Logger).InfoContext(ctx, "retrying on a new connection", "err", err)
+		lines, _, _, err = m.exchange(ctx, cmd, emtpyEnd)
+	}
+	return lines, err
+}
+
+// idempotent are the verbs which can be repeated without changing the outcome.
+var idempotent = map[string]bool{
+	"EncStatus":   true,
+	"Prepare":     true,
+	"SetFileName": true,
+}
+
+// exchange does a single write and read cycle of command.
+//
+// The returned bools report whether the command was written, and whether any
+// part of the reply was received.
+//
+// Must be called with m.lock held.
+func (m *MetusSocket) exchange(ctx context.Context, cmd []byte, emtpyEnd int) (_ [][]byte, sent bool, replied bool, _ error) {
 	if m.Conn == nil {
-		return nil, errors.New("not connected")
+		if !m.Remote.IsValid() {
+			return nil, false, false, ErrNotConnected
+		}
+		if err := m.dial(ctx); err != nil {
+			return nil, false, false, err
+		}
+	}
+	if m.buf == nil {
+		m.buf = bufio.NewReader(m.Conn)
+	}
+
+	_, ctxDeadline := ctx.Deadline()
+	if conn, ok := m.Conn.(interface{ SetDeadline(time.Time) error }); ok {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			deadline = time.Now().Add(networkTimeout)
+		}
+		conn.SetDeadline(deadline)
+		// Cancellation is delivered by moving the deadline into the past,
+		// which unblocks any pending Read or Write.
+		stop := context.AfterFunc(ctx, func() {
+			conn.SetDeadline(time.Unix(1, 0))
+		})
+		defer stop()
 	}
-	m.Conn.Write(cmd)
+
+	fail := func(replied bool, err error) ([][]byte, bool, bool, error) {
+		m.drop()
+		if ctxDeadline && errors.Is(err, os.ErrDeadlineExceeded) {
+			// The connection deadline may fire a moment before the context.
+			<-ctx.Done()
+		}
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return nil, sent, replied, &SystemError{err}
+	}
+
+	wirelog.Send(m.Logger, cmd)
+	if _, err := m.Conn.Write(cmd); err != nil {
+		return fail(false, err)
+	}
+	sent = true
 	var firstLine []byte
 	var err error
 	for {
 		firstLine, err = m.buf.ReadBytes('\n')
+		wirelog.Recv(m.Logger, firstLine)
 		if err != nil {
-			return nil, err
+			return fail(len(firstLine) > 0, err)
 		}
 		firstLine = bytes.TrimSpace(firstLine)
 		if len(firstLine) == 0 {
@@ -55,22 +255,30 @@ func (m *MetusSocket) command(cmd []byte, emtpyEnd int) ([][]byte, error) {
 		}
 		break
 	}
-	if !bytes.HasPrefix(firstLine, []byte("OK: ")) {
-		return nil, fmt.Errorf("unexpected response: %v", firstLine)
+	rest, ok := bytes.CutPrefix(firstLine, []byte("OK:"))
+	if !ok {
+		// Error replies are terminated by a single blank line, which is
+		// skipped as leading whitespace of the next reply.
+		reply, _ := bytes.CutPrefix(firstLine, []byte("ERR: "))
+		return nil, true, true, &ReplyError{string(reply)}
 	}
 	var lines [][]byte
 	var empties int
-	lines = append(lines, firstLine[4:])
+	// A status of no encoders is a bare OK line.
+	if rest = bytes.TrimSpace(rest); len(rest) > 0 {
+		lines = append(lines, rest)
+	}
 	for {
 		line, err := m.buf.ReadBytes('\n')
+		wirelog.Recv(m.Logger, line)
 		if err != nil {
-			return nil, err
+			return fail(true, err)
 		}
 		line = bytes.TrimSpace(line)
 		if len(line) == 0 {
 			empties++
 			if empties >= emtpyEnd {
-				return lines, nil
+				return lines, true, true, nil
 			}
 			continue
 		}
@@ -79,36 +287,177 @@ func (m *MetusSocket) command(cmd []byte, emtpyEnd int) ([][]byte, error) {
 	}
 }
 
-func (m *MetusSocket) StartAll() error {
-	m.lock.Lock()
-	defer m.lock.Unlock()
-	_, err := m.command([]byte("Start\r\n"), 2)
-	return err
+// encoderCommand sends verb to the named encoder, followed by optional args.
+//
+// An empty name is refused, because the server would read the command as
+// addressing all encoders.
+func (m *MetusSocket) encoderCommand(ctx context.Context, verb string, name string, args ...string) error {
+	if name == "" {
+		return ErrEmptyName
+	}
+	return m.sendCommand(ctx, verb, append([]string{name}, args...), 1)
 }
 
-func (m *MetusSocket) Start(name string) error {
+// allCommand sends verb to all encoders, followed by optional args. The
+// reply of all encoders is terminated by two blank lines instead of one.
+func (m *MetusSocket) allCommand(ctx context.Context, verb string, args ...string) error {
+	return m.sendCommand(ctx, verb, args, 2)
+}
+
+// sendCommand sends verb with its quoted args and reads the reply.
+func (m *MetusSocket) sendCommand(ctx context.Context, verb string, args []string, emptyEnd int) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 	var buf bytes.Buffer
-	fmt.Fprintf(&buf, "Start %q\r\n", name)
-	_, err := m.command(buf.Bytes(), 1)
+	buf.WriteString(verb)
+	for _, arg := range args {
+		fmt.Fprintf(&buf, " %q", arg)
+	}
+	buf.WriteString("\r\n")
+	_, err := m.command(ctx, buf.Bytes(), emptyEnd)
 	return err
 }
 
+// StartAll starts recording on all encoders.
+func (m *MetusSocket) StartAll() error {
+	return m.StartAllCtx(context.Background())
+}
+
+// StartAllCtx is like StartAll, with ctx bounding the command.
+func (m *MetusSocket) StartAllCtx(ctx context.Context) error {
+	return m.allCommand(ctx, "Start")
+}
+
+// Start starts recording on the named encoder.
+func (m *MetusSocket) Start(name string) error {
+	return m.StartCtx(context.Background(), name)
+}
+
+// StartCtx is like Start, with ctx bounding the command.
+func (m *MetusSocket) StartCtx(ctx context.Context, name string) error {
+	return m.encoderCommand(ctx, "Start", name)
+}
+
+// StopAll stops recording on all encoders.
 func (m *MetusSocket) StopAll() error {
-	m.lock.Lock()
-	defer m.lock.Unlock()
-	_, err := m.command([]byte("Stop\r\n"), 2)
-	return err
+	return m.StopAllCtx(context.Background())
+}
+
+// StopAllCtx is like StopAll, with ctx bounding the command.
+func (m *MetusSocket) StopAllCtx(ctx context.Context) error {
+	return m.allCommand(ctx, "Stop")
 }
 
+// Stop stops recording on the named encoder.
 func (m *MetusSocket) Stop(name string) error {
-	m.lock.Lock()
-	defer m.lock.Unlock()
-	var buf bytes.Buffer
-	fmt.Fprintf(&buf, "Stop %q\r\n", name)
-	_, err := m.command(buf.Bytes(), 1)
-	return err
+	return m.StopCtx(context.Background(), name)
+}
+
+// StopCtx is like Stop, with ctx bounding the command.
+func (m *MetusSocket) StopCtx(ctx context.Context, name string) error {
+	return m.encoderCommand(ctx, "Stop", name)
+}
+
+// PauseAll pauses recording on all encoders.
+func (m *MetusSocket) PauseAll() error {
+	return m.PauseAllCtx(context.Background())
+}
+
+// PauseAllCtx is like PauseAll, with ctx bounding the command.
+func (m *MetusSocket) PauseAllCtx(ctx context.Context) error {
+	return m.allCommand(ctx, "Pause")
+}
+
+// Pause pauses recording on the named encoder.
+func (m *MetusSocket) Pause(name string) error {
+	return m.PauseCtx(context.Background(), name)
+}
+
+// PauseCtx is like Pause, with ctx bounding the command.
+func (m *MetusSocket) PauseCtx(ctx context.Context, name string) error {
+	return m.encoderCommand(ctx, "Pause", name)
+}
+
+// ResumeAll resumes recording on all paused encoders.
+func (m *MetusSocket) ResumeAll() error {
+	return m.ResumeAllCtx(context.Background())
+}
+
+// ResumeAllCtx is like ResumeAll, with ctx bounding the command.
+func (m *MetusSocket) ResumeAllCtx(ctx context.Context) error {
+	return m.allCommand(ctx, "Resume")
+}
+
+// Resume resumes recording on the named encoder.
+func (m *MetusSocket) Resume(name string) error {
+	return m.ResumeCtx(context.Background(), name)
+}
+
+// ResumeCtx is like Resume, with ctx bounding the command.
+func (m *MetusSocket) ResumeCtx(ctx context.Context, name string) error {
+	return m.encoderCommand(ctx, "Resume", name)
+}
+
+// SplitAll closes the current file on all encoders and continues recording
+// into a new one.
+func (m *MetusSocket) SplitAll() error {
+	return m.SplitAllCtx(context.Background())
+}
+
+// SplitAllCtx is like SplitAll, with ctx bounding the command.
+func (m *MetusSocket) SplitAllCtx(ctx context.Context) error {
+	return m.allCommand(ctx, "Split")
+}
+
+// Split closes the current file of the named encoder and continues recording
+// into a new one.
+func (m *MetusSocket) Split(name string) error {
+	return m.SplitCtx(context.Background(), name)
+}
+
+// SplitCtx is like Split, with ctx bounding the command.
+func (m *MetusSocket) SplitCtx(ctx context.Context, name string) error {
+	return m.encoderCommand(ctx, "Split", name)
+}
+
+// Prepare selects the named encoder ahead of the next Start.
+//
+// If profile is not empty, the encoder is switched to that profile as well,
+// otherwise the encoder keeps its current profile and only the name is sent.
+// There is no form preparing every encoder, as a single argument names the
+// encoder.
+func (m *MetusSocket) Prepare(name string, profile string) error {
+	return m.PrepareCtx(context.Background(), name, profile)
+}
+
+// PrepareCtx is like Prepare, with ctx bounding the command.
+func (m *MetusSocket) PrepareCtx(ctx context.Context, name string, profile string) error {
+	if profile == "" {
+		return m.encoderCommand(ctx, "Prepare", name)
+	}
+	return m.encoderCommand(ctx, "Prepare", name, profile)
+}
+
+// SetFileNameAll sets the file/clip name used by all encoders for the next
+// recording.
+func (m *MetusSocket) SetFileNameAll(filename string) error {
+	return m.SetFileNameAllCtx(context.Background(), filename)
+}
+
+// SetFileNameAllCtx is like SetFileNameAll, with ctx bounding the command.
+func (m *MetusSocket) SetFileNameAllCtx(ctx context.Context, filename string) error {
+	return m.allCommand(ctx, "SetFileName", filename)
+}
+
+// SetFileName sets the file/clip name used by the named encoder for the next
+// recording.
+func (m *MetusSocket) SetFileName(name string, filename string) error {
+	return m.SetFileNameCtx(context.Background(), name, filename)
+}
+
+// SetFileNameCtx is like SetFileName, with ctx bounding the command.
+func (m *MetusSocket) SetFileNameCtx(ctx context.Context, name string, filename string) error {
+	return m.encoderCommand(ctx, "SetFileName", name, filename)
 }
 
 type Status int
@@ -169,26 +518,71 @@ func toStatus(status []byte) (Status, error) {
 	}
 }
 
+// String returns the status as spelled by Metus Ingest.
+func (s Status) String() string {
+	switch s {
+	case StatusNone:
+		return "None"
+	case StatusRunning:
+		return "Running"
+	case StatusRunned:
+		return "Runned"
+	case StatusStopping:
+		return "Stopping"
+	case StatusStopped:
+		return "Stopped"
+	case StatusPausing:
+		return "Pausing"
+	case StatusPaused:
+		return "Paused"
+	case StatusPreparing:
+		return "Preparing"
+	case StatusPrepared:
+		return "Prepared"
+	case StatusSplitting:
+		return "Splitting"
+	case StatusSplitted:
+		return "Splitted"
+	default:
+		return fmt.Sprintf("Status(%d)", int(s))
+	}
+}
+
+// Status returns the status of the named encoder.
 func (m *MetusSocket) Status(name string) (Status, error) {
+	return m.StatusCtx(context.Background(), name)
+}
+
+// StatusCtx is like Status, with ctx bounding the command.
+func (m *MetusSocket) StatusCtx(ctx context.Context, name string) (Status, error) {
+	if name == "" {
+		return -1, ErrEmptyName
+	}
 	m.lock.Lock()
 	defer m.lock.Unlock()
 	var buf bytes.Buffer
 	fmt.Fprintf(&buf, "EncStatus %q\r\n", name)
-	reply, err := m.command(buf.Bytes(), 1)
+	reply, err := m.command(ctx, buf.Bytes(), 1)
 	if err != nil {
 		return -1, err
 	}
-	if !bytes.HasPrefix(reply[0], []byte(name+":")) {
+	if len(reply) == 0 || !bytes.HasPrefix(reply[0], []byte(name+":")) {
 		return -1, fmt.Errorf("unexpected reply: %v", reply)
 	}
 	status := reply[0][len(name)+1:]
 	return toStatus(status)
 }
 
+// StatusAll returns the status of every encoder by name.
 func (m *MetusSocket) StatusAll() (map[string]Status, error) {
+	return m.StatusAllCtx(context.Background())
+}
+
+// StatusAllCtx is like StatusAll, with ctx bounding the command.
+func (m *MetusSocket) StatusAllCtx(ctx context.Context) (map[string]Status, error) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
-	reply, err := m.command([]byte("EncStatus\r\n"), 1)
+	reply, err := m.command(ctx, []byte("EncStatus\r\n"), 1)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,143 @@
+package metus
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReplyError(t *testing.T) {
+	s := &Server{}
+	m := testPair(t, s)
+	err := m.Start("missing")
+	var replyErr *ReplyError
+	if !errors.As(err, &replyErr) {
+		t.Fatalf("Start() error = %v, want *ReplyError", err)
+	}
+	if replyErr.Reply != "unknown encoder: missing" {
+		t.Errorf("ReplyError.Reply = %q, want %q", replyErr.Reply, "unknown encoder: missing")
+	}
+}
+
+func TestCommandCtx(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	m := &MetusSocket{Conn: client}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := m.StartCtx(ctx, "enc1")
+	var sysErr *SystemError
+	if !errors.As(err, &sysErr) {
+		t.Fatalf("StartCtx() error = %v, want *SystemError", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("StartCtx() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestPrepare(t *testing.T) {
+	tests := []struct {
+		name, profile string
+		want          string
+	}{
+		{"enc1", "", "Prepare \"enc1\"\r\n"},
+		{"enc1", "4K", "Prepare \"enc1\" \"4K\"\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer client.Close()
+			defer server.Close()
+			sent := make(chan string, 1)
+			go func() {
+				line, _ := bufio.NewReader(server).ReadString('\n')
+				sent <- line
+				io.WriteString(server, "OK: enc1:Prepared\r\n\r\n")
+			}()
+			m := &MetusSocket{Conn: client}
+			if err := m.Prepare(tt.name, tt.profile); err != nil {
+				t.Fatalf("Prepare() error = %v", err)
+			}
+			if got := <-sent; got != tt.want {
+				t.Errorf("Prepare() sent %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Stop may have run before the drop, so it is not retried.
+	drops := 1
+	var stops atomic.Int32
+	s := &Server{
+		Fail: func(verb string, name string) error {
+			if verb != "Stop" {
+				return nil
+			}
+			stops.Add(1)
+			if drops > 0 {
+				drops--
+				return ErrEmulatedDrop
+			}
+			return nil
+		},
+	}
+	s.AddEncoder("enc1", "")
+	go s.Serve(l)
+	defer s.Close()
+
+	m, err := Connect(netip.MustParseAddrPort(l.Addr().String()))
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer m.Close()
+
+	if err := m.Start("enc1"); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	var sysErr *SystemError
+	if err := m.Stop("enc1"); !errors.As(err, &sysErr) {
+		t.Fatalf("Stop() error = %v, want *SystemError", err)
+	}
+	if n := stops.Load(); n != 1 {
+		t.Errorf("Stop() was sent %d times, want 1", n)
+	}
+	if err := m.Stop("enc1"); err != nil {
+		t.Fatalf("Stop() after drop error = %v", err)
+	}
+	if got, _ := m.Status("enc1"); got != StatusStopped {
+		t.Errorf("Status() = %v, want %v", got, StatusStopped)
+	}
+
+	// A connection closed while idle is replaced transparently.
+	s.lock.Lock()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.lock.Unlock()
+	if got, err := m.Status("enc1"); err != nil || got != StatusStopped {
+		t.Errorf("Status() after idle close = %v, %v, want %v", got, err, StatusStopped)
+	}
+}
+
+func TestNotConnected(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	m := &MetusSocket{Conn: client}
+	m.Close()
+	if _, err := m.StatusAll(); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("StatusAll() after Close() error = %v, want %v", err, ErrNotConnected)
+	}
+}
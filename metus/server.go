@@ -0,0 +1,358 @@
+package metus
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrEmulatedDrop can be returned from Server.Fail to close the connection
+// instead of replying, as if the network or the Ingest process went away.
+var ErrEmulatedDrop = errors.New("broadcastkit/metus: emulated connection drop")
+
+// Server emulates the control port of a Metus Ingest instance.
+//
+// The emulator is meant for testing automation without a real recorder. It
+// keeps a simulated state machine for every encoder added by AddEncoder and
+// answers the commands sent by MetusSocket with the same framing.
+//
+// Transitional states (Running, Stopping, Pausing, Preparing, Splitting) are
+// reported until Step has elapsed, and settle into their final state after.
+// A zero Step settles every transition immediately.
+//
+// Server must not be copied after first use.
+// Server is safe to use from multiple goroutines.
+type Server struct {
+	// Step is the time an encoder spends in a transitional state.
+	Step time.Duration
+	// Fail, if set, is consulted before every command. A non-nil error is
+	// sent to the client as an error reply and the command is not executed.
+	// See also ErrEmulatedDrop.
+	Fail func(verb string, name string) error
+
+	lock     sync.Mutex
+	encoders map[string]*emuEncoder
+	order    []string
+	listener net.Listener
+	closed   bool
+	conns    map[io.ReadWriteCloser]struct{}
+}
+
+// emuEncoder is the simulated state of a single encoder.
+type emuEncoder struct {
+	status   Status
+	next     Status
+	until    time.Time
+	profile  string
+	filename string
+}
+
+// settle advances the encoder to its final state if Step has elapsed.
+func (e *emuEncoder) settle(now time.Time) {
+	if e.next != e.status && !now.Before(e.until) {
+		e.status = e.next
+	}
+}
+
+// move puts the encoder into a transitional state, ending in final.
+func (e *emuEncoder) move(now time.Time, step time.Duration, trans Status, final Status) {
+	e.status = trans
+	e.next = final
+	e.until = now.Add(step)
+	e.settle(now)
+}
+
+// AddEncoder adds an encoder to the emulator in the Stopped state.
+//
+// Adding an existing encoder resets its state.
+func (s *Server) AddEncoder(name string, profile string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.encoders == nil {
+		s.encoders = make(map[string]*emuEncoder)
+	}
+	if _, ok := s.encoders[name]; !ok {
+		s.order = append(s.order, name)
+	}
+	s.encoders[name] = &emuEncoder{
+		status:  StatusStopped,
+		next:    StatusStopped,
+		profile: profile,
+	}
+}
+
+// Encoder returns the current status, profile and file name of an encoder.
+//
+// The returned bool is false if no such encoder exists.
+func (s *Server) Encoder(name string) (Status, string, string, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	e, ok := s.encoders[name]
+	if !ok {
+		return StatusNone, "", "", false
+	}
+	e.settle(time.Now())
+	return e.status, e.profile, e.filename, true
+}
+
+// ListenAndServe listens on the TCP address addr and serves connections.
+//
+// The default port 32106 is used if addr does not contain one.
+func (s *Server) ListenAndServe(addr string) error {
+	if addr == "" {
+		addr = "0.0.0.0:32106"
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(strings.Trim(addr, "[]"), "32106")
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l and serves each of them on a new goroutine.
+//
+// Serve always returns a non-nil error. After Close, the error is net.ErrClosed.
+func (s *Server) Serve(l net.Listener) error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		l.Close()
+		return net.ErrClosed
+	}
+	s.listener = l
+	s.lock.Unlock()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.ServeConn(conn)
+	}
+}
+
+// Close stops the listener and closes all active connections.
+func (s *Server) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for c := range s.conns {
+		c.Close()
+	}
+	s.conns = nil
+	return err
+}
+
+// ServeConn serves commands on a single connection until it fails.
+//
+// The connection is closed when ServeConn returns.
+func (s *Server) ServeConn(conn io.ReadWriteCloser) error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		conn.Close()
+		return net.ErrClosed
+	}
+	if s.conns == nil {
+		s.conns = make(map[io.ReadWriteCloser]struct{})
+	}
+	s.conns[conn] = struct{}{}
+	s.lock.Unlock()
+	defer func() {
+		s.lock.Lock()
+		delete(s.conns, conn)
+		s.lock.Unlock()
+		conn.Close()
+	}()
+
+	buf := bufio.NewReader(conn)
+	for {
+		line, err := buf.ReadBytes('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		reply, err := s.execute(line)
+		if errors.Is(err, ErrEmulatedDrop) {
+			return err
+		}
+		if _, err := conn.Write(reply); err != nil {
+			return err
+		}
+	}
+}
+
+// splitArgs splits a command line into the verb and its quoted arguments.
+func splitArgs(line []byte) (string, []string, error) {
+	verb, rest, _ := strings.Cut(string(line), " ")
+	var args []string
+	for {
+		rest = strings.TrimLeft(rest, " \t")
+		if rest == "" {
+			return verb, args, nil
+		}
+		q, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return verb, nil, fmt.Errorf("syntax error: %s", line)
+		}
+		arg, _ := strconv.Unquote(q)
+		args = append(args, arg)
+		rest = rest[len(q):]
+	}
+}
+
+// execute runs a single command line and returns the framed reply.
+func (s *Server) execute(line []byte) ([]byte, error) {
+	var out bytes.Buffer
+	verb, args, err := splitArgs(line)
+	if err != nil {
+		fmt.Fprintf(&out, "ERR: %s\r\n\r\n", err)
+		return out.Bytes(), nil
+	}
+
+	// Commands addressing every encoder carry one argument less. The reply
+	// then lists all encoders and is terminated by an extra blank line.
+	var params int
+	switch verb {
+	case "Start", "Stop", "Pause", "Resume", "Split", "EncStatus":
+		params = 0
+	case "SetFileName":
+		params = 1
+	case "Prepare":
+		// Prepare always names the encoder, optionally followed by a profile.
+		if len(args) == 0 || len(args) > 2 {
+			fmt.Fprintf(&out, "ERR: wrong number of arguments: %s\r\n\r\n", line)
+			return out.Bytes(), nil
+		}
+		params = len(args) - 1
+	default:
+		fmt.Fprintf(&out, "ERR: unknown command: %s\r\n\r\n", verb)
+		return out.Bytes(), nil
+	}
+	var name string
+	all := len(args) == params
+	switch {
+	case all:
+	case len(args) == params+1:
+		name, args = args[0], args[1:]
+	default:
+		fmt.Fprintf(&out, "ERR: wrong number of arguments: %s\r\n\r\n", line)
+		return out.Bytes(), nil
+	}
+
+	if s.Fail != nil {
+		if err := s.Fail(verb, name); err != nil {
+			if errors.Is(err, ErrEmulatedDrop) {
+				return nil, err
+			}
+			fmt.Fprintf(&out, "ERR: %s\r\n\r\n", err)
+			return out.Bytes(), nil
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := time.Now()
+
+	if verb == "EncStatus" {
+		names := []string{name}
+		if all {
+			names = s.order
+		} else if _, ok := s.encoders[name]; !ok {
+			fmt.Fprintf(&out, "ERR: unknown encoder: %s\r\n\r\n", name)
+			return out.Bytes(), nil
+		}
+		// The first encoder shares the line of OK, a reply without encoders
+		// is a bare OK line.
+		out.WriteString("OK: ")
+		if len(names) == 0 {
+			out.WriteString("\r\n")
+		}
+		for _, n := range names {
+			e := s.encoders[n]
+			e.settle(now)
+			fmt.Fprintf(&out, "%s:%s\r\n", n, e.status)
+		}
+		out.WriteString("\r\n")
+		return out.Bytes(), nil
+	}
+
+	if !all {
+		e, ok := s.encoders[name]
+		if !ok {
+			fmt.Fprintf(&out, "ERR: unknown encoder: %s\r\n\r\n", name)
+			return out.Bytes(), nil
+		}
+		if err := s.transition(now, e, verb, args); err != nil {
+			fmt.Fprintf(&out, "ERR: %s: %s\r\n\r\n", name, err)
+			return out.Bytes(), nil
+		}
+		fmt.Fprintf(&out, "OK: %s:%s\r\n\r\n", name, e.status)
+		return out.Bytes(), nil
+	}
+
+	// Encoders in an unsuitable state are skipped and reported, the same
+	// way the Ingest UI ignores them when operating on every encoder. The
+	// reply is terminated by two blank lines.
+	fmt.Fprintf(&out, "OK: %s\r\n", verb)
+	for _, n := range s.order {
+		e := s.encoders[n]
+		if err := s.transition(now, e, verb, args); err != nil {
+			fmt.Fprintf(&out, "%s:%s\r\n", n, err)
+			continue
+		}
+		fmt.Fprintf(&out, "%s:%s\r\n", n, e.status)
+	}
+	out.WriteString("\r\n\r\n")
+	return out.Bytes(), nil
+}
+
+// transition applies verb to the encoder state machine.
+//
+// Must be called with s.lock held.
+func (s *Server) transition(now time.Time, e *emuEncoder, verb string, args []string) error {
+	e.settle(now)
+	recording := e.status == StatusRunned || e.status == StatusSplitted
+	idle := e.status == StatusStopped || e.status == StatusPrepared || e.status == StatusNone
+	switch {
+	case verb == "Start" && idle:
+		e.move(now, s.Step, StatusRunning, StatusRunned)
+	case verb == "Stop" && (recording || e.status == StatusPaused):
+		e.move(now, s.Step, StatusStopping, StatusStopped)
+	case verb == "Pause" && recording:
+		e.move(now, s.Step, StatusPausing, StatusPaused)
+	case verb == "Resume" && e.status == StatusPaused:
+		e.move(now, s.Step, StatusRunning, StatusRunned)
+	case verb == "Split" && recording:
+		e.move(now, s.Step, StatusSplitting, StatusSplitted)
+	case verb == "Prepare" && idle:
+		if len(args) > 0 && args[0] != "" {
+			e.profile = args[0]
+		}
+		e.move(now, s.Step, StatusPreparing, StatusPrepared)
+	case verb == "SetFileName" && !recording:
+		e.filename = args[0]
+	default:
+		return fmt.Errorf("cannot %s while %s", verb, e.status)
+	}
+	return nil
+}
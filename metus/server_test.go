@@ -0,0 +1,219 @@
+package metus
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func testPair(t *testing.T, s *Server) *MetusSocket {
+	client, server := net.Pipe()
+	go s.ServeConn(server)
+	t.Cleanup(func() { client.Close() })
+	return &MetusSocket{Conn: client}
+}
+
+func TestServerStateMachine(t *testing.T) {
+	s := &Server{}
+	s.AddEncoder("enc1", "HD")
+	s.AddEncoder("enc2", "HD")
+	m := testPair(t, s)
+
+	tests := []struct {
+		name string
+		do   func() error
+		want map[string]Status
+	}{
+		{"start one", func() error { return m.Start("enc1") }, map[string]Status{"enc1": StatusRunned, "enc2": StatusStopped}},
+		{"start all", m.StartAll, map[string]Status{"enc1": StatusRunned, "enc2": StatusRunned}},
+		{"pause one", func() error { return m.Pause("enc2") }, map[string]Status{"enc1": StatusRunned, "enc2": StatusPaused}},
+		{"resume all", m.ResumeAll, map[string]Status{"enc1": StatusRunned, "enc2": StatusRunned}},
+		{"split one", func() error { return m.Split("enc1") }, map[string]Status{"enc1": StatusSplitted, "enc2": StatusRunned}},
+		{"stop all", m.StopAll, map[string]Status{"enc1": StatusStopped, "enc2": StatusStopped}},
+		{"prepare one", func() error { return m.Prepare("enc1", "4K") }, map[string]Status{"enc1": StatusPrepared, "enc2": StatusStopped}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.do(); err != nil {
+				t.Fatalf("command error = %v", err)
+			}
+			got, err := m.StatusAll()
+			if err != nil {
+				t.Fatalf("StatusAll() error = %v", err)
+			}
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Errorf("StatusAll()[%s] = %v, want %v", name, got[name], want)
+				}
+			}
+		})
+	}
+
+	if _, profile, _, _ := s.Encoder("enc1"); profile != "4K" {
+		t.Errorf("Encoder(enc1) profile = %v, want 4K", profile)
+	}
+	if err := m.SetFileNameAll("take 1"); err != nil {
+		t.Fatalf("SetFileNameAll() error = %v", err)
+	}
+	if _, _, filename, _ := s.Encoder("enc2"); filename != "take 1" {
+		t.Errorf("Encoder(enc2) filename = %v, want take 1", filename)
+	}
+}
+
+func TestServerTransitions(t *testing.T) {
+	s := &Server{Step: time.Hour}
+	s.AddEncoder("enc1", "")
+	m := testPair(t, s)
+	if err := m.Start("enc1"); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	got, err := m.Status("enc1")
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if got != StatusRunning {
+		t.Errorf("Status() = %v, want %v", got, StatusRunning)
+	}
+	if err := m.Pause("enc1"); err == nil {
+		t.Errorf("Pause() while Running error = nil, want error")
+	}
+}
+
+func TestServerFail(t *testing.T) {
+	s := &Server{
+		Fail: func(verb string, name string) error {
+			if verb == "Start" {
+				return errors.New("no signal")
+			}
+			return nil
+		},
+	}
+	s.AddEncoder("enc1", "")
+	m := testPair(t, s)
+	if err := m.Start("enc1"); err == nil {
+		t.Errorf("Start() error = nil, want error")
+	}
+	got, err := m.Status("enc1")
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if got != StatusStopped {
+		t.Errorf("Status() = %v, want %v", got, StatusStopped)
+	}
+}
+
+func TestServerNoEncoders(t *testing.T) {
+	m := testPair(t, &Server{})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := m.StartAllCtx(ctx); err != nil {
+		t.Fatalf("StartAll() error = %v", err)
+	}
+	got, err := m.StatusAllCtx(ctx)
+	if err != nil {
+		t.Fatalf("StatusAll() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("StatusAll() = %v, want no encoders", got)
+	}
+	if err := m.StopAllCtx(ctx); err != nil {
+		t.Fatalf("StopAll() after StatusAll() error = %v", err)
+	}
+}
+
+func TestServerEmptyName(t *testing.T) {
+	s := &Server{}
+	s.AddEncoder("enc1", "")
+	s.AddEncoder("enc2", "")
+	m := testPair(t, s)
+	if err := m.StartAll(); err != nil {
+		t.Fatalf("StartAll() error = %v", err)
+	}
+	if err := m.Stop(""); !errors.Is(err, ErrEmptyName) {
+		t.Errorf("Stop(\"\") error = %v, want %v", err, ErrEmptyName)
+	}
+	if err := m.Start(""); !errors.Is(err, ErrEmptyName) {
+		t.Errorf("Start(\"\") error = %v, want %v", err, ErrEmptyName)
+	}
+	for _, name := range []string{"enc1", "enc2"} {
+		if status, _, _, _ := s.Encoder(name); status != StatusRunned {
+			t.Errorf("Encoder(%s) = %v after Stop(\"\"), want %v", name, status, StatusRunned)
+		}
+	}
+}
+
+func TestServerEncoderCommands(t *testing.T) {
+	s := &Server{}
+	s.AddEncoder("enc1", "HD")
+	s.AddEncoder("enc2", "HD")
+	m := testPair(t, s)
+	if err := m.StartAll(); err != nil {
+		t.Fatalf("StartAll() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		do   func() error
+		want map[string]Status
+	}{
+		{"pause all", m.PauseAll, map[string]Status{"enc1": StatusPaused, "enc2": StatusPaused}},
+		{"resume one", func() error { return m.Resume("enc2") }, map[string]Status{"enc1": StatusPaused, "enc2": StatusRunned}},
+		{"resume all", m.ResumeAll, map[string]Status{"enc1": StatusRunned, "enc2": StatusRunned}},
+		{"split all", m.SplitAll, map[string]Status{"enc1": StatusSplitted, "enc2": StatusSplitted}},
+		{"stop all", m.StopAll, map[string]Status{"enc1": StatusStopped, "enc2": StatusStopped}},
+		{"prepare each", func() error {
+			if err := m.Prepare("enc1", "4K"); err != nil {
+				return err
+			}
+			return m.Prepare("enc2", "4K")
+		}, map[string]Status{"enc1": StatusPrepared, "enc2": StatusPrepared}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.do(); err != nil {
+				t.Fatalf("command error = %v", err)
+			}
+			for name, want := range tt.want {
+				if got, _, _, _ := s.Encoder(name); got != want {
+					t.Errorf("Encoder(%s) = %v, want %v", name, got, want)
+				}
+			}
+		})
+	}
+
+	if err := m.SetFileName("enc1", "take 2"); err != nil {
+		t.Fatalf("SetFileName() error = %v", err)
+	}
+	for name, want := range map[string]string{"enc1": "take 2", "enc2": ""} {
+		if _, profile, filename, _ := s.Encoder(name); profile != "4K" || filename != want {
+			t.Errorf("Encoder(%s) = %q, %q, want 4K, %q", name, profile, filename, want)
+		}
+	}
+	if err := m.Prepare("enc1", ""); err != nil {
+		t.Fatalf("Prepare() without profile error = %v", err)
+	}
+	if _, profile, _, _ := s.Encoder("enc1"); profile != "4K" {
+		t.Errorf("Encoder(enc1) profile = %q after Prepare() without profile, want 4K", profile)
+	}
+
+	empty := map[string]func() error{
+		"Pause":       func() error { return m.Pause("") },
+		"Resume":      func() error { return m.Resume("") },
+		"Split":       func() error { return m.Split("") },
+		"Prepare":     func() error { return m.Prepare("", "SD") },
+		"SetFileName": func() error { return m.SetFileName("", "x") },
+		"Status":      func() error { _, err := m.Status(""); return err },
+	}
+	for verb, do := range empty {
+		if err := do(); !errors.Is(err, ErrEmptyName) {
+			t.Errorf("%s(\"\") error = %v, want %v", verb, err, ErrEmptyName)
+		}
+	}
+	for _, name := range []string{"enc1", "enc2"} {
+		if _, profile, filename, _ := s.Encoder(name); profile != "4K" || filename == "x" {
+			t.Errorf("Encoder(%s) = %q, %q after commands without a name", name, profile, filename)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"puzzlekraken.com/broadcastkit/metus"
+)
+
+var metusDevice = device{
+	name:  "metus",
+	usage: "start -all | start <encoder> | stop -all | stop <encoder> | status [encoder]",
+	commands: map[string]func(context.Context, *env, []string) error{
+		"start":  metusStart,
+		"stop":   metusStop,
+		"status": metusStatus,
+	},
+}
+
+func (e *env) metusSocket() (*metus.MetusSocket, error) {
+	remote, err := resolve(e.Address, 32106)
+	if err != nil {
+		return nil, err
+	}
+	// The connection is opened by the first command.
+	return &metus.MetusSocket{Remote: remote, Logger: e.log}, nil
+}
+
+// metusEncoder parses the encoder of a command, empty for all encoders. All
+// encoders are addressed with -all, or without arguments if idle is set,
+// so a missing or empty name in a script never stops every recorder.
+func metusEncoder(cmd string, args []string, idle bool) (string, error) {
+	fs := flag.NewFlagSet("metus "+cmd, flag.ContinueOnError)
+	all := fs.Bool("all", false, "address all encoders")
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+	switch {
+	case *all && fs.NArg() > 0:
+		return "", errors.New("-all cannot be combined with an encoder")
+	case *all || (idle && fs.NArg() == 0):
+		return "", nil
+	case fs.NArg() != 1:
+		return "", fmt.Errorf("usage: metus %s -all | <encoder>", cmd)
+	case fs.Arg(0) == "":
+		return "", errors.New("empty encoder name")
+	}
+	return fs.Arg(0), nil
+}
+
+func metusStart(ctx context.Context, e *env, args []string) error {
+	name, err := metusEncoder("start", args, false)
+	if err != nil {
+		return err
+	}
+	m, err := e.metusSocket()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	if name == "" {
+		err = m.StartAllCtx(ctx)
+	} else {
+		err = m.StartCtx(ctx, name)
+	}
+	if err != nil {
+		return err
+	}
+	return printMetusStatus(ctx, e, m, name)
+}
+
+func metusStop(ctx context.Context, e *env, args []string) error {
+	name, err := metusEncoder("stop", args, false)
+	if err != nil {
+		return err
+	}
+	m, err := e.metusSocket()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	if name == "" {
+		err = m.StopAllCtx(ctx)
+	} else {
+		err = m.StopCtx(ctx, name)
+	}
+	if err != nil {
+		return err
+	}
+	return printMetusStatus(ctx, e, m, name)
+}
+
+func metusStatus(ctx context.Context, e *env, args []string) error {
+	name, err := metusEncoder("status", args, true)
+	if err != nil {
+		return err
+	}
+	m, err := e.metusSocket()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	return printMetusStatus(ctx, e, m, name)
+}
+
+// printMetusStatus writes the status of one or all encoders as name=status
+// lines, or as one JSON object.
+func printMetusStatus(ctx context.Context, e *env, m *metus.MetusSocket, name string) error {
+	var all map[string]metus.Status
+	if name == "" {
+		var err error
+		all, err = m.StatusAllCtx(ctx)
+		if err != nil {
+			return err
+		}
+	} else {
+		s, err := m.StatusCtx(ctx, name)
+		if err != nil {
+			return err
+		}
+		all = map[string]metus.Status{name: s}
+	}
+	obj := make(map[string]string, len(all))
+	var lines []string
+	for _, n := range slices.Sorted(maps.Keys(all)) {
+		obj[n] = all[n].String()
+		lines = append(lines, fmt.Sprintf("%s=%s", n, all[n]))
+	}
+	return e.out.print(obj, strings.Join(lines, "\n"))
+}
@@ -4256,12 +4256,12 @@ func (a AWBGainDisabled) packResponse() string {
 
 type AWBGainQuery struct{}
 
-func init() { registerRequest(func() AWRequest { return AWRGainQuery{} }) }
+func init() { registerRequest(func() AWRequest { return AWBGainQuery{} }) }
 func (a AWBGainQuery) Acceptable() bool {
 	return true
 }
 func (a AWBGainQuery) Response() AWResponse {
-	return AWRGainControl{}
+	return AWBGainControl{}
 }
 func (a AWBGainQuery) requestSignature() string {
 	return "QGB"
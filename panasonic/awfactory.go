@@ -1,5 +1,7 @@
 package panasonic
 
+import "sync"
+
 // AWResponse is the interface implemented by all responses sent from a camera.
 //
 // For processing information, the application has to type-assert the response
@@ -138,9 +140,30 @@ var awRequestTable = []awRequestFactory{}
 // awResponseTable is the factory lookup table for AWResponses
 var awResponseTable = []awResponseFactory{}
 
+// awRequestTrie and awResponseTrie index the signatures of the lookup tables
+// by their table position. They are built on first use, after all init
+// functions have registered their types.
+var (
+	awTrieOnce     sync.Once
+	awRequestTrie  awTrie
+	awResponseTrie awTrie
+)
+
+func buildAWTries() {
+	sigs := make([]string, len(awRequestTable))
+	for i, e := range awRequestTable {
+		sigs[i] = e.sig
+	}
+	awRequestTrie = newAWTrie(sigs, awLeafSize)
+	sigs = make([]string, len(awResponseTable))
+	for i, e := range awResponseTable {
+		sigs[i] = e.sig
+	}
+	awResponseTrie = newAWTrie(sigs, awLeafSize)
+}
+
 // registerRequest registers a new request type with the factory table
 func registerRequest(new func() AWRequest) {
-	// TODO(zsh): These functions may be optimized away by code-generation instead.
 	n := new()
 	p := n.requestSignature()
 	awRequestTable = append(awRequestTable, awRequestFactory{p, new})
@@ -148,7 +171,6 @@ func registerRequest(new func() AWRequest) {
 
 // registerResponse registers a new response type with the factory table
 func registerResponse(new func() AWResponse) {
-	// TODO(zsh): These functions may be optimized away by code-generation instead.
 	n := new()
 	p := n.responseSignature()
 	awResponseTable = append(awResponseTable, awResponseFactory{p, new})
@@ -157,14 +179,13 @@ func registerResponse(new func() AWResponse) {
 // newRequest creates a new request via the factory
 func newRequest(cmd string) AWRequest {
 	// This function is within a latency-critical path of incoming requests.
-	// Following is a tight-loop with everything inlined, but this may need
-	// optimization if latency becomes an issue.
-	for _, e := range awRequestTable {
-		if match(e.sig, cmd) {
-			req := e.new()
-			req = req.unpackRequest(cmd)
-			return req
-		}
+	// The trie lookup only visits the signatures sharing a prefix with cmd
+	// instead of matching the whole table.
+	awTrieOnce.Do(buildAWTries)
+	if i := awRequestTrie.find(cmd, -1); i >= 0 {
+		req := awRequestTable[i].new()
+		req = req.unpackRequest(cmd)
+		return req
 	}
 	return AWUnknownRequest{
 		text: cmd,
@@ -175,20 +196,37 @@ func newRequest(cmd string) AWRequest {
 func newResponse(cmd string, quirks quirkMode) AWResponse {
 	// This function is less critical than newRequest(), because the object
 	// returned by AWRequest.Response() is used in happy-path response creation.
-	for _, e := range awResponseTable {
-		if match(e.sig, cmd) {
-			res := e.new()
-			if qRes, ok := res.(awQuirkedPacking); quirks != quirkNone && ok {
-				res = qRes.packingQuirk(quirks)
-				if !match(res.responseSignature(), cmd) {
-					continue
-				}
+	awTrieOnce.Do(buildAWTries)
+	for i := awResponseTrie.find(cmd, -1); i >= 0; i = awResponseTrie.find(cmd, i) {
+		res := awResponseTable[i].new()
+		if qRes, ok := res.(awQuirkedPacking); quirks != quirkNone && ok {
+			res = qRes.packingQuirk(quirks)
+			if !match(res.responseSignature(), cmd) {
+				continue
 			}
-			res = res.unpackResponse(cmd)
-			return res
 		}
+		res = res.unpackResponse(cmd)
+		return res
 	}
 	return AWUnknownResponse{
 		text: cmd,
 	}
 }
+
+// ParseAWRequest creates an AWRequest from its Panasonic string representation.
+//
+// Commands unknown to this package are returned as AWUnknownRequest, which are
+// passed to the camera verbatim.
+func ParseAWRequest(cmd string) AWRequest {
+	return newRequest(cmd)
+}
+
+// FormatAWRequest returns the Panasonic string representation of req.
+func FormatAWRequest(req AWRequest) string {
+	return req.packRequest()
+}
+
+// FormatAWResponse returns the Panasonic string representation of res.
+func FormatAWResponse(res AWResponse) string {
+	return res.packResponse()
+}
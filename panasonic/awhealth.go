@@ -0,0 +1,424 @@
+package panasonic
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"puzzlekraken.com/broadcastkit/internal/wirelog"
+)
+
+const (
+	healthInterval = 30 * time.Second
+	healthHistory  = 100
+	healthMissed   = 3
+)
+
+// firmwareComponent is the software component compared across the fleet.
+const firmwareComponent = 1
+
+// AlertKind is the condition reported by an Alert.
+type AlertKind int
+
+const (
+	AlertUnresponsive AlertKind = iota + 1 // the camera stopped answering
+	AlertHealthCode                        // the camera reports a HealthCode problem
+	AlertFan                               // the camera reports a fan failure
+	AlertErrorInfo                         // the camera reports another failure
+	AlertFirmware                          // the firmware differs from the same models
+)
+
+func (k AlertKind) String() string {
+	switch k {
+	case AlertUnresponsive:
+		return "unresponsive"
+	case AlertHealthCode:
+		return "health code"
+	case AlertFan:
+		return "fan failure"
+	case AlertErrorInfo:
+		return "error information"
+	case AlertFirmware:
+		return "firmware mismatch"
+	default:
+		return fmt.Sprintf("AlertKind(%d)", int(k))
+	}
+}
+
+// Alert is a change of a monitored condition of a camera.
+type Alert struct {
+	Camera string
+	Kind   AlertKind
+	// Resolved is set when the condition is over.
+	Resolved bool
+	// Sample is the health of the camera which raised or resolved the alert.
+	Sample HealthSample
+	// Expected is the firmware of the other cameras of the same model, for
+	// AlertFirmware.
+	Expected string
+}
+
+// HealthSample is the health of a camera at a point in time.
+type HealthSample struct {
+	Time time.Time
+	// Err is set if the camera did not answer. The other fields are then
+	// copied from the last answer.
+	Err  error
+	Code HealthCode
+	Info ErrorInfoBits
+	// Model and Firmware are empty until the camera reports them.
+	Model    string
+	Firmware string
+}
+
+// HealthMonitor polls the health of a fleet of cameras and raises alerts.
+//
+// Every poll queries the HealthCode, the model and the software version of the
+// camera, and reads the error information from the camera data page. With
+// Notify set, rER, OER and qSV notifications update the health between polls.
+//
+// An alert is raised once, when its condition starts, and again with Resolved
+// set when it ends. Cameras are compared to the firmware most common among
+// the cameras of the same model, ties resolved to the higher version.
+//
+// Set the fields before calling Run. HealthMonitor must not be copied after
+// first use.
+type HealthMonitor struct {
+	// Cameras are the cameras to monitor by name.
+	Cameras map[string]*CameraClient
+	// Interval is the time between polls, 30 seconds by default.
+	Interval time.Duration
+	// History is the number of samples kept per camera, 100 by default.
+	History int
+	// Missed is the number of polls in a row a camera may not answer before
+	// AlertUnresponsive, 3 by default.
+	Missed int
+	// Notify subscribes to the notifications of the cameras.
+	Notify bool
+	// OnAlert, if set, is called for every alert, one at a time. It must not
+	// block.
+	OnAlert func(Alert)
+
+	lock      sync.Mutex
+	health    map[string]*cameraHealth
+	alertLock sync.Mutex // serializes OnAlert
+}
+
+// cameraHealth is the monitoring state of a camera.
+type cameraHealth struct {
+	history []HealthSample
+	missed  int
+	active  map[AlertKind]bool
+}
+
+// Status returns the last sample of every camera polled so far.
+func (m *HealthMonitor) Status() map[string]HealthSample {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	s := make(map[string]HealthSample, len(m.health))
+	for name, h := range m.health {
+		if len(h.history) > 0 {
+			s[name] = h.history[len(h.history)-1]
+		}
+	}
+	return s
+}
+
+// HistoryOf returns the samples of a camera, oldest first.
+func (m *HealthMonitor) HistoryOf(name string) []HealthSample {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if h, ok := m.health[name]; ok {
+		return slices.Clone(h.history)
+	}
+	return nil
+}
+
+// Run polls the cameras until ctx is done. It returns ctx.Err(), or the error
+// of subscribing to notifications.
+func (m *HealthMonitor) Run(ctx context.Context) error {
+	interval := m.Interval
+	if interval == 0 {
+		interval = healthInterval
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var listeners map[string]*NotifyListener
+	if m.Notify {
+		listeners = make(map[string]*NotifyListener, len(m.Cameras))
+		defer func() {
+			for _, l := range listeners {
+				l.Close()
+			}
+		}()
+		for name, cam := range m.Cameras {
+			l, err := cam.Listener()
+			if err != nil {
+				return err
+			}
+			listeners[name] = l
+			go m.listen(ctx, name, l)
+		}
+	}
+
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		for _, name := range m.poll() {
+			// A camera which was restarted forgot the subscription.
+			if l := listeners[name]; l != nil {
+				if err := l.Start(); err != nil {
+					wirelog.Or(m.Cameras[name].Logger).Warn("notification subscription", "camera", name, "err", err)
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+		}
+	}
+}
+
+// listen feeds the notifications of a camera to Update until l is closed.
+func (m *HealthMonitor) listen(ctx context.Context, name string, l *NotifyListener) {
+	var backoff acceptBackoff
+	for {
+		res, err := l.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			wirelog.Or(m.Cameras[name].Logger).Warn("notification accept", "camera", name, "err", err)
+			backoff.wait(ctx)
+			continue
+		}
+		backoff.reset()
+		m.Update(name, res)
+	}
+}
+
+// poll polls every camera concurrently. It returns the cameras which answered
+// again after AlertUnresponsive.
+func (m *HealthMonitor) poll() []string {
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	var recovered []string
+	for name, cam := range m.Cameras {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s := pollHealth(cam)
+			if m.record(name, func(HealthSample) HealthSample { return s }) {
+				lock.Lock()
+				recovered = append(recovered, name)
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	m.checkFirmware()
+	return recovered
+}
+
+// pollHealth queries the health of a camera. Queries the camera does not
+// support leave their fields empty.
+func pollHealth(cam *CameraClient) HealthSample {
+	s := HealthSample{Time: time.Now()}
+	res, err := cam.AWCommand(AWHealthQuery{})
+	var sysErr *SystemError
+	if errors.As(err, &sysErr) {
+		s.Err = err
+		return s
+	}
+	if r, ok := res.(AWHealthStatus); ok {
+		s.Code = r.Code
+	}
+	if res, err := cam.AWCommand(AWModelNameQuery{}); err == nil {
+		if r, ok := res.(AWModelName); ok {
+			s.Model = r.ModelName
+		}
+	}
+	if res, err := cam.AWCommand(AWSoftwareVersionQuery{Component: firmwareComponent}); err == nil {
+		if r, ok := res.(AWSoftwareVersion); ok {
+			s.Firmware = firmware(r)
+		}
+	}
+	if batch, err := cam.AWBatch(); err == nil {
+		for _, res := range batch {
+			if r, ok := res.(AWERrorInformation); ok {
+				s.Info = r.Info
+			}
+		}
+	}
+	return s
+}
+
+// firmware formats a software version like 01.23A05.
+func firmware(v AWSoftwareVersion) string {
+	return fmt.Sprintf("%02d.%02d%c%02d", v.Major, v.Minor, v.Flag, v.Revision)
+}
+
+// Update records a notification of the named camera. Responses other than
+// AWHealthStatus, AWERrorInformation and AWSoftwareVersion are ignored, so
+// every notification may be passed.
+func (m *HealthMonitor) Update(name string, res AWResponse) {
+	var apply func(*HealthSample)
+	switch r := res.(type) {
+	case AWHealthStatus:
+		apply = func(s *HealthSample) { s.Code = r.Code }
+	case AWERrorInformation:
+		apply = func(s *HealthSample) { s.Info = r.Info }
+	case AWSoftwareVersion:
+		if r.Component != firmwareComponent {
+			return
+		}
+		apply = func(s *HealthSample) { s.Firmware = firmware(r) }
+		defer m.checkFirmware()
+	default:
+		return
+	}
+	m.record(name, func(last HealthSample) HealthSample {
+		s := last
+		s.Time, s.Err = time.Now(), nil
+		apply(&s)
+		return s
+	})
+}
+
+// state returns the monitoring state of a camera. The lock must be held.
+func (m *HealthMonitor) state(name string) *cameraHealth {
+	if m.health == nil {
+		m.health = make(map[string]*cameraHealth)
+	}
+	h, ok := m.health[name]
+	if !ok {
+		h = &cameraHealth{active: make(map[AlertKind]bool)}
+		m.health[name] = h
+	}
+	return h
+}
+
+// last returns the last sample, or the zero sample.
+func (h *cameraHealth) last() HealthSample {
+	if len(h.history) == 0 {
+		return HealthSample{}
+	}
+	return h.history[len(h.history)-1]
+}
+
+// record appends the sample returned by sample to the history of a camera and
+// raises the alerts of the conditions changed. sample is called with the last
+// sample under the lock, so concurrent updates build on each other. record
+// reports whether the camera recovered from AlertUnresponsive.
+func (m *HealthMonitor) record(name string, sample func(last HealthSample) HealthSample) bool {
+	missedLimit := m.Missed
+	if missedLimit == 0 {
+		missedLimit = healthMissed
+	}
+	historyLimit := m.History
+	if historyLimit == 0 {
+		historyLimit = healthHistory
+	}
+
+	m.lock.Lock()
+	h := m.state(name)
+	last := h.last()
+	s := sample(last)
+	var alerts []Alert
+	set := func(kind AlertKind, active bool) {
+		if h.active[kind] != active {
+			h.active[kind] = active
+			alerts = append(alerts, Alert{Camera: name, Kind: kind, Resolved: !active, Sample: s})
+		}
+	}
+	recovered := false
+	if s.Err != nil {
+		err := s.Err
+		s = last
+		s.Time, s.Err = time.Now(), err
+		h.missed++
+		if h.missed >= missedLimit {
+			set(AlertUnresponsive, true)
+		}
+	} else {
+		if s.Model == "" {
+			s.Model = last.Model
+		}
+		if s.Firmware == "" {
+			s.Firmware = last.Firmware
+		}
+		h.missed = 0
+		recovered = h.active[AlertUnresponsive]
+		set(AlertUnresponsive, false)
+		set(AlertHealthCode, s.Code.Problem())
+		set(AlertFan, s.Info&ErrorInfoFan != 0)
+		set(AlertErrorInfo, s.Info&ErrorInfoOther != 0)
+	}
+	h.history = append(h.history, s)
+	if over := len(h.history) - historyLimit; over > 0 {
+		h.history = slices.Delete(h.history, 0, over)
+	}
+	m.lock.Unlock()
+
+	m.alert(alerts)
+	return recovered
+}
+
+// checkFirmware compares the firmware of cameras of the same model.
+func (m *HealthMonitor) checkFirmware() {
+	m.lock.Lock()
+	count := make(map[string]map[string]int) // model, firmware
+	for _, h := range m.health {
+		s := h.last()
+		if s.Model == "" || s.Firmware == "" {
+			continue
+		}
+		if count[s.Model] == nil {
+			count[s.Model] = make(map[string]int)
+		}
+		count[s.Model][s.Firmware]++
+	}
+	expected := make(map[string]string, len(count))
+	for model, versions := range count {
+		var best string
+		for v, n := range versions {
+			if n > versions[best] || n == versions[best] && v > best {
+				best = v
+			}
+		}
+		expected[model] = best
+	}
+	var alerts []Alert
+	for name, h := range m.health {
+		s := h.last()
+		want, ok := expected[s.Model]
+		mismatch := ok && s.Firmware != "" && s.Firmware != want
+		if h.active[AlertFirmware] != mismatch {
+			h.active[AlertFirmware] = mismatch
+			alerts = append(alerts, Alert{Camera: name, Kind: AlertFirmware, Resolved: !mismatch, Sample: s, Expected: want})
+		}
+	}
+	m.lock.Unlock()
+
+	// Alerts of different cameras are sorted for a stable order.
+	slices.SortFunc(alerts, func(a, b Alert) int { return cmp.Compare(a.Camera, b.Camera) })
+	m.alert(alerts)
+}
+
+func (m *HealthMonitor) alert(alerts []Alert) {
+	if m.OnAlert == nil {
+		return
+	}
+	m.alertLock.Lock()
+	defer m.alertLock.Unlock()
+	for _, a := range alerts {
+		m.OnAlert(a)
+	}
+}
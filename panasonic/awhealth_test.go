@@ -0,0 +1,202 @@
+package panasonic
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeHealth is a camera reporting configurable health.
+type fakeHealth struct {
+	lock  sync.Mutex
+	code  HealthCode
+	info  ErrorInfoBits
+	model string
+	minor int
+}
+
+func (f *fakeHealth) AWCommand(req AWRequest) (AWResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	switch req.(type) {
+	case AWHealthQuery:
+		return AWHealthStatus{Code: f.code}, nil
+	case AWModelNameQuery:
+		return AWModelName{ModelName: f.model}, nil
+	case AWSoftwareVersionQuery:
+		return AWSoftwareVersion{Component: 1, Major: 1, Minor: f.minor, Flag: 'A', Revision: 2}, nil
+	}
+	return nil, NewAWError(AWErrUnsupported, req)
+}
+
+func (f *fakeHealth) AWBatch() ([]AWResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return []AWResponse{AWPower{Power: PowerOn}, AWERrorInformation{Info: f.info}}, nil
+}
+
+func (f *fakeHealth) set(fn func(f *fakeHealth)) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	fn(f)
+}
+
+func TestHealthMonitor(t *testing.T) {
+	fakes := map[string]*fakeHealth{
+		"cam1": {model: "AW-UE150", minor: 10},
+		"cam2": {model: "AW-UE150", minor: 10},
+		"cam3": {model: "AW-UE150", minor: 8},
+		"cam4": {model: "AW-HE40", minor: 3},
+	}
+	servers := make(map[string]*httptest.Server)
+	cameras := make(map[string]*CameraClient)
+	for name, f := range fakes {
+		srv := httptest.NewServer(&CameraServer{AWHandler: f})
+		t.Cleanup(srv.Close)
+		servers[name] = srv
+		cameras[name] = &CameraClient{Remote: netip.MustParseAddrPort(srv.Listener.Addr().String())}
+	}
+	var alerts []Alert
+	m := &HealthMonitor{
+		Cameras: cameras,
+		Missed:  2,
+		History: 3,
+		OnAlert: func(a Alert) { alerts = append(alerts, a) },
+	}
+	type alert struct {
+		camera   string
+		kind     AlertKind
+		resolved bool
+	}
+	expect := func(step string, want ...alert) {
+		t.Helper()
+		var got []alert
+		for _, a := range alerts {
+			got = append(got, alert{a.Camera, a.Kind, a.Resolved})
+		}
+		if len(got) != len(want) {
+			t.Errorf("%s: alerts = %v, want %v", step, got, want)
+		} else {
+			for i := range got {
+				if got[i] != want[i] {
+					t.Errorf("%s: alerts = %v, want %v", step, got, want)
+					break
+				}
+			}
+		}
+		alerts = nil
+	}
+
+	m.poll()
+	expect("first poll", alert{"cam3", AlertFirmware, false})
+	if s := m.Status()["cam1"]; s.Model != "AW-UE150" || s.Firmware != "01.10A02" || s.Err != nil {
+		t.Errorf("Status()[cam1] = %+v", s)
+	}
+
+	fakes["cam1"].set(func(f *fakeHealth) { f.info = ErrorInfoFan })
+	fakes["cam2"].set(func(f *fakeHealth) { f.code = 0x10 })
+	fakes["cam3"].set(func(f *fakeHealth) { f.minor = 10 })
+	m.poll()
+	m.poll()
+	var got []alert
+	for _, a := range alerts {
+		got = append(got, alert{a.Camera, a.Kind, a.Resolved})
+	}
+	want := map[alert]bool{
+		{"cam1", AlertFan, false}:        true,
+		{"cam2", AlertHealthCode, false}: true,
+		{"cam3", AlertFirmware, true}:    true,
+	}
+	if len(got) != len(want) {
+		t.Errorf("second poll: alerts = %v, want %v", got, want)
+	}
+	for _, a := range got {
+		if !want[a] {
+			t.Errorf("second poll: unexpected alert %v", a)
+		}
+	}
+	alerts = nil
+
+	servers["cam4"].Close()
+	m.poll()
+	expect("first missed poll")
+	m.poll()
+	expect("second missed poll", alert{"cam4", AlertUnresponsive, false})
+	if s := m.Status()["cam4"]; s.Err == nil || s.Model != "AW-HE40" {
+		t.Errorf("Status()[cam4] = %+v, want error and last known model", s)
+	}
+	if h := m.HistoryOf("cam4"); len(h) != 3 {
+		t.Errorf("len(HistoryOf(cam4)) = %d, want 3", len(h))
+	}
+
+	m.Update("cam2", AWHealthStatus{Code: HealthOk})
+	m.Update("cam2", AWTitle{Title: "ignored"})
+	expect("notification", alert{"cam2", AlertHealthCode, true})
+	m.Update("cam1", AWSoftwareVersion{Component: 1, Major: 2, Flag: 'A'})
+	expect("firmware notification", alert{"cam1", AlertFirmware, false})
+}
+
+func TestHealthMonitor_ConcurrentUpdate(t *testing.T) {
+	for range 50 {
+		m := &HealthMonitor{}
+		var wg sync.WaitGroup
+		for _, res := range []AWResponse{
+			AWHealthStatus{Code: HealthOk},
+			AWERrorInformation{Info: ErrorInfoFan},
+			AWSoftwareVersion{Component: firmwareComponent, Major: 1, Flag: 'A'},
+		} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				m.Update("cam1", res)
+			}()
+		}
+		wg.Wait()
+		if s := m.Status()["cam1"]; s.Code != HealthOk || s.Info != ErrorInfoFan || s.Firmware == "" {
+			t.Fatalf("Status()[cam1] = %+v, want every notification applied", s)
+		}
+	}
+}
+
+func TestHealthMonitor_Run(t *testing.T) {
+	camera := &CameraServer{AWHandler: &fakeHealth{model: "AW-UE150"}}
+	srv := httptest.NewServer(camera)
+	defer srv.Close()
+	alerts := make(chan Alert, 10)
+	m := &HealthMonitor{
+		Cameras:  map[string]*CameraClient{"cam": {Remote: netip.MustParseAddrPort(srv.Listener.Addr().String())}},
+		Interval: 10 * time.Millisecond,
+		Notify:   true,
+		OnAlert:  func(a Alert) { alerts <- a },
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	done := make(chan error)
+	go func() { done <- m.Run(ctx) }()
+
+	for camera.Notify.Len() == 0 {
+		if ctx.Err() != nil {
+			t.Fatal("monitor did not subscribe to notifications")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	camera.Notify.SendAll(AWHealthStatus{Code: 0x05})
+	select {
+	case a := <-alerts:
+		if a.Kind != AlertHealthCode || a.Resolved || a.Sample.Code != 0x05 {
+			t.Errorf("alert = %+v, want health code 0x05", a)
+		}
+	case <-ctx.Done():
+		t.Fatal("no alert for the notification")
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run() = %v, want %v", err, context.Canceled)
+	}
+	if n := len(m.HistoryOf("cam")); n < 2 {
+		t.Errorf("len(HistoryOf(cam)) = %d, want at least 2", n)
+	}
+}
@@ -0,0 +1,280 @@
+package panasonic
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+)
+
+// AWMiddleware wraps an AWHandler into another, like the
+// func(http.Handler) http.Handler middleware of net/http.
+//
+// Middleware in this package implement both AWHandler and AWHandlerCtx, and
+// pass the context to the wrapped handler if it supports it.
+type AWMiddleware func(AWHandler) AWHandler
+
+// Chain wraps h into the middleware. The first middleware is the outermost,
+// it sees the requests first and the responses last.
+//
+//	server := &CameraServer{AWHandler: Chain(camera, ReadOnly(), RateLimit(130*time.Millisecond))}
+func Chain(h AWHandler, middleware ...AWMiddleware) AWHandler {
+	for _, m := range slices.Backward(middleware) {
+		h = m(h)
+	}
+	return h
+}
+
+// AWHandlerFuncs is an AWHandler calling its functions. It is the building
+// block of middleware, a nil function passes the call to Next.
+type AWHandlerFuncs struct {
+	Next    AWHandler
+	Command func(context.Context, AWRequest) (AWResponse, error)
+	Batch   func(context.Context) ([]AWResponse, error)
+}
+
+func (f *AWHandlerFuncs) AWCommand(req AWRequest) (AWResponse, error) {
+	return f.AWCommandCtx(context.Background(), req)
+}
+
+func (f *AWHandlerFuncs) AWBatch() ([]AWResponse, error) {
+	return f.AWBatchCtx(context.Background())
+}
+
+func (f *AWHandlerFuncs) AWCommandCtx(ctx context.Context, req AWRequest) (AWResponse, error) {
+	if f.Command == nil {
+		return awCommand(ctx, f.Next, req)
+	}
+	return f.Command(ctx, req)
+}
+
+func (f *AWHandlerFuncs) AWBatchCtx(ctx context.Context) ([]AWResponse, error) {
+	if f.Batch == nil {
+		return awBatch(ctx, f.Next)
+	}
+	return f.Batch(ctx)
+}
+
+var _ AWHandler = (*AWHandlerFuncs)(nil)
+var _ AWHandlerCtx = (*AWHandlerFuncs)(nil)
+
+// Validate is the middleware form of ValidateHandler.
+func Validate(profile *ModelProfile) AWMiddleware {
+	return func(h AWHandler) AWHandler {
+		return ValidateHandler(h, profile)
+	}
+}
+
+// sameType reports whether req has the type of any of reqs.
+func sameType(req AWRequest, reqs []AWRequest) bool {
+	t := reflect.TypeOf(req)
+	for _, r := range reqs {
+		if reflect.TypeOf(r) == t {
+			return true
+		}
+	}
+	return false
+}
+
+// filter refuses the requests for which allow is false with AWErrUnsupported.
+func filter(allow func(AWRequest) bool) AWMiddleware {
+	return func(h AWHandler) AWHandler {
+		return &AWHandlerFuncs{
+			Next: h,
+			Command: func(ctx context.Context, req AWRequest) (AWResponse, error) {
+				if !allow(req) {
+					return nil, NewAWError(AWErrUnsupported, req)
+				}
+				return awCommand(ctx, h, req)
+			},
+		}
+	}
+}
+
+// Allow refuses the requests of other types than reqs with AWErrUnsupported.
+// The types are given as zero values, like AWPanTilt{}.
+func Allow(reqs ...AWRequest) AWMiddleware {
+	return filter(func(req AWRequest) bool { return sameType(req, reqs) })
+}
+
+// Deny refuses the requests of the types of reqs with AWErrUnsupported. The
+// types are given as zero values, like AWPower{}.
+func Deny(reqs ...AWRequest) AWMiddleware {
+	return filter(func(req AWRequest) bool { return !sameType(req, reqs) })
+}
+
+// readRequests are the requests only reading the state of the camera, given
+// as zero values. Besides the queries, the error information is a read too.
+var readRequests = []AWRequest{
+	AWPowerQuery{},
+	AWInstallQuery{},
+	AWPanTiltQuery{},
+	AWZoomQuery{},
+	AWZoomQueryAltenate{},
+	AWFocusQuery{},
+	AWFocusQueryAlternate{},
+	AWAutoFocusQuery{},
+	AWIrisQuery{},
+	AWAutoIrisQuery{},
+	AWCombinedIrisQuery{},
+	AWPresetQuery{},
+	AWPresetSpeedQuery{},
+	AWPresetFreezeQuery{},
+	AWPresetEntriesQuery{},
+	AWTallyEnableQuery{},
+	AWTallyQuery{},
+	AWWirelessRemoteQuery{},
+	AWWirelessRemoteIDQuery{},
+	AWSpeedWithZoomQuery{},
+	AWHealthQuery{},
+	AWOptionSwitchQuery{},
+	AWLensInformationQuery{},
+	AWLensInformationNotifyQuery{},
+	AWSoftwareVersionQuery{},
+	AWAutoFocusQueryAlternate{},
+	AWAutoIrisQueryAlternate{},
+	AWIrisQueryAlternate{},
+	AWIrisQueryAlternate2{},
+	AWNDFilterQuery{},
+	AWContrastLevelQuery{},
+	AWLensInformationAlternateQuery{},
+	AWModelNameQuery{},
+	AWFormatQuery{},
+	AWSDIFormatQuery{},
+	AWGainQuery{},
+	AWPedestalQuery{},
+	AWWhiteBalanceModeQuery{},
+	AWShutterModeQuery{},
+	AWDetailQuery{},
+	AWSceneQuery{},
+	AWColorBarQuery{},
+	AWPresetModeQuery{},
+	AWOSDQuery{},
+	AWTotalDetailQuery{},
+	AWRGainQuery{},
+	AWBGainQuery{},
+	AWColorTempQuery{},
+	AWImageStabilizationQuery{},
+	AWDigitalZoomQuery{},
+	AWiZoomQuery{},
+	AWDigitalExtenderQuery{},
+	AWPinPDisplayPosQuery{},
+	AWERrorInformation{},
+}
+
+// isQuery reports whether req only reads the state of the camera.
+func isQuery(req AWRequest) bool {
+	return sameType(req, readRequests)
+}
+
+// ReadOnly refuses the requests changing the state of the camera with
+// AWErrUnsupported, only queries and the batch pass. Unknown requests are
+// refused too, as they may change anything.
+func ReadOnly() AWMiddleware {
+	return filter(isQuery)
+}
+
+// Rewrite passes the requests to the handler as returned by f.
+func Rewrite(f func(AWRequest) AWRequest) AWMiddleware {
+	return func(h AWHandler) AWHandler {
+		return &AWHandlerFuncs{
+			Next: h,
+			Command: func(ctx context.Context, req AWRequest) (AWResponse, error) {
+				return awCommand(ctx, h, f(req))
+			},
+		}
+	}
+}
+
+// RemapRemoteID translates the wireless remote IDs of the camera, so a panel
+// sees the camera under another ID. The IDs set by the panel are mapped
+// through m, and the IDs reported by the camera through the inverse of m. IDs
+// missing from m pass unchanged.
+func RemapRemoteID(m map[WirelessRemoteID]WirelessRemoteID) AWMiddleware {
+	inverse := make(map[WirelessRemoteID]WirelessRemoteID, len(m))
+	for from, to := range m {
+		inverse[to] = from
+	}
+	remap := func(m map[WirelessRemoteID]WirelessRemoteID, id WirelessRemoteID) WirelessRemoteID {
+		if to, ok := m[id]; ok {
+			return to
+		}
+		return id
+	}
+	response := func(res AWResponse) AWResponse {
+		if r, ok := res.(AWWirelessRemoteID); ok {
+			r.RemoteID = remap(inverse, r.RemoteID)
+			return r
+		}
+		return res
+	}
+	return func(h AWHandler) AWHandler {
+		return &AWHandlerFuncs{
+			Next: h,
+			Command: func(ctx context.Context, req AWRequest) (AWResponse, error) {
+				if r, ok := req.(AWWirelessRemoteID); ok {
+					r.RemoteID = remap(m, r.RemoteID)
+					req = r
+				}
+				res, err := awCommand(ctx, h, req)
+				return response(res), err
+			},
+			Batch: func(ctx context.Context) ([]AWResponse, error) {
+				res, err := awBatch(ctx, h)
+				for i := range res {
+					res[i] = response(res[i])
+				}
+				return res, err
+			},
+		}
+	}
+}
+
+// RateLimit delays the requests to each wrapped handler to at most one per
+// interval, as cameras drop commands sent in quick succession. Waiting
+// requests give up when their context is done. The batch is not limited.
+func RateLimit(interval time.Duration) AWMiddleware {
+	return func(h AWHandler) AWHandler {
+		var lock sync.Mutex
+		var next time.Time
+		return &AWHandlerFuncs{
+			Next: h,
+			Command: func(ctx context.Context, req AWRequest) (AWResponse, error) {
+				lock.Lock()
+				now := time.Now()
+				slot := next
+				if slot.Before(now) {
+					slot = now
+				}
+				next = slot.Add(interval)
+				lock.Unlock()
+				if wait := slot.Sub(now); wait > 0 {
+					t := time.NewTimer(wait)
+					select {
+					case <-t.C:
+					case <-ctx.Done():
+						t.Stop()
+						return nil, ctx.Err()
+					}
+				}
+				return awCommand(ctx, h, req)
+			},
+		}
+	}
+}
+
+// Record calls f with every request passing and its outcome. Calls of f may
+// be concurrent. The batch is not recorded.
+func Record(f func(ctx context.Context, req AWRequest, res AWResponse, err error)) AWMiddleware {
+	return func(h AWHandler) AWHandler {
+		return &AWHandlerFuncs{
+			Next: h,
+			Command: func(ctx context.Context, req AWRequest) (AWResponse, error) {
+				res, err := awCommand(ctx, h, req)
+				f(ctx, req, res, err)
+				return res, err
+			},
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package panasonic
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"net/netip"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// remoteHandler stores the wireless remote ID and passes other requests to
+// countingHandler.
+type remoteHandler struct {
+	ctxHandler
+	id WirelessRemoteID
+}
+
+func (h *remoteHandler) AWCommandCtx(ctx context.Context, req AWRequest) (AWResponse, error) {
+	h.lock.Lock()
+	switch r := req.(type) {
+	case AWWirelessRemoteID:
+		h.id = r.RemoteID
+	case AWWirelessRemoteIDQuery:
+		defer h.lock.Unlock()
+		return AWWirelessRemoteID{RemoteID: h.id}, nil
+	}
+	h.lock.Unlock()
+	return h.ctxHandler.AWCommandCtx(ctx, req)
+}
+
+func (h *remoteHandler) AWBatch() ([]AWResponse, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return []AWResponse{AWWirelessRemoteID{RemoteID: h.id}}, nil
+}
+
+func (h *remoteHandler) AWBatchCtx(ctx context.Context) ([]AWResponse, error) {
+	return h.AWBatch()
+}
+
+func TestChain_Order(t *testing.T) {
+	var order []string
+	mark := func(name string) AWMiddleware {
+		return func(h AWHandler) AWHandler {
+			return &AWHandlerFuncs{
+				Next: h,
+				Command: func(ctx context.Context, req AWRequest) (AWResponse, error) {
+					order = append(order, name)
+					return awCommand(ctx, h, req)
+				},
+			}
+		}
+	}
+	h := Chain(&countingHandler{}, mark("a"), mark("b"), mark("c"))
+	if _, err := h.AWCommand(AWGainQuery{}); err != nil {
+		t.Fatalf("AWCommand() error = %v", err)
+	}
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("middleware order = %v, want %v", order, want)
+	}
+	if h, ok := Chain(&countingHandler{}).(*countingHandler); !ok {
+		t.Errorf("Chain() without middleware = %T, want the handler", h)
+	}
+}
+
+func TestFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		m      AWMiddleware
+		req    AWRequest
+		refuse bool
+	}{
+		{"allowed", Allow(AWPanTilt{}, AWZoom{}), AWZoom{Zoom: 10}, false},
+		{"not allowed", Allow(AWPanTilt{}, AWZoom{}), AWGain{Gain: 6}, true},
+		{"denied", Deny(AWPower{}), AWPower{Power: PowerOn}, true},
+		{"not denied", Deny(AWPower{}), AWPowerQuery{}, false},
+		{"read only query", ReadOnly(), AWZoomQuery{}, false},
+		{"read only error information", ReadOnly(), AWERrorInformation{Info: 1}, false},
+		{"read only change", ReadOnly(), AWGain{Gain: 6}, true},
+		{"read only unknown", ReadOnly(), AWUnknownRequest{text: "XYZ:1"}, true},
+		{"validate", Validate(wiperless), AWWiperControlBasic{Enabled: On}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &countingHandler{}
+			_, err := Chain(h, tt.m).AWCommand(tt.req)
+			var awErr AWError
+			if got := errors.As(err, &awErr); got != tt.refuse {
+				t.Errorf("AWCommand() error = %v, want refused %v", err, tt.refuse)
+			}
+			if tt.refuse && awErr.No != AWErrUnsupported && awErr.No != AWErrUnacceptable {
+				t.Errorf("AWCommand() error number = %v", awErr.No)
+			}
+			if want := map[bool]int{false: 1, true: 0}[tt.refuse]; h.count() != want {
+				t.Errorf("handler received %d requests, want %d", h.count(), want)
+			}
+			if _, err := Chain(h, tt.m).AWBatch(); err != nil {
+				t.Errorf("AWBatch() error = %v, want the batch to pass", err)
+			}
+		})
+	}
+}
+
+func TestReadRequests(t *testing.T) {
+	for _, f := range awRequestTable {
+		req := f.new()
+		if strings.Contains(commandName(req), "Query") && !isQuery(req) {
+			t.Errorf("%s is missing from readRequests", commandName(req))
+		}
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	h := &countingHandler{}
+	var got AWRequest
+	rec := Record(func(_ context.Context, req AWRequest, _ AWResponse, _ error) { got = req })
+	halve := Rewrite(func(req AWRequest) AWRequest {
+		if r, ok := req.(AWGain); ok {
+			r.Gain /= 2
+			return r
+		}
+		return req
+	})
+	if _, err := Chain(h, halve, rec).AWCommand(AWGain{Gain: 12}); err != nil {
+		t.Fatalf("AWCommand() error = %v", err)
+	}
+	if want := (AWGain{Gain: 6}); got != want {
+		t.Errorf("handler got %v, want %v", got, want)
+	}
+}
+
+func TestRemapRemoteID(t *testing.T) {
+	h := &remoteHandler{}
+	srv := httptest.NewServer(&CameraServer{AWHandler: Chain(h, RemapRemoteID(map[WirelessRemoteID]WirelessRemoteID{
+		RemoteCAM1: RemoteCAM3,
+	}))})
+	defer srv.Close()
+	cam := &CameraClient{Remote: netip.MustParseAddrPort(srv.Listener.Addr().String())}
+
+	if _, err := cam.AWCommand(AWWirelessRemoteID{RemoteID: RemoteCAM1}); err != nil {
+		t.Fatalf("AWCommand() error = %v", err)
+	}
+	if h.id != RemoteCAM3 {
+		t.Errorf("camera ID = %v, want %v", h.id, RemoteCAM3)
+	}
+	res, err := cam.AWCommand(AWWirelessRemoteIDQuery{})
+	if want := (AWWirelessRemoteID{RemoteID: RemoteCAM1}); err != nil || res != want {
+		t.Errorf("AWCommand(query) = %v, %v, want %v", res, err, want)
+	}
+	batch, err := cam.AWBatch()
+	if want := []AWResponse{AWWirelessRemoteID{RemoteID: RemoteCAM1}}; err != nil || !reflect.DeepEqual(batch, want) {
+		t.Errorf("AWBatch() = %v, %v, want %v", batch, err, want)
+	}
+	if h.ctx == nil {
+		t.Errorf("handler was not called with the request context")
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	const interval = 20 * time.Millisecond
+	h := Chain(&countingHandler{}, RateLimit(interval))
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.AWCommand(AWGainQuery{})
+		}()
+	}
+	wg.Wait()
+	if got := time.Since(start); got < 3*interval {
+		t.Errorf("4 requests took %v, want at least %v", got, 3*interval)
+	}
+
+	slow := Chain(&countingHandler{}, RateLimit(time.Hour)).(AWHandlerCtx)
+	if _, err := slow.AWCommandCtx(context.Background(), AWGainQuery{}); err != nil {
+		t.Fatalf("AWCommandCtx() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := slow.AWCommandCtx(ctx, AWGainQuery{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("AWCommandCtx() error = %v, want %v", err, context.Canceled)
+	}
+}
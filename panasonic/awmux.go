@@ -0,0 +1,196 @@
+package panasonic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+
+	"puzzlekraken.com/broadcastkit/internal/wirelog"
+)
+
+// CameraMux serves many virtual cameras on one host, for panels which reach
+// every camera at its own address.
+//
+// Each virtual camera is served by its own CameraServer, so panels only
+// receive the notifications of the camera they subscribed to. Requests are
+// routed by the local address they were received on, then by their Host
+// header, and answered with 404 Not Found if neither is mapped.
+//
+// Set the fields before the first request. CameraMux must not be copied after
+// first use.
+type CameraMux struct {
+	// Cameras maps the names of the virtual cameras to their handlers.
+	Cameras map[string]AWHandler
+	// Addrs maps local addresses to camera names. A zero port matches any
+	// port of the address. Run listens on these addresses.
+	Addrs map[netip.AddrPort]string
+	// Hosts maps the Host header of requests to camera names, with or
+	// without the port.
+	Hosts map[string]string
+	// Logger is passed to the CameraServer of every camera.
+	Logger *slog.Logger
+
+	once    sync.Once
+	servers map[string]*CameraServer
+}
+
+func (m *CameraMux) setup() {
+	m.servers = make(map[string]*CameraServer, len(m.Cameras))
+	for name, h := range m.Cameras {
+		m.servers[name] = &CameraServer{AWHandler: h, Logger: m.Logger}
+	}
+}
+
+// Server returns the CameraServer of a camera, or nil if there is no camera
+// of that name. Its Profile and Thumbnails may be set before the first
+// request.
+func (m *CameraMux) Server(name string) *CameraServer {
+	m.once.Do(m.setup)
+	return m.servers[name]
+}
+
+// Notify sends a notification to the panels subscribed to a camera.
+func (m *CameraMux) Notify(name string, res AWResponse) {
+	if s := m.Server(name); s != nil {
+		s.Notify.SendAll(res)
+	}
+}
+
+// route returns the name of the camera a request is for.
+func (m *CameraMux) route(r *http.Request) (string, bool) {
+	if a, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+		if local, err := netip.ParseAddrPort(a.String()); err == nil {
+			ip := local.Addr().Unmap()
+			if name, ok := m.Addrs[netip.AddrPortFrom(ip, local.Port())]; ok {
+				return name, true
+			}
+			if name, ok := m.Addrs[netip.AddrPortFrom(ip, 0)]; ok {
+				return name, true
+			}
+		}
+	}
+	host := unpadHost(r.Host)
+	if name, ok := m.Hosts[host]; ok {
+		return name, true
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		name, ok := m.Hosts[h]
+		return name, ok
+	}
+	return "", false
+}
+
+// unpadHost removes the zero padding of the IP address in the Host header of
+// the AW-RP50, like 198.051.100.008.
+func unpadHost(host string) string {
+	h, port, err := net.SplitHostPort(host)
+	if err != nil {
+		h = host
+	}
+	parts := strings.Split(h, ".")
+	if len(parts) != 4 {
+		return host
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || n > 255 {
+			return host
+		}
+		parts[i] = strconv.Itoa(n)
+	}
+	h = strings.Join(parts, ".")
+	if port != "" {
+		return net.JoinHostPort(h, port)
+	}
+	return h
+}
+
+// ServeHTTP implements the http.Handler interface
+func (m *CameraMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name, ok := m.route(r)
+	s := m.Server(name)
+	if !ok || s == nil {
+		http.NotFound(w, r)
+		return
+	}
+	s.ServeHTTP(w, r)
+}
+
+// Run serves the cameras on every address of Addrs until ctx is done. A zero
+// port listens on port 80.
+func (m *CameraMux) Run(ctx context.Context) error {
+	listeners := make(map[netip.AddrPort]net.Listener)
+	for addr := range m.Addrs {
+		if addr.Port() == 0 {
+			addr = netip.AddrPortFrom(addr.Addr(), 80)
+		}
+		if listeners[addr] != nil {
+			continue
+		}
+		l, err := net.Listen("tcp", addr.String())
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return &SystemError{err}
+		}
+		listeners[addr] = l
+	}
+	srv := &http.Server{Handler: m}
+	for _, l := range listeners {
+		go srv.Serve(l)
+	}
+	<-ctx.Done()
+	srv.Close()
+	return ctx.Err()
+}
+
+// Forward sends the notifications of cam to the panels subscribed to the
+// camera name, until ctx is done. The subscription to cam is stopped when
+// Forward returns.
+//
+// Forward returns ctx.Err() when ctx is done, and an error wrapping
+// net.ErrClosed if the listener closed for another reason.
+func (m *CameraMux) Forward(ctx context.Context, name string, cam *CameraClient) error {
+	s := m.Server(name)
+	if s == nil {
+		return fmt.Errorf("broadcastkit/panasonic: unknown camera: %s", name)
+	}
+	l, err := cam.Listener()
+	if err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { l.Close() })
+	defer func() {
+		if stop() {
+			l.Close()
+		}
+	}()
+	if err := l.Start(); err != nil {
+		return err
+	}
+	var backoff acceptBackoff
+	for {
+		res, err := l.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if err != nil {
+			wirelog.Or(m.Logger).Warn("notification accept", "camera", name, "err", err)
+			backoff.wait(ctx)
+			continue
+		}
+		backoff.reset()
+		s.Notify.SendAll(res)
+	}
+}
@@ -0,0 +1,195 @@
+package panasonic
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+// serveOn starts a test server of h listening on the loopback address ip.
+func serveOn(t *testing.T, h http.Handler, ip string) netip.AddrPort {
+	t.Helper()
+	l, err := net.Listen("tcp4", ip+":0")
+	if err != nil {
+		t.Skipf("cannot listen on %s: %v", ip, err)
+	}
+	srv := httptest.NewUnstartedServer(h)
+	srv.Listener.Close()
+	srv.Listener = l
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return netip.MustParseAddrPort(l.Addr().String())
+}
+
+func TestCameraMux_Route(t *testing.T) {
+	m := &CameraMux{
+		Cameras: map[string]AWHandler{
+			"a": modelHandler("AW-A"),
+			"b": modelHandler("AW-B"),
+			"c": modelHandler("AW-C"),
+		},
+		Addrs: map[netip.AddrPort]string{
+			netip.MustParseAddrPort("127.0.0.2:0"): "b",
+		},
+		Hosts: map[string]string{
+			"cam-a.local":  "a",
+			"198.51.100.8": "c",
+		},
+	}
+	one := serveOn(t, m, "127.0.0.1")
+	two := serveOn(t, m, "127.0.0.2")
+	m.Addrs[one] = "a"
+
+	tests := []struct {
+		name string
+		addr netip.AddrPort
+		host string
+		want string
+	}{
+		{"address and port", one, "", "OID:AW-A"},
+		{"address only", two, "", "OID:AW-B"},
+		{"address before host", two, "cam-a.local", "OID:AW-B"},
+		{"host", one, "cam-a.local", "OID:AW-A"},
+		{"padded host", one, "198.051.100.008", "OID:AW-C"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.host != "" {
+				delete(m.Addrs, one)
+				defer func() { m.Addrs[one] = "a" }()
+			}
+			req, _ := http.NewRequest("GET", "http://"+tt.addr.String()+"/cgi-bin/aw_cam?cmd=QID&res=1", nil)
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("GET error = %v", err)
+			}
+			defer res.Body.Close()
+			b, _ := io.ReadAll(res.Body)
+			if string(b) != tt.want {
+				t.Errorf("response = %q, want %q", b, tt.want)
+			}
+		})
+	}
+
+	delete(m.Addrs, one)
+	req, _ := http.NewRequest("GET", "http://"+one.String()+"/cgi-bin/aw_cam?cmd=QID&res=1", nil)
+	req.Host = "unknown.local"
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("unrouted status = %d, want %d", res.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestCameraMux_Notify(t *testing.T) {
+	upstream := &CameraServer{AWHandler: modelHandler("AW-UP")}
+	up := httptest.NewServer(upstream)
+	defer up.Close()
+
+	m := &CameraMux{
+		Cameras: map[string]AWHandler{
+			"a": modelHandler("AW-A"),
+			"b": modelHandler("AW-B"),
+		},
+		Hosts: map[string]string{},
+	}
+	srv := httptest.NewServer(m)
+	defer srv.Close()
+	addr := netip.MustParseAddrPort(srv.Listener.Addr().String())
+	m.Hosts[addr.String()] = "a"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	done := make(chan error)
+	go func() {
+		done <- m.Forward(ctx, "a", &CameraClient{Remote: netip.MustParseAddrPort(up.Listener.Addr().String())})
+	}()
+
+	panelA, err := (&CameraClient{Remote: addr}).Listener()
+	if err != nil {
+		t.Fatalf("Listener() error = %v", err)
+	}
+	defer panelA.Close()
+	if err := panelA.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if n := m.Server("a").Notify.Len(); n != 1 {
+		t.Errorf("camera a sessions = %d, want 1", n)
+	}
+	if n := m.Server("b").Notify.Len(); n != 0 {
+		t.Errorf("camera b sessions = %d, want 0", n)
+	}
+
+	for upstream.Notify.Len() == 0 {
+		if ctx.Err() != nil {
+			t.Fatal("Forward() did not subscribe to the upstream camera")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	upstream.Notify.SendAll(AWHealthStatus{Code: 0x05})
+	panelA.SetDeadline(time.Now().Add(time.Second))
+	res, err := panelA.Accept()
+	if want := (AWHealthStatus{Code: 0x05}); err != nil || res != want {
+		t.Errorf("Accept() = %v, %v, want %v", res, err, want)
+	}
+
+	m.Notify("a", AWHealthStatus{Code: 0x06})
+	res, err = panelA.Accept()
+	if want := (AWHealthStatus{Code: 0x06}); err != nil || res != want {
+		t.Errorf("Accept() after Notify() = %v, %v, want %v", res, err, want)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Forward() = %v, want %v", err, context.Canceled)
+	}
+	if n := upstream.Notify.Len(); n != 0 {
+		t.Errorf("upstream sessions after Forward() = %d, want 0", n)
+	}
+	if err := m.Forward(context.Background(), "x", nil); err == nil {
+		t.Errorf("Forward(unknown) error = nil, want an error")
+	}
+}
+
+func TestCameraMux_Run(t *testing.T) {
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := netip.MustParseAddrPort(l.Addr().String())
+	l.Close()
+
+	m := &CameraMux{
+		Cameras: map[string]AWHandler{"a": modelHandler("AW-A")},
+		Addrs:   map[netip.AddrPort]string{addr: "a"},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- m.Run(ctx) }()
+
+	cam := &CameraClient{Remote: addr}
+	var p *ModelProfile
+	for start := time.Now(); time.Since(start) < 2*time.Second; time.Sleep(5 * time.Millisecond) {
+		if p, err = cam.DetectProfile(); err == nil {
+			break
+		}
+	}
+	if err != nil || p.Model != "AW-A" {
+		t.Errorf("DetectProfile() = %v, %v, want AW-A", p, err)
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run() = %v, want %v", err, context.Canceled)
+	}
+}
@@ -1,9 +1,11 @@
 package panasonic
 
 import (
+	"context"
 	"encoding/binary"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
 	"net/http"
 	"net/netip"
@@ -11,6 +13,9 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"puzzlekraken.com/broadcastkit/internal/wirelog"
+	"puzzlekraken.com/broadcastkit/metrics"
 )
 
 // notifyUnpack retrieves a string response from the notification container
@@ -127,7 +132,7 @@ func (l *NotifyListener) start() error {
 	}
 	defer res.Body.Close()
 	if res.StatusCode != http.StatusNoContent {
-		return &SystemError{fmt.Errorf("http status code: %d (expected %d)", res.StatusCode, http.StatusNoContent)}
+		return &SystemError{&statusError{res.StatusCode, http.StatusNoContent}}
 	}
 	return nil
 }
@@ -135,13 +140,13 @@ func (l *NotifyListener) start() error {
 // Stop requests the camera to stop sending notifications
 func (l *NotifyListener) Stop() error {
 	port := netip.MustParseAddrPort(l.lis.Addr().String()).Port()
-	res, err := l.cam.httpGet("/cgi-bin/event", "connect=start&my_port="+strconv.Itoa(int(port))+"&uid=0", nil)
+	res, err := l.cam.httpGet("/cgi-bin/event", "connect=stop&my_port="+strconv.Itoa(int(port))+"&uid=0", nil)
 	if err != nil {
 		return &SystemError{err}
 	}
 	defer res.Body.Close()
 	if res.StatusCode != http.StatusNoContent {
-		return &SystemError{fmt.Errorf("http status code: %d (expected %d)", res.StatusCode, http.StatusNoContent)}
+		return &SystemError{&statusError{res.StatusCode, http.StatusNoContent}}
 	}
 	return nil
 }
@@ -181,7 +186,12 @@ func (l *NotifyListener) SetDeadline(t time.Time) error {
 // the responsibility of the caller to re-call Start() if expected notifications
 // are not received.
 func (l *NotifyListener) Accept() (AWResponse, error) {
-	l.once.Do(func() { l.start() })
+	log := wirelog.Or(l.cam.Logger)
+	l.once.Do(func() {
+		if err := l.start(); err != nil {
+			log.Info("notification start", "err", err)
+		}
+	})
 
 	conn, err := l.acceptTCP()
 	if err != nil {
@@ -195,15 +205,47 @@ func (l *NotifyListener) Accept() (AWResponse, error) {
 	if err != nil {
 		return nil, &SystemError{err}
 	}
+	wirelog.Recv(l.cam.Logger, b)
 
 	cmd, err := notifyUnpack(b)
 	if err != nil {
+		log.Warn("notification parse", "err", err)
 		return nil, &SystemError{err}
 	}
+	if l.cam.Metrics != nil {
+		l.cam.Metrics.Notification(l.cam.Remote.String(), metrics.Received)
+	}
 
 	return newResponse(cmd, quirkNotify), nil
 }
 
+// acceptBackoff delays the retries of a failing Accept, so a persistent
+// failure like running out of file descriptors does not spin. The delay
+// doubles with every consecutive failure up to a second, like in net/http.
+type acceptBackoff struct {
+	delay time.Duration
+}
+
+// wait sleeps before the next retry, or until ctx is done.
+func (b *acceptBackoff) wait(ctx context.Context) {
+	if b.delay == 0 {
+		b.delay = 5 * time.Millisecond
+	} else {
+		b.delay = min(2*b.delay, time.Second)
+	}
+	t := time.NewTimer(b.delay)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
+
+// reset forgets the failures after a successful Accept.
+func (b *acceptBackoff) reset() {
+	b.delay = 0
+}
+
 // Close closes the listener.
 //
 // Any currently blocked Accept() calls will be unblocked and return an error.
@@ -221,6 +263,11 @@ type NotifyServer struct {
 	// effectively keying the map on IP instead of IP:PORT. Uncertain if it is
 	// intentional, we stick to IP:PORT mapping.
 	list map[netip.AddrPort]*NotifySession
+	// Logger, if set, receives subscription changes and delivery failures.
+	Logger *slog.Logger
+	// Metrics, if set, receives the notifications delivered and the error
+	// counters of the sessions.
+	Metrics metrics.Recorder
 }
 
 // SendAll sends a notification to all active sessions.
@@ -232,10 +279,20 @@ func (l *NotifyServer) SendAll(res AWResponse) {
 	defer l.lock.Unlock()
 	for dst, s := range l.list {
 		if s.Errors.Load() > 2 {
+			wirelog.Or(l.Logger).Warn("notification peer dropped", "peer", dst)
 			delete(l.list, dst)
 			continue
 		}
-		s.Send(res)
+		err := s.Send(res)
+		if err != nil {
+			wirelog.Or(l.Logger).Info("notification delivery", "peer", dst, "err", err)
+		}
+		if l.Metrics != nil {
+			if err == nil {
+				l.Metrics.Notification(dst.String(), metrics.Sent)
+			}
+			l.Metrics.NotifySessionErrors(dst.String(), int(s.Errors.Load()))
+		}
 	}
 }
 
@@ -247,6 +304,7 @@ func (l *NotifyServer) Add(peer netip.AddrPort) {
 		l.list = make(map[netip.AddrPort]*NotifySession)
 	}
 	l.list[peer] = NewNotifySession(peer)
+	wirelog.Or(l.Logger).Info("notification peer added", "peer", peer)
 }
 
 // Remove removes a peer from the session list
@@ -254,6 +312,7 @@ func (l *NotifyServer) Remove(peer netip.AddrPort) {
 	l.lock.Lock()
 	defer l.lock.Unlock()
 	delete(l.list, peer)
+	wirelog.Or(l.Logger).Info("notification peer removed", "peer", peer)
 }
 
 // Len returns the number of active sessions
@@ -0,0 +1,63 @@
+package panasonic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"puzzlekraken.com/broadcastkit/capture"
+)
+
+func TestNotifyUnpack_Capture(t *testing.T) {
+	// Every received event holds one notification, as the camera opens a new
+	// connection for each of them.
+	events, err := capture.ReadFile("testdata/notify.capture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"lPI1", "aPC8000", "axz5A3", "OGU:08", "OAW:1", "dOSD:4C:1", "OER:0"}
+	if len(events) != len(want) {
+		t.Fatalf("capture has %d events, want %d", len(events), len(want))
+	}
+	for i, e := range events {
+		got, err := notifyUnpack(e.Data)
+		if err != nil {
+			t.Errorf("notifyUnpack() error = %v", err)
+			continue
+		}
+		if got != want[i] {
+			t.Errorf("notifyUnpack() = %q, want %q", got, want[i])
+		}
+	}
+}
+
+func TestNotifyUnpack_Truncated(t *testing.T) {
+	events, err := capture.ReadFile("testdata/notify.capture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := events[0].Data
+	for _, n := range []int{0, 23, 24, len(b) - 1} {
+		if _, err := notifyUnpack(b[:n]); err == nil {
+			t.Errorf("notifyUnpack(%d bytes) error = nil, want error", n)
+		}
+	}
+}
+
+func TestAcceptBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var b acceptBackoff
+	var delays []time.Duration
+	for range 10 {
+		b.wait(ctx)
+		delays = append(delays, b.delay)
+	}
+	if delays[0] != 5*time.Millisecond || delays[1] != 10*time.Millisecond || delays[9] != time.Second {
+		t.Errorf("delays = %v, want doubling from 5ms up to 1s", delays)
+	}
+	b.reset()
+	if b.wait(ctx); b.delay != 5*time.Millisecond {
+		t.Errorf("delay after reset = %v, want 5ms", b.delay)
+	}
+}
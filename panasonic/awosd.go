@@ -0,0 +1,211 @@
+package panasonic
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"puzzlekraken.com/broadcastkit/internal/wirelog"
+)
+
+// ErrOSDClosed is returned by OSDSession navigation when the menu is closed.
+var ErrOSDClosed = errors.New("broadcastkit/panasonic: OSD menu is closed")
+
+const (
+	osdPace    = 300 * time.Millisecond
+	osdTimeout = 60 * time.Second
+)
+
+// OSDSession drives the on-screen menu of a camera remotely.
+//
+// The menu state is tracked from the commands sent through the session, the
+// OSD query, and the notifications passed to Update. A menu opened by the
+// session is closed automatically if it is not navigated for Timeout, so a
+// forgotten session does not leave the menu burnt into the program output.
+//
+// Use CameraClient.OSD to obtain a session. Set the fields before first use.
+type OSDSession struct {
+	// Pace is the minimum time between navigation commands, 300ms by
+	// default. Cameras drop menu key presses that arrive faster.
+	Pace time.Duration
+	// Timeout is the idle time after which the menu is closed, 60 seconds
+	// by default. A negative Timeout disables closing.
+	Timeout time.Duration
+
+	cam   *CameraClient
+	lock  sync.Mutex
+	open  bool
+	known bool      // open reflects the camera
+	last  time.Time // time of the last navigation command
+	timer *time.Timer
+	armed int // generation of timer, to ignore stale expiries
+}
+
+// OSD returns a new session for the on-screen menu of the camera.
+func (c *CameraClient) OSD() *OSDSession {
+	return &OSDSession{cam: c}
+}
+
+// Open opens the menu.
+func (s *OSDSession) Open() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.set(On); err != nil {
+		return err
+	}
+	s.arm()
+	return nil
+}
+
+// Close closes the menu.
+func (s *OSDSession) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.disarm()
+	return s.set(Off)
+}
+
+// Up moves the menu cursor up.
+func (s *OSDSession) Up() error {
+	return s.navigate(AWOSDUp{I: 1})
+}
+
+// Down moves the menu cursor down.
+func (s *OSDSession) Down() error {
+	return s.navigate(AWOSDDown{I: 1})
+}
+
+// Ok selects the item under the menu cursor.
+func (s *OSDSession) Ok() error {
+	return s.navigate(AWOSDOk{I: 1})
+}
+
+// IsOpen queries the camera whether the menu is open.
+func (s *OSDSession) IsOpen() (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.query()
+}
+
+// Update tracks the menu state from a notification of the camera. Responses
+// other than the OSD state are ignored, so every notification may be passed.
+//
+// A menu closed at the camera stops the automatic closing. A menu opened by
+// someone else is tracked, but not closed automatically.
+func (s *OSDSession) Update(res AWResponse) {
+	var open bool
+	switch r := res.(type) {
+	case AWOSDSet:
+		open = r.Enable == On
+	case AWOSDQuery:
+		open = r.Enable == On
+	default:
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.open, s.known = open, true
+	if !open {
+		s.disarm()
+	}
+}
+
+// navigate sends a menu key press at the pace of the session.
+func (s *OSDSession) navigate(req AWRequest) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.known {
+		if _, err := s.query(); err != nil {
+			return err
+		}
+	}
+	if !s.open {
+		return ErrOSDClosed
+	}
+	pace := s.Pace
+	if pace == 0 {
+		pace = osdPace
+	}
+	if wait := time.Until(s.last.Add(pace)); wait > 0 {
+		time.Sleep(wait)
+	}
+	_, err := s.cam.AWCommand(req)
+	s.last = time.Now()
+	if err != nil {
+		// The camera may refuse the key because the menu closed under us.
+		s.known = false
+		return err
+	}
+	if s.timer != nil {
+		s.arm()
+	}
+	return nil
+}
+
+// set opens or closes the menu. The lock must be held.
+func (s *OSDSession) set(enable Toggle) error {
+	res, err := s.cam.AWCommand(AWOSDSet{Enable: enable})
+	if err != nil {
+		s.known = false
+		return err
+	}
+	if r, ok := res.(AWOSDSet); ok {
+		enable = r.Enable
+	}
+	s.open, s.known = enable == On, true
+	return nil
+}
+
+// query asks the camera for the menu state. The lock must be held.
+func (s *OSDSession) query() (bool, error) {
+	res, err := s.cam.AWCommand(AWOSDQuery{})
+	if err != nil {
+		return false, err
+	}
+	r, ok := res.(AWOSDQuery)
+	if !ok {
+		return false, &SystemError{errors.New("unexpected response to OSD query: " + res.packResponse())}
+	}
+	s.open, s.known = r.Enable == On, true
+	if !s.open {
+		s.disarm()
+	}
+	return s.open, nil
+}
+
+// arm (re)starts the idle timer. The lock must be held.
+func (s *OSDSession) arm() {
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = osdTimeout
+	}
+	if timeout < 0 {
+		return
+	}
+	s.disarm()
+	s.armed++
+	armed := s.armed
+	s.timer = time.AfterFunc(timeout, func() { s.expire(armed) })
+}
+
+// disarm stops the idle timer. The lock must be held.
+func (s *OSDSession) disarm() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+}
+
+// expire closes the menu when the timer of generation armed fires, unless it
+// was stopped or re-armed since.
+func (s *OSDSession) expire(armed int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.timer == nil || s.armed != armed {
+		return
+	}
+	s.timer = nil
+	if err := s.set(Off); err != nil {
+		wirelog.Or(s.cam.Logger).Warn("OSD menu timeout close", "err", err)
+	}
+}
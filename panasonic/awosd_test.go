@@ -0,0 +1,142 @@
+package panasonic
+
+import (
+	"errors"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeMenu is a camera with an on-screen menu.
+type fakeMenu struct {
+	lock sync.Mutex
+	open bool
+	cmds []string
+	at   []time.Time
+}
+
+func (f *fakeMenu) AWCommand(req AWRequest) (AWResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.cmds = append(f.cmds, req.packRequest())
+	f.at = append(f.at, time.Now())
+	switch r := req.(type) {
+	case AWOSDSet:
+		f.open = r.Enable == On
+	case AWOSDQuery:
+		if f.open {
+			return AWOSDQuery{Enable: On}, nil
+		}
+		return AWOSDQuery{Enable: Off}, nil
+	}
+	return req.Response(), nil
+}
+
+func (f *fakeMenu) AWBatch() ([]AWResponse, error) { return nil, nil }
+
+func (f *fakeMenu) commands() []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return slices.Clone(f.cmds)
+}
+
+func newFakeMenu(t *testing.T) (*fakeMenu, *CameraClient) {
+	t.Helper()
+	menu := &fakeMenu{}
+	srv := httptest.NewServer(&CameraServer{AWHandler: menu})
+	t.Cleanup(srv.Close)
+	return menu, &CameraClient{Remote: netip.MustParseAddrPort(srv.Listener.Addr().String())}
+}
+
+func TestOSDSession(t *testing.T) {
+	menu, cam := newFakeMenu(t)
+	osd := cam.OSD()
+	osd.Pace = 20 * time.Millisecond
+	osd.Timeout = -1
+
+	if err := osd.Down(); !errors.Is(err, ErrOSDClosed) {
+		t.Fatalf("Down() on closed menu = %v, want ErrOSDClosed", err)
+	}
+	if err := osd.Open(); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	for _, key := range []func() error{osd.Down, osd.Down, osd.Up, osd.Ok} {
+		if err := key(); err != nil {
+			t.Fatalf("navigation error = %v", err)
+		}
+	}
+	if open, err := osd.IsOpen(); err != nil || !open {
+		t.Errorf("IsOpen() = %v, %v, want true", open, err)
+	}
+	if err := osd.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	want := []string{"QUS", "DUS:1", "DDW:1", "DDW:1", "DUP:1", "DIT:1", "QUS", "DUS:0"}
+	if got := menu.commands(); !slices.Equal(got, want) {
+		t.Errorf("commands = %q, want %q", got, want)
+	}
+	for i := 3; i <= 5; i++ {
+		if gap := menu.at[i].Sub(menu.at[i-1]); gap < osd.Pace {
+			t.Errorf("gap before %s = %v, want at least %v", menu.cmds[i], gap, osd.Pace)
+		}
+	}
+}
+
+func TestOSDSession_Timeout(t *testing.T) {
+	menu, cam := newFakeMenu(t)
+	osd := cam.OSD()
+	osd.Pace = time.Millisecond
+	osd.Timeout = 50 * time.Millisecond
+
+	if err := osd.Open(); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	// Navigation postpones the timeout.
+	time.Sleep(30 * time.Millisecond)
+	if err := osd.Down(); err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if got := menu.commands(); got[len(got)-1] == "DUS:0" {
+		t.Fatalf("menu closed before the timeout after navigation: %q", got)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if got := menu.commands(); got[len(got)-1] == "DUS:0" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("menu not closed after timeout: %q", menu.commands())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := osd.Ok(); !errors.Is(err, ErrOSDClosed) {
+		t.Errorf("Ok() after timeout = %v, want ErrOSDClosed", err)
+	}
+}
+
+func TestOSDSession_Update(t *testing.T) {
+	menu, cam := newFakeMenu(t)
+	osd := cam.OSD()
+	osd.Pace = time.Millisecond
+	osd.Timeout = 20 * time.Millisecond
+
+	if err := osd.Open(); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	// Closed at the camera, the session must neither navigate nor close.
+	osd.Update(AWOSDSet{Enable: Off})
+	osd.Update(AWPower{Power: PowerOn})
+	if err := osd.Up(); !errors.Is(err, ErrOSDClosed) {
+		t.Errorf("Up() after close notification = %v, want ErrOSDClosed", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got, want := menu.commands(), []string{"DUS:1"}; !slices.Equal(got, want) {
+		t.Errorf("commands = %q, want %q", got, want)
+	}
+}
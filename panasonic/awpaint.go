@@ -0,0 +1,301 @@
+package panasonic
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// PaintSnapshot holds the paint settings of a camera, the settings a video
+// engineer matches between cameras of a multi-camera show.
+//
+// A snapshot can be stored as JSON and applied to any number of cameras.
+type PaintSnapshot struct {
+	Scene       int           `json:"scene"`
+	Gain        Decibel       `json:"gain"`
+	Pedestal    int           `json:"pedestal"`
+	WhiteMode   WhiteMode     `json:"white_mode"`
+	RGain       int           `json:"r_gain"`
+	BGain       int           `json:"b_gain"`
+	ColorTemp   ColorTemp     `json:"color_temp"`
+	Detail      DetailLevel   `json:"detail"`
+	TotalDetail int           `json:"total_detail"`
+	Shutter     ShutterMode   `json:"shutter"`
+	NDFilter    NDFilter      `json:"nd_filter"`
+	Contrast    CenteredScale `json:"contrast"`
+	// Missing names the settings the camera did not report, because the
+	// model lacks them or they are disabled in the current mode, like the R
+	// and B gain with auto tracking white. These are neither compared nor
+	// applied.
+	Missing []string `json:"missing,omitempty"`
+}
+
+// PaintChange is a setting differing between two snapshots.
+type PaintChange struct {
+	Setting  string
+	From, To any
+}
+
+// paintSetting describes how a field of PaintSnapshot is read and written.
+type paintSetting struct {
+	name  string
+	query AWRequest
+	// disabled is the response of cameras with the setting turned off.
+	disabled AWResponse
+	// read stores the response in the snapshot, if it belongs to the setting.
+	read  func(*PaintSnapshot, AWResponse) bool
+	write func(PaintSnapshot) AWRequest
+	value func(PaintSnapshot) any
+}
+
+// paintSettings is in the order of applying the settings. The scene comes
+// first as it loads a whole scene file, and the white balance mode comes
+// before the values it enables.
+var paintSettings = []paintSetting{
+	{
+		name:  "scene",
+		query: AWSceneQuery{},
+		read: func(s *PaintSnapshot, res AWResponse) bool {
+			r, ok := res.(AWSceneQuery)
+			s.Scene = r.Scene
+			return ok
+		},
+		write: func(s PaintSnapshot) AWRequest { return AWSceneSet{Scene: s.Scene} },
+		value: func(s PaintSnapshot) any { return s.Scene },
+	},
+	{
+		name:  "white_mode",
+		query: AWWhiteBalanceModeQuery{},
+		read: func(s *PaintSnapshot, res AWResponse) bool {
+			r, ok := res.(AWWhiteBalanceMode)
+			s.WhiteMode = r.WhiteMode
+			return ok
+		},
+		write: func(s PaintSnapshot) AWRequest { return AWWhiteBalanceMode{WhiteMode: s.WhiteMode} },
+		value: func(s PaintSnapshot) any { return s.WhiteMode },
+	},
+	{
+		name:     "r_gain",
+		query:    AWRGainQuery{},
+		disabled: AWRGainDisabled{},
+		read: func(s *PaintSnapshot, res AWResponse) bool {
+			r, ok := res.(AWRGainControl)
+			s.RGain = r.Gain
+			return ok
+		},
+		write: func(s PaintSnapshot) AWRequest { return AWRGainControl{Gain: s.RGain} },
+		value: func(s PaintSnapshot) any { return s.RGain },
+	},
+	{
+		name:     "b_gain",
+		query:    AWBGainQuery{},
+		disabled: AWBGainDisabled{},
+		read: func(s *PaintSnapshot, res AWResponse) bool {
+			r, ok := res.(AWBGainControl)
+			s.BGain = r.Gain
+			return ok
+		},
+		write: func(s PaintSnapshot) AWRequest { return AWBGainControl{Gain: s.BGain} },
+		value: func(s PaintSnapshot) any { return s.BGain },
+	},
+	{
+		name:  "color_temp",
+		query: AWColorTempQuery{},
+		read: func(s *PaintSnapshot, res AWResponse) bool {
+			r, ok := res.(AWColorTemp)
+			s.ColorTemp = r.Temp
+			return ok
+		},
+		write: func(s PaintSnapshot) AWRequest { return AWColorTemp{Temp: s.ColorTemp} },
+		value: func(s PaintSnapshot) any { return s.ColorTemp },
+	},
+	{
+		name:  "gain",
+		query: AWGainQuery{},
+		read: func(s *PaintSnapshot, res AWResponse) bool {
+			r, ok := res.(AWGain)
+			s.Gain = r.Gain
+			return ok
+		},
+		write: func(s PaintSnapshot) AWRequest { return AWGain{Gain: s.Gain} },
+		value: func(s PaintSnapshot) any { return s.Gain },
+	},
+	{
+		name:  "pedestal",
+		query: AWPedestalQuery{},
+		read: func(s *PaintSnapshot, res AWResponse) bool {
+			r, ok := res.(AWPedestal)
+			s.Pedestal = r.Pedestal
+			return ok
+		},
+		write: func(s PaintSnapshot) AWRequest { return AWPedestal{Pedestal: s.Pedestal} },
+		value: func(s PaintSnapshot) any { return s.Pedestal },
+	},
+	{
+		name:  "shutter",
+		query: AWShutterModeQuery{},
+		read: func(s *PaintSnapshot, res AWResponse) bool {
+			r, ok := res.(AWShutterMode)
+			s.Shutter = r.ShutterMode
+			return ok
+		},
+		write: func(s PaintSnapshot) AWRequest { return AWShutterMode{ShutterMode: s.Shutter} },
+		value: func(s PaintSnapshot) any { return s.Shutter },
+	},
+	{
+		name:  "nd_filter",
+		query: AWNDFilterQuery{},
+		read: func(s *PaintSnapshot, res AWResponse) bool {
+			r, ok := res.(AWNDFilter)
+			s.NDFilter = r.Level
+			return ok
+		},
+		write: func(s PaintSnapshot) AWRequest { return AWNDFilter{Level: s.NDFilter} },
+		value: func(s PaintSnapshot) any { return s.NDFilter },
+	},
+	{
+		name:  "detail",
+		query: AWDetailQuery{},
+		read: func(s *PaintSnapshot, res AWResponse) bool {
+			r, ok := res.(AWDetail)
+			s.Detail = r.Detail
+			return ok
+		},
+		write: func(s PaintSnapshot) AWRequest { return AWDetail{Detail: s.Detail} },
+		value: func(s PaintSnapshot) any { return s.Detail },
+	},
+	{
+		name:  "total_detail",
+		query: AWTotalDetailQuery{},
+		read: func(s *PaintSnapshot, res AWResponse) bool {
+			r, ok := res.(AWTotalDetail)
+			s.TotalDetail = r.Detail
+			return ok
+		},
+		write: func(s PaintSnapshot) AWRequest { return AWTotalDetail{Detail: s.TotalDetail} },
+		value: func(s PaintSnapshot) any { return s.TotalDetail },
+	},
+	{
+		name:  "contrast",
+		query: AWContrastLevelQuery{},
+		read: func(s *PaintSnapshot, res AWResponse) bool {
+			r, ok := res.(AWContrastLevel)
+			s.Contrast = r.Level
+			return ok
+		},
+		write: func(s PaintSnapshot) AWRequest { return AWContrastLevel{Level: s.Contrast} },
+		value: func(s PaintSnapshot) any { return s.Contrast },
+	},
+}
+
+// ReadPaint reads the paint settings of a camera.
+//
+// The settings found on the camdata page are taken from a single AWBatch, the
+// rest is queried one by one. Settings the camera refuses with an AWError are
+// listed in Missing, only network failures are returned as errors.
+func ReadPaint(c *CameraClient) (PaintSnapshot, error) {
+	var s PaintSnapshot
+	done := make([]bool, len(paintSettings))
+	missing := make([]bool, len(paintSettings))
+	// Older models have no camdata page, they are queried instead.
+	batch, _ := c.AWBatch()
+	for _, res := range batch {
+		for i, p := range paintSettings {
+			if done[i] {
+				continue
+			}
+			if p.disabled != nil && res == p.disabled {
+				done[i], missing[i] = true, true
+				break
+			}
+			if p.read(&s, res) {
+				done[i] = true
+				break
+			}
+		}
+	}
+	for i, p := range paintSettings {
+		if done[i] {
+			continue
+		}
+		res, err := c.AWCommand(p.query)
+		var sysErr *SystemError
+		if errors.As(err, &sysErr) {
+			return s, fmt.Errorf("%s: %w", p.name, err)
+		}
+		missing[i] = err != nil || !p.read(&s, res)
+	}
+	for i, p := range paintSettings {
+		if missing[i] {
+			s.Missing = append(s.Missing, p.name)
+		}
+	}
+	return s, nil
+}
+
+// has reports whether the setting was read from the camera.
+func (s PaintSnapshot) has(name string) bool {
+	return !slices.Contains(s.Missing, name)
+}
+
+// Diff returns the settings changed from s to t. Settings missing from either
+// snapshot are not compared.
+func (s PaintSnapshot) Diff(t PaintSnapshot) []PaintChange {
+	var diff []PaintChange
+	for _, p := range paintSettings {
+		if !s.has(p.name) || !t.has(p.name) {
+			continue
+		}
+		if from, to := p.value(s), p.value(t); from != to {
+			diff = append(diff, PaintChange{Setting: p.name, From: from, To: to})
+		}
+	}
+	return diff
+}
+
+// Apply sets the paint settings of the snapshot on a camera.
+//
+// The color temperature is only applied in variable white balance mode, as
+// cameras refuse it otherwise. A refused setting does not stop the others
+// from being applied, the errors of all of them are returned together.
+func (s PaintSnapshot) Apply(c *CameraClient) error {
+	var errs []error
+	for _, p := range paintSettings {
+		if !s.has(p.name) {
+			continue
+		}
+		if p.name == "color_temp" && s.has("white_mode") && s.WhiteMode != WhiteVariable {
+			continue
+		}
+		if _, err := c.AWCommand(p.write(s)); err != nil {
+			var sysErr *SystemError
+			if errors.As(err, &sysErr) {
+				return errors.Join(append(errs, fmt.Errorf("%s: %w", p.name, err))...)
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", p.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ApplyAll sets the paint settings of the snapshot on all cameras in parallel.
+// The errors are prefixed with the name of the camera.
+func (s PaintSnapshot) ApplyAll(cameras map[string]*CameraClient) error {
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	var errs []error
+	for name, cam := range cameras {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Apply(cam); err != nil {
+				lock.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
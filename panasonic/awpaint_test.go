@@ -0,0 +1,244 @@
+package panasonic
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// fakePaint is a camera holding paint settings. The R and B gain are disabled
+// with auto tracking white, and contrast is unsupported unless enabled.
+type fakePaint struct {
+	lock     sync.Mutex
+	s        PaintSnapshot
+	contrast bool
+}
+
+func (f *fakePaint) gainEnabled() bool {
+	return f.s.WhiteMode != AutoTrackingWhite
+}
+
+func (f *fakePaint) AWCommand(req AWRequest) (AWResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	switch r := req.(type) {
+	case AWSceneQuery:
+		return AWSceneQuery{Scene: f.s.Scene}, nil
+	case AWShutterModeQuery:
+		return AWShutterMode{ShutterMode: f.s.Shutter}, nil
+	case AWNDFilterQuery:
+		return AWNDFilter{Level: f.s.NDFilter}, nil
+	case AWDetailQuery:
+		return AWDetail{Detail: f.s.Detail}, nil
+	case AWTotalDetailQuery:
+		return AWTotalDetail{Detail: f.s.TotalDetail}, nil
+	case AWContrastLevelQuery:
+		if !f.contrast {
+			return nil, NewAWError(AWErrUnsupported, req)
+		}
+		return AWContrastLevel{Level: f.s.Contrast}, nil
+	case AWSceneSet:
+		f.s.Scene = r.Scene
+	case AWGain:
+		f.s.Gain = r.Gain
+	case AWPedestal:
+		f.s.Pedestal = r.Pedestal
+	case AWWhiteBalanceMode:
+		f.s.WhiteMode = r.WhiteMode
+	case AWRGainControl:
+		if !f.gainEnabled() {
+			return nil, NewAWError(AWErrUnacceptable, req)
+		}
+		f.s.RGain = r.Gain
+	case AWBGainControl:
+		if !f.gainEnabled() {
+			return nil, NewAWError(AWErrUnacceptable, req)
+		}
+		f.s.BGain = r.Gain
+	case AWColorTemp:
+		if f.s.WhiteMode != WhiteVariable {
+			return nil, NewAWError(AWErrUnacceptable, req)
+		}
+		f.s.ColorTemp = r.Temp
+	case AWShutterMode:
+		f.s.Shutter = r.ShutterMode
+	case AWNDFilter:
+		f.s.NDFilter = r.Level
+	case AWDetail:
+		f.s.Detail = r.Detail
+	case AWTotalDetail:
+		f.s.TotalDetail = r.Detail
+	case AWContrastLevel:
+		if !f.contrast {
+			return nil, NewAWError(AWErrUnsupported, req)
+		}
+		f.s.Contrast = r.Level
+	default:
+		return nil, NewAWError(AWErrUnsupported, req)
+	}
+	return req.Response(), nil
+}
+
+// AWBatch reports the settings found on the camdata page of real cameras.
+func (f *fakePaint) AWBatch() ([]AWResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	var r, b AWResponse = AWRGainDisabled{}, AWBGainDisabled{}
+	if f.gainEnabled() {
+		r, b = AWRGainControl{Gain: f.s.RGain}, AWBGainControl{Gain: f.s.BGain}
+	}
+	return []AWResponse{
+		AWGain{Gain: f.s.Gain},
+		AWPedestal{Pedestal: f.s.Pedestal},
+		AWWhiteBalanceMode{WhiteMode: f.s.WhiteMode},
+		r, b,
+		AWColorTemp{Temp: f.s.ColorTemp},
+	}, nil
+}
+
+func (f *fakePaint) serve(t *testing.T) *CameraClient {
+	t.Helper()
+	srv := httptest.NewServer(&CameraServer{AWHandler: f})
+	t.Cleanup(srv.Close)
+	return &CameraClient{Remote: netip.MustParseAddrPort(srv.Listener.Addr().String())}
+}
+
+func TestReadPaint(t *testing.T) {
+	tests := []struct {
+		name string
+		cam  *fakePaint
+		want PaintSnapshot
+	}{
+		{
+			name: "all settings",
+			cam: &fakePaint{contrast: true, s: PaintSnapshot{
+				Scene: 2, Gain: 12, Pedestal: -9, WhiteMode: WhiteVariable, RGain: 4, BGain: -6,
+				ColorTemp: ToColorTemp(5600), Detail: DetailHigh, TotalDetail: 20,
+				Shutter: Shutter1o100, NDFilter: NDFilter1_16, Contrast: -12,
+			}},
+			want: PaintSnapshot{
+				Scene: 2, Gain: 12, Pedestal: -9, WhiteMode: WhiteVariable, RGain: 4, BGain: -6,
+				ColorTemp: ToColorTemp(5600), Detail: DetailHigh, TotalDetail: 20,
+				Shutter: Shutter1o100, NDFilter: NDFilter1_16, Contrast: -12,
+			},
+		},
+		{
+			name: "disabled and unsupported",
+			cam: &fakePaint{s: PaintSnapshot{
+				Gain: DecibelAuto, WhiteMode: AutoTrackingWhite, RGain: 4, Contrast: 5,
+			}},
+			want: PaintSnapshot{
+				Gain: DecibelAuto, WhiteMode: AutoTrackingWhite,
+				Missing: []string{"r_gain", "b_gain", "contrast"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadPaint(tt.cam.serve(t))
+			if err != nil {
+				t.Fatalf("ReadPaint() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadPaint() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadPaint_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(&CameraServer{AWHandler: &fakePaint{}})
+	cam := &CameraClient{Remote: netip.MustParseAddrPort(srv.Listener.Addr().String())}
+	srv.Close()
+	if _, err := ReadPaint(cam); err == nil {
+		t.Errorf("ReadPaint() error = nil, want an error")
+	}
+}
+
+func TestPaintSnapshot_Diff(t *testing.T) {
+	base := PaintSnapshot{Gain: 6, WhiteMode: AutoWhiteBalanceA, RGain: 1, BGain: 2}
+	tests := []struct {
+		name string
+		s, t PaintSnapshot
+		want []PaintChange
+	}{
+		{
+			name: "equal",
+			s:    base,
+			t:    base,
+		},
+		{
+			name: "changed",
+			s:    base,
+			t:    PaintSnapshot{Gain: 9, WhiteMode: AutoWhiteBalanceA, RGain: 1, BGain: -2, Shutter: Shutter1o60},
+			want: []PaintChange{
+				{Setting: "b_gain", From: 2, To: -2},
+				{Setting: "gain", From: Decibel(6), To: Decibel(9)},
+				{Setting: "shutter", From: ShutterMode(0), To: ShutterMode(Shutter1o60)},
+			},
+		},
+		{
+			name: "missing on either side",
+			s:    PaintSnapshot{Gain: 6, RGain: 1, Missing: []string{"gain"}},
+			t:    PaintSnapshot{Gain: 9, RGain: 5, Missing: []string{"r_gain"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.Diff(tt.t); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaintSnapshot_ApplyAll(t *testing.T) {
+	src := &fakePaint{s: PaintSnapshot{
+		Scene: 1, Gain: 3, Pedestal: 6, WhiteMode: AutoWHiteBalanceB, RGain: -4, BGain: 7,
+		ColorTemp: ToColorTemp(3200), Detail: DetailLow, TotalDetail: -10,
+		Shutter: Shutter1o120, NDFilter: NDFilter1_4,
+	}}
+	snap, err := ReadPaint(src.serve(t))
+	if err != nil {
+		t.Fatalf("ReadPaint() error = %v", err)
+	}
+	dst := map[string]*fakePaint{
+		"cam1": {s: PaintSnapshot{WhiteMode: AutoTrackingWhite}},
+		"cam2": {contrast: true, s: PaintSnapshot{WhiteMode: WhiteVariable, Contrast: 8}},
+	}
+	cams := make(map[string]*CameraClient)
+	for name, f := range dst {
+		cams[name] = f.serve(t)
+	}
+	if err := snap.ApplyAll(cams); err != nil {
+		t.Fatalf("ApplyAll() error = %v", err)
+	}
+	for name, cam := range cams {
+		got, err := ReadPaint(cam)
+		if err != nil {
+			t.Fatalf("ReadPaint(%s) error = %v", name, err)
+		}
+		// The color temperature is only applied in variable mode.
+		got.ColorTemp = snap.ColorTemp
+		if diff := snap.Diff(got); len(diff) != 0 {
+			t.Errorf("%s differs after ApplyAll(): %v", name, diff)
+		}
+	}
+	if got := dst["cam2"].s.Contrast; got != 8 {
+		t.Errorf("cam2 contrast = %v, want 8 as the source lacks contrast", got)
+	}
+}
+
+func TestPaintSnapshot_Apply_Errors(t *testing.T) {
+	f := &fakePaint{s: PaintSnapshot{WhiteMode: AutoTrackingWhite}}
+	snap := PaintSnapshot{Gain: 6, RGain: 3, Missing: []string{"white_mode"}}
+	err := snap.Apply(f.serve(t))
+	if err == nil {
+		t.Fatalf("Apply() error = nil, want the refused R gain")
+	}
+	if f.s.Gain != 6 {
+		t.Errorf("gain = %v after Apply(), want 6 despite the refused R gain", f.s.Gain)
+	}
+}
@@ -0,0 +1,248 @@
+package panasonic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+)
+
+// presetLibraryVersion is the format version of preset library files.
+const presetLibraryVersion = 1
+
+const (
+	presetSettle  = 500 * time.Millisecond
+	presetTimeout = 15 * time.Second
+)
+
+// PresetLibrary is the portable form of the presets stored in a camera.
+type PresetLibrary struct {
+	Version int              `json:"version"`
+	Title   string           `json:"title,omitempty"`
+	Model   string           `json:"model,omitempty"`
+	Presets []PresetPosition `json:"presets"`
+}
+
+// PresetPosition is the lens and head position stored in a preset.
+type PresetPosition struct {
+	Preset Preset    `json:"preset"`
+	Pan    MoveUnit  `json:"pan"`
+	Tilt   MoveUnit  `json:"tilt"`
+	Zoom   ScaleUnit `json:"zoom"`
+	Focus  ScaleUnit `json:"focus"`
+	Iris   ScaleUnit `json:"iris"`
+}
+
+// ReadPresetLibrary parses a preset library file.
+func ReadPresetLibrary(r io.Reader) (PresetLibrary, error) {
+	var l PresetLibrary
+	if err := json.NewDecoder(r).Decode(&l); err != nil {
+		return PresetLibrary{}, fmt.Errorf("broadcastkit/panasonic: preset library: %w", err)
+	}
+	if l.Version == 0 || l.Version > presetLibraryVersion {
+		return PresetLibrary{}, fmt.Errorf("broadcastkit/panasonic: unsupported preset library version: %d", l.Version)
+	}
+	return l, nil
+}
+
+// Write writes the library as a preset library file.
+func (l PresetLibrary) Write(w io.Writer) error {
+	l.Version = presetLibraryVersion
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(l)
+}
+
+// PresetManager exports the presets of a camera into a PresetLibrary and
+// imports them into the same or another camera.
+//
+// Presets can only be read by recalling them, so Export moves the camera
+// through every stored preset, and Import moves it through every imported
+// one. Neither should be used on a camera which is on air.
+type PresetManager struct {
+	Camera *CameraClient
+	// User is the account used to set the camera title, the factory default
+	// account if nil.
+	User *url.Userinfo
+	// Settle is the interval of position queries while waiting for the
+	// camera to stop moving, 500ms by default.
+	Settle time.Duration
+	// Timeout is the longest wait for the camera to stop moving, 15 seconds
+	// by default.
+	Timeout time.Duration
+}
+
+// Export reads every stored preset of the camera. The camera is returned to
+// its original position afterwards, also when reading a preset fails or ctx
+// is cancelled.
+func (m *PresetManager) Export(ctx context.Context) (lib PresetLibrary, err error) {
+	lib = PresetLibrary{Version: presetLibraryVersion}
+	stored, err := m.stored()
+	if err != nil {
+		return lib, err
+	}
+	if lib.Title, err = m.Camera.GetTitle(); err != nil {
+		return lib, err
+	}
+	if res, err := m.Camera.AWCommand(AWModelNameQuery{}); err == nil {
+		if r, ok := res.(AWModelName); ok {
+			lib.Model = r.ModelName
+		}
+	}
+
+	home, err := m.position()
+	if err != nil {
+		return lib, fmt.Errorf("broadcastkit/panasonic: original position: %w", err)
+	}
+	defer func() {
+		if herr := m.moveTo(home); herr != nil && err == nil {
+			err = herr
+		}
+	}()
+	for p := range Preset(100) {
+		if !stored.Has(uint8(p)) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return lib, fmt.Errorf("broadcastkit/panasonic: preset %d: %w", p, err)
+		}
+		if _, err := m.Camera.AWCommand(AWPresetRecall{Preset: p}); err != nil {
+			return lib, fmt.Errorf("broadcastkit/panasonic: recall preset %d: %w", p, err)
+		}
+		pos, err := m.settle(ctx)
+		if err != nil {
+			return lib, fmt.Errorf("broadcastkit/panasonic: preset %d: %w", p, err)
+		}
+		pos.Preset = p
+		lib.Presets = append(lib.Presets, pos)
+	}
+	return lib, nil
+}
+
+// Import stores the presets of lib in the camera and sets the camera title.
+// Presets of the camera which are not in lib are kept. When ctx is cancelled,
+// the presets imported so far are kept.
+//
+// Cameras in auto focus or auto iris mode refuse the stored focus or iris,
+// those presets keep the focus or iris of the camera.
+func (m *PresetManager) Import(ctx context.Context, lib PresetLibrary) error {
+	for _, pos := range lib.Presets {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("broadcastkit/panasonic: preset %d: %w", pos.Preset, err)
+		}
+		if err := m.moveTo(pos); err != nil {
+			return fmt.Errorf("broadcastkit/panasonic: preset %d: %w", pos.Preset, err)
+		}
+		if _, err := m.settle(ctx); err != nil {
+			return fmt.Errorf("broadcastkit/panasonic: preset %d: %w", pos.Preset, err)
+		}
+		if _, err := m.Camera.AWCommand(AWPresetRegister{Preset: pos.Preset}); err != nil {
+			return fmt.Errorf("broadcastkit/panasonic: register preset %d: %w", pos.Preset, err)
+		}
+	}
+	if lib.Title != "" {
+		return m.Camera.SetTitle(lib.Title, m.User)
+	}
+	return nil
+}
+
+// stored returns the bits of the stored presets.
+func (m *PresetManager) stored() (Bits128, error) {
+	var bits Bits128
+	for offset := range 3 {
+		res, err := m.Camera.AWCommand(AWPresetEntriesQuery{Offset: offset})
+		if err != nil {
+			return bits, err
+		}
+		switch r := res.(type) {
+		case AWPresetEntries00:
+			bits = bits.Union(r.PresetBits())
+		case AWPresetEntries01:
+			bits = bits.Union(r.PresetBits())
+		case AWPresetEntries02:
+			bits = bits.Union(r.PresetBits())
+		default:
+			return bits, fmt.Errorf("broadcastkit/panasonic: unexpected response to preset entries query: %s", res.packResponse())
+		}
+	}
+	return bits, nil
+}
+
+// moveTo commands the camera to a position.
+func (m *PresetManager) moveTo(pos PresetPosition) error {
+	if _, err := m.Camera.AWCommand(AWPanTiltTo{Pan: pos.Pan, Tilt: pos.Tilt}); err != nil {
+		return err
+	}
+	if _, err := m.Camera.AWCommand(AWZoomTo{Zoom: pos.Zoom}); err != nil {
+		return err
+	}
+	// Lenses in auto focus or auto iris mode refuse the position.
+	for _, req := range []AWRequest{AWFocusTo{Focus: pos.Focus}, AWIrisTo{Iris: pos.Iris}} {
+		var awErr AWError
+		if _, err := m.Camera.AWCommand(req); err != nil && !errors.As(err, &awErr) {
+			return err
+		}
+	}
+	return nil
+}
+
+// position queries the current position of the camera.
+func (m *PresetManager) position() (PresetPosition, error) {
+	var pos PresetPosition
+	for _, req := range []AWRequest{AWPanTiltQuery{}, AWZoomQuery{}, AWFocusQuery{}, AWIrisQuery{}} {
+		res, err := m.Camera.AWCommand(req)
+		if err != nil {
+			return pos, err
+		}
+		switch r := res.(type) {
+		case AWPanTiltTo:
+			pos.Pan, pos.Tilt = r.Pan, r.Tilt
+		case AWZoomTo:
+			pos.Zoom = r.Zoom
+		case AWFocusTo:
+			pos.Focus = r.Focus
+		case AWIrisTo:
+			pos.Iris = r.Iris
+		default:
+			return pos, fmt.Errorf("unexpected response to position query: %s", res.packResponse())
+		}
+	}
+	return pos, nil
+}
+
+// settle waits until two position queries in a row agree and returns the
+// position.
+func (m *PresetManager) settle(ctx context.Context) (PresetPosition, error) {
+	interval := m.Settle
+	if interval == 0 {
+		interval = presetSettle
+	}
+	timeout := m.Timeout
+	if timeout == 0 {
+		timeout = presetTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	last, err := m.position()
+	if err != nil {
+		return last, err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return last, fmt.Errorf("camera did not stop moving: %w", ctx.Err())
+		case <-time.After(interval):
+		}
+		pos, err := m.position()
+		if err != nil {
+			return pos, err
+		}
+		if pos == last {
+			return pos, nil
+		}
+		last = pos
+	}
+}
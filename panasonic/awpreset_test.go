@@ -0,0 +1,215 @@
+package panasonic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeHead is a camera storing presets, moving instantly.
+type fakeHead struct {
+	lock      sync.Mutex
+	pos       PresetPosition
+	presets   map[Preset]PresetPosition
+	title     string
+	autoFocus bool
+	// refused are stored presets the camera fails to recall.
+	refused map[Preset]bool
+	recalls int
+}
+
+func (f *fakeHead) AWCommand(req AWRequest) (AWResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	switch r := req.(type) {
+	case AWPanTiltTo:
+		f.pos.Pan, f.pos.Tilt = r.Pan, r.Tilt
+	case AWZoomTo:
+		f.pos.Zoom = r.Zoom
+	case AWFocusTo:
+		if f.autoFocus {
+			return nil, NewAWError(AWErrBusy, req)
+		}
+		f.pos.Focus = r.Focus
+	case AWIrisTo:
+		f.pos.Iris = r.Iris
+	case AWPanTiltQuery:
+		return AWPanTiltTo{Pan: f.pos.Pan, Tilt: f.pos.Tilt}, nil
+	case AWZoomQuery:
+		return AWZoomTo{Zoom: f.pos.Zoom}, nil
+	case AWFocusQuery:
+		return AWFocusTo{Focus: f.pos.Focus}, nil
+	case AWIrisQuery:
+		return AWIrisTo{Iris: f.pos.Iris}, nil
+	case AWPresetRecall:
+		f.recalls++
+		pos, ok := f.presets[r.Preset]
+		if !ok || f.refused[r.Preset] {
+			return nil, NewAWError(AWErrUnacceptable, req)
+		}
+		f.pos = pos
+	case AWPresetRegister:
+		pos := f.pos
+		pos.Preset = r.Preset
+		f.presets[r.Preset] = pos
+	case AWPresetEntriesQuery:
+		var bits Bits128
+		for p := range f.presets {
+			bits = bits.Set(uint8(p))
+		}
+		e0, e1, e2 := AWPresetEntries(bits)
+		return []AWResponse{e0, e1, e2}[r.Offset], nil
+	case AWModelNameQuery:
+		return AWModelName{ModelName: "AW-UE150"}, nil
+	}
+	return req.Response(), nil
+}
+
+func (f *fakeHead) AWBatch() ([]AWResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return []AWResponse{AWTitle{Title: f.title}}, nil
+}
+
+// serve serves the fake camera, including the title setting page.
+func (f *fakeHead) serve(t *testing.T) *CameraClient {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.Handle("/", &CameraServer{AWHandler: f})
+	mux.HandleFunc("/cgi-bin/set_basic", func(w http.ResponseWriter, r *http.Request) {
+		title := r.URL.Query().Get("cam_title")
+		f.lock.Lock()
+		f.title = title
+		f.lock.Unlock()
+		w.Write([]byte("cam_title=" + title + "\r\n"))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return &CameraClient{Remote: netip.MustParseAddrPort(srv.Listener.Addr().String())}
+}
+
+func TestPresetManager(t *testing.T) {
+	home := PresetPosition{Pan: 10, Tilt: -10, Zoom: 100, Focus: 200, Iris: 300}
+	src := &fakeHead{
+		pos:   home,
+		title: "Stage Left",
+		presets: map[Preset]PresetPosition{
+			0:  {Preset: 0, Pan: 1000, Tilt: 200, Zoom: 1500, Focus: 800, Iris: 1200},
+			41: {Preset: 41, Pan: -3000, Tilt: -100, Zoom: 0, Focus: 2730, Iris: 600},
+			99: {Preset: 99, Pan: 5, Tilt: 5, Zoom: 5, Focus: 5, Iris: 5},
+		},
+	}
+	m := &PresetManager{Camera: src.serve(t), Settle: time.Millisecond}
+	lib, err := m.Export(context.Background())
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	want := PresetLibrary{
+		Version: presetLibraryVersion,
+		Title:   "Stage Left",
+		Model:   "AW-UE150",
+		Presets: []PresetPosition{src.presets[0], src.presets[41], src.presets[99]},
+	}
+	if !reflect.DeepEqual(lib, want) {
+		t.Errorf("Export() = %+v, want %+v", lib, want)
+	}
+	if got := src.pos; got.Pan != home.Pan || got.Tilt != home.Tilt || got.Zoom != home.Zoom || got.Focus != home.Focus || got.Iris != home.Iris {
+		t.Errorf("position after Export() = %+v, want %+v", got, home)
+	}
+
+	var buf bytes.Buffer
+	if err := lib.Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	read, err := ReadPresetLibrary(&buf)
+	if err != nil {
+		t.Fatalf("ReadPresetLibrary() error = %v", err)
+	}
+	if !reflect.DeepEqual(read, lib) {
+		t.Errorf("ReadPresetLibrary() = %+v, want %+v", read, lib)
+	}
+
+	dst := &fakeHead{presets: map[Preset]PresetPosition{7: {Preset: 7, Pan: 77}}, autoFocus: true}
+	m = &PresetManager{Camera: dst.serve(t), Settle: time.Millisecond}
+	if err := m.Import(context.Background(), read); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if dst.title != "Stage Left" {
+		t.Errorf("title after Import() = %q, want %q", dst.title, "Stage Left")
+	}
+	for _, p := range lib.Presets {
+		got := dst.presets[p.Preset]
+		p.Focus = 0 // refused in auto focus
+		if got != p {
+			t.Errorf("preset %d after Import() = %+v, want %+v", p.Preset, got, p)
+		}
+	}
+	if _, ok := dst.presets[7]; !ok {
+		t.Error("Import() removed a preset not in the library")
+	}
+}
+
+func TestReadPresetLibrary_Version(t *testing.T) {
+	for _, s := range []string{`{"presets": []}`, `{"version": 2, "presets": []}`, `[`} {
+		if _, err := ReadPresetLibrary(bytes.NewBufferString(s)); err == nil {
+			t.Errorf("ReadPresetLibrary(%s) error = nil, want error", s)
+		}
+	}
+}
+
+func TestPresetManager_ExportFailure(t *testing.T) {
+	home := PresetPosition{Pan: 10, Tilt: -10, Zoom: 100}
+	f := &fakeHead{
+		pos: home,
+		presets: map[Preset]PresetPosition{
+			0: {Preset: 0, Pan: 1000, Zoom: 1500},
+			1: {Preset: 1, Pan: -1000, Zoom: 50},
+		},
+		refused: map[Preset]bool{1: true},
+	}
+	m := &PresetManager{Camera: f.serve(t), Settle: time.Millisecond}
+	if _, err := m.Export(context.Background()); err == nil {
+		t.Fatal("Export() error = nil, want the recall error")
+	}
+	if got := f.pos; got.Pan != home.Pan || got.Tilt != home.Tilt || got.Zoom != home.Zoom {
+		t.Errorf("position after failed Export() = %+v, want %+v", got, home)
+	}
+}
+
+func TestPresetManager_Cancel(t *testing.T) {
+	home := PresetPosition{Pan: 10, Tilt: -10, Zoom: 100}
+	f := &fakeHead{
+		pos: home,
+		presets: map[Preset]PresetPosition{
+			0: {Preset: 0, Pan: 1000, Zoom: 1500},
+			1: {Preset: 1, Pan: -1000, Zoom: 50},
+		},
+	}
+	m := &PresetManager{Camera: f.serve(t), Settle: time.Millisecond}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := m.Export(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Export() error = %v, want %v", err, context.Canceled)
+	}
+	if f.recalls != 0 {
+		t.Errorf("Export() recalled %d presets after cancel, want 0", f.recalls)
+	}
+	if got := f.pos; got.Pan != home.Pan || got.Tilt != home.Tilt || got.Zoom != home.Zoom {
+		t.Errorf("position after cancelled Export() = %+v, want %+v", got, home)
+	}
+
+	lib := PresetLibrary{Presets: []PresetPosition{{Preset: 5, Pan: 500}}}
+	if err := m.Import(ctx, lib); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Import() error = %v, want %v", err, context.Canceled)
+	}
+	if _, ok := f.presets[5]; ok {
+		t.Error("Import() stored a preset after cancel")
+	}
+}
@@ -0,0 +1,205 @@
+package panasonic
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ModelProfile describes the capabilities of a camera model: the commands it
+// supports, the values it accepts, the video formats and the protocol quirks.
+//
+// Profiles of known models are found with LookupProfile. Set a profile on a
+// CameraClient in Strict mode to refuse requests the camera would refuse, or
+// on a CameraServer to emulate the model. UIs can use Supports to hide the
+// controls of missing features.
+type ModelProfile struct {
+	// Model is the model name reported by AWModelName, like AW-UE150.
+	Model string
+	// Unsupported are the requests the model refuses with AWErrUnsupported,
+	// given as zero values like AWWiperControlBasic{}.
+	Unsupported []AWRequest
+	// Formats are the video formats of the model, any acceptable Format if
+	// empty. They restrict both AWFormat and AWSDIFormat.
+	Formats []Format
+	// Accept, if set, restricts the values of requests beyond their
+	// Acceptable method, like a lower maximum gain. It is only called with
+	// acceptable requests.
+	Accept func(AWRequest) bool
+	// NoBatch is set for models without the /live/camdata.html page. AWBatch
+	// fails on them like on a missing page.
+	NoBatch bool
+}
+
+// Supports reports whether the model supports req. A nil profile supports
+// every request.
+func (p *ModelProfile) Supports(req AWRequest) bool {
+	return p == nil || !sameType(req, p.Unsupported)
+}
+
+// SupportsFormat reports whether the model has the video format f.
+func (p *ModelProfile) SupportsFormat(f Format) bool {
+	if p == nil || len(p.Formats) == 0 {
+		return f.Acceptable()
+	}
+	return slices.Contains(p.Formats, f)
+}
+
+// accepts reports whether the model accepts the values of req.
+func (p *ModelProfile) accepts(req AWRequest) bool {
+	if !req.Acceptable() {
+		return false
+	}
+	if p == nil {
+		return true
+	}
+	switch r := req.(type) {
+	case AWFormat:
+		if !p.SupportsFormat(r.Format) {
+			return false
+		}
+	case AWSDIFormat:
+		if !p.SupportsFormat(r.Format) {
+			return false
+		}
+	}
+	return p.Accept == nil || p.Accept(req)
+}
+
+// Validate returns the AWError a camera of the profile answers req with, if
+// req is unsupported or its values are not accepted. Otherwise it returns
+// nil. A nil profile only checks Acceptable.
+func (p *ModelProfile) Validate(req AWRequest) error {
+	if !p.Supports(req) {
+		return NewAWError(AWErrUnsupported, req)
+	}
+	if !p.accepts(req) {
+		return NewAWError(AWErrUnacceptable, req)
+	}
+	return nil
+}
+
+// batchError returns the error of AWBatch on the model, if it has no batch.
+func (p *ModelProfile) batchError() error {
+	if p == nil || !p.NoBatch {
+		return nil
+	}
+	return &SystemError{&statusError{http.StatusNotFound, http.StatusOK}}
+}
+
+// hdFormats are the acceptable formats up to 1080 lines.
+func hdFormats() []Format {
+	var hd []Format
+	for _, f := range validFormats {
+		if f < F2160p29 || (f > F2160p60 && f != F2160p24) {
+			hd = append(hd, f)
+		}
+	}
+	return hd
+}
+
+// Model dependent commands, with their queries, which profiles list as
+// unsupported.
+var (
+	wiper = []AWRequest{
+		AWWiperControl{},
+		AWWiperControlBasic{},
+	}
+	pinP = []AWRequest{
+		AWPinPDisplayPos{},
+		AWPinPDisplayPosQuery{},
+	}
+	digitalExtender = []AWRequest{
+		AWDigitalExtender{},
+		AWDigitalExtenderQuery{},
+	}
+	iZoom = []AWRequest{
+		AWiZoom{},
+		AWiZoomQuery{},
+	}
+	stabilization = []AWRequest{
+		AWImageStabilization{},
+		AWImageStabilizationQuery{},
+	}
+)
+
+// maxGain accepts the gains up to max dB and the auto gain.
+func maxGain(max Decibel) func(AWRequest) bool {
+	return func(req AWRequest) bool {
+		r, ok := req.(AWGain)
+		return !ok || r.Gain == DecibelAuto || r.Gain <= max
+	}
+}
+
+var (
+	profileLock sync.RWMutex
+	profiles    = map[string]*ModelProfile{
+		"AW-UE150": {
+			Model:       "AW-UE150",
+			Unsupported: wiper,
+			Accept:      maxGain(36),
+		},
+		"AW-UE70": {
+			Model:       "AW-UE70",
+			Unsupported: slices.Concat(wiper, pinP, digitalExtender),
+			Accept:      maxGain(36),
+		},
+		"AW-HE40": {
+			Model:       "AW-HE40",
+			Unsupported: slices.Concat(wiper, pinP, digitalExtender, stabilization),
+			Formats:     hdFormats(),
+			Accept:      maxGain(18),
+		},
+		"AW-HR140": {
+			Model:       "AW-HR140",
+			Unsupported: slices.Concat(pinP, digitalExtender, iZoom),
+			Formats:     hdFormats(),
+			Accept:      maxGain(30),
+		},
+	}
+)
+
+// LookupProfile returns the profile of a model, as named by AWModelName.
+// Both "AW-UE150" and "UE150" find the same profile.
+func LookupProfile(model string) (*ModelProfile, bool) {
+	profileLock.RLock()
+	defer profileLock.RUnlock()
+	p, ok := profiles[profileKey(model)]
+	return p, ok
+}
+
+// RegisterProfile adds a profile, or replaces the profile of the same model.
+// Profiles must not be modified after they are registered.
+func RegisterProfile(p *ModelProfile) {
+	profileLock.Lock()
+	defer profileLock.Unlock()
+	profiles[profileKey(p.Model)] = p
+}
+
+// profileKey normalizes a model name to its AW- prefixed form.
+func profileKey(model string) string {
+	if !strings.HasPrefix(model, "AW-") {
+		return "AW-" + model
+	}
+	return model
+}
+
+// DetectProfile queries the model of the camera and returns its profile.
+// Unknown models get an empty profile with only the model name set, which
+// supports everything.
+func (c *CameraClient) DetectProfile() (*ModelProfile, error) {
+	res, err := c.AWCommand(AWModelNameQuery{})
+	if err != nil {
+		return nil, err
+	}
+	r, ok := res.(AWModelName)
+	if !ok {
+		return nil, &SystemError{errors.New("unexpected response to model name query: " + res.packResponse())}
+	}
+	if p, ok := LookupProfile(r.ModelName); ok {
+		return p, nil
+	}
+	return &ModelProfile{Model: r.ModelName}, nil
+}
@@ -0,0 +1,182 @@
+package panasonic
+
+import (
+	"errors"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestModelProfile_Validate(t *testing.T) {
+	lowGain := &ModelProfile{
+		Model:   "AW-TEST",
+		Formats: []Format{F1080p50, F1080i50},
+		Accept: func(req AWRequest) bool {
+			r, ok := req.(AWGain)
+			return !ok || r.Gain <= 36
+		},
+	}
+	tests := []struct {
+		name    string
+		profile *ModelProfile
+		req     AWRequest
+		want    AWErrNo
+	}{
+		{"acceptable", wiperless, AWGain{Gain: 12}, 0},
+		{"unacceptable", wiperless, AWGain{Gain: 60}, AWErrUnacceptable},
+		{"unsupported", wiperless, AWWiperControlBasic{Enabled: On}, AWErrUnsupported},
+		{"unsupported and unacceptable", wiperless, AWWiperControlBasic{Enabled: 7}, AWErrUnsupported},
+		{"nil profile", nil, AWWiperControlBasic{Enabled: On}, 0},
+		{"nil profile unacceptable", nil, AWPedestal{Pedestal: 99}, AWErrUnacceptable},
+		{"unknown request", wiperless, AWUnknownRequest{text: "XYZ:1"}, 0},
+		{"format", lowGain, AWFormat{Format: F1080p50}, 0},
+		{"missing format", lowGain, AWFormat{Format: F2160p50}, AWErrUnacceptable},
+		{"missing SDI format", lowGain, AWSDIFormat{Format: F720p50}, AWErrUnacceptable},
+		{"accepted", lowGain, AWGain{Gain: 36}, 0},
+		{"not accepted", lowGain, AWGain{Gain: 42}, AWErrUnacceptable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.profile.Validate(tt.req)
+			var awErr AWError
+			switch {
+			case tt.want == 0 && err != nil:
+				t.Errorf("Validate() error = %v, want nil", err)
+			case tt.want != 0 && !errors.As(err, &awErr):
+				t.Errorf("Validate() error = %v, want an AWError", err)
+			case tt.want != 0 && awErr.No != tt.want:
+				t.Errorf("Validate() error number = %v, want %v", awErr.No, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupProfile(t *testing.T) {
+	tests := []struct {
+		model  string
+		want   string
+		wantOk bool
+	}{
+		{"AW-UE150", "AW-UE150", true},
+		{"UE150", "AW-UE150", true},
+		{"AW-HR140", "AW-HR140", true},
+		{"AW-XX1", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			p, ok := LookupProfile(tt.model)
+			if ok != tt.wantOk {
+				t.Fatalf("LookupProfile() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && p.Model != tt.want {
+				t.Errorf("LookupProfile() model = %v, want %v", p.Model, tt.want)
+			}
+		})
+	}
+
+	he40, _ := LookupProfile("AW-HE40")
+	if he40.SupportsFormat(F2160p50) || !he40.SupportsFormat(F1080p50) {
+		t.Errorf("AW-HE40 formats = %v, want HD only", he40.Formats)
+	}
+	hr140, _ := LookupProfile("AW-HR140")
+	if !hr140.Supports(AWWiperControl{}) || he40.Supports(AWWiperControl{}) {
+		t.Errorf("only the AW-HR140 should support wipers")
+	}
+	ue150, _ := LookupProfile("AW-UE150")
+	if !ue150.Supports(AWDigitalExtenderQuery{}) || he40.Supports(AWDigitalExtender{}) {
+		t.Errorf("only the AW-UE150 should support the digital extender")
+	}
+	if hr140.Supports(AWiZoom{}) || he40.Supports(AWImageStabilization{}) {
+		t.Errorf("AW-HR140 iZoom or AW-HE40 stabilization supported")
+	}
+	if err := he40.Validate(AWGain{Gain: 24}); err == nil {
+		t.Errorf("AW-HE40 Validate(AWGain{24}) = nil, want an error")
+	}
+	if err := he40.Validate(AWGain{Gain: DecibelAuto}); err != nil {
+		t.Errorf("AW-HE40 Validate(AWGain{auto}) = %v, want nil", err)
+	}
+}
+
+func TestRegisterProfile(t *testing.T) {
+	profileLock.Lock()
+	saved := maps.Clone(profiles)
+	profileLock.Unlock()
+	t.Cleanup(func() {
+		profileLock.Lock()
+		profiles = saved
+		profileLock.Unlock()
+	})
+
+	RegisterProfile(&ModelProfile{Model: "XX9", NoBatch: true})
+	p, ok := LookupProfile("AW-XX9")
+	if !ok || !p.NoBatch {
+		t.Errorf("LookupProfile() = %v, %v, want the registered profile", p, ok)
+	}
+}
+
+func TestCameraServer_Profile(t *testing.T) {
+	h := &countingHandler{}
+	profile := &ModelProfile{Model: "AW-TEST", Unsupported: wiper, NoBatch: true}
+	srv := httptest.NewServer(&CameraServer{AWHandler: h, Profile: profile})
+	defer srv.Close()
+	cam := &CameraClient{Remote: netip.MustParseAddrPort(srv.Listener.Addr().String())}
+
+	if _, err := cam.AWCommand(AWWiperControl{Speed: 1}); !errors.As(err, &AWError{}) {
+		t.Errorf("AWCommand(unsupported) error = %v, want an AWError", err)
+	}
+	if _, err := cam.AWCommand(AWGain{Gain: 6}); err != nil {
+		t.Errorf("AWCommand() error = %v", err)
+	}
+	if n := h.count(); n != 1 {
+		t.Errorf("handler received %d requests, want 1", n)
+	}
+
+	var status *statusError
+	if _, err := cam.AWBatch(); !errors.As(err, &status) || status.got != http.StatusNotFound {
+		t.Errorf("AWBatch() error = %v, want a 404 status", err)
+	}
+	// A strict client knows the batch is missing without asking.
+	srv.Close()
+	cam.Strict, cam.Profile = true, profile
+	if _, err := cam.AWBatch(); !errors.As(err, &status) || status.got != http.StatusNotFound {
+		t.Errorf("AWBatch() in Strict mode error = %v, want a 404 status", err)
+	}
+}
+
+func TestCameraClient_DetectProfile(t *testing.T) {
+	tests := []struct {
+		model string
+		want  *ModelProfile
+	}{
+		{"AW-UE70", profiles["AW-UE70"]},
+		{"AW-NEW1", &ModelProfile{Model: "AW-NEW1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			srv := httptest.NewServer(&CameraServer{AWHandler: modelHandler(tt.model)})
+			defer srv.Close()
+			cam := &CameraClient{Remote: netip.MustParseAddrPort(srv.Listener.Addr().String())}
+			got, err := cam.DetectProfile()
+			if err != nil {
+				t.Fatalf("DetectProfile() error = %v", err)
+			}
+			if got.Model != tt.want.Model || len(got.Unsupported) != len(tt.want.Unsupported) {
+				t.Errorf("DetectProfile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// modelHandler answers the model name query with its model.
+type modelHandler string
+
+func (m modelHandler) AWCommand(req AWRequest) (AWResponse, error) {
+	if _, ok := req.(AWModelNameQuery); ok {
+		return AWModelName{ModelName: string(m)}, nil
+	}
+	return nil, NewAWError(AWErrUnsupported, req)
+}
+
+func (m modelHandler) AWBatch() ([]AWResponse, error) { return nil, nil }
@@ -6,14 +6,20 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"math/rand/v2"
 	"net"
 	"net/http"
 	"net/netip"
 	"net/url"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
+
+	"puzzlekraken.com/broadcastkit/internal/wirelog"
+	"puzzlekraken.com/broadcastkit/metrics"
 )
 
 // CameraClient represent a remote camera to be controlled via the AW protocol
@@ -27,7 +33,19 @@ type CameraClient struct {
 	// before the first request is sent.
 	// CheckRedirects is set func(...) error { return http.ErrUseLastResponse }
 	// Timeout is set to 5 seconds
-	Http     http.Client
+	Http http.Client
+	// Logger, if set, receives the HTTP traffic at debug level. It is also
+	// used by the notification listeners of the camera.
+	Logger *slog.Logger
+	// Metrics, if set, receives the latency and errors of commands, and the
+	// notifications received by the listeners of the camera.
+	Metrics metrics.Recorder
+	// Strict, if set, answers the requests failing Profile.Validate locally
+	// with their AWError, without sending them to the camera.
+	Strict bool
+	// Profile describes the camera model. It is only used in Strict mode,
+	// where AWBatch also fails without a request on models with NoBatch.
+	Profile  *ModelProfile
 	httpOnce sync.Once     // track http initialization
 	dummyCtr atomic.Uint64 // source for dummy cache-disabling numbers
 }
@@ -42,6 +60,7 @@ func (c *CameraClient) httpInit() {
 	if c.Http.Timeout == 0 {
 		c.Http.Timeout = networkTimeout
 	}
+	c.Http.Transport = wirelog.Transport(c.Http.Transport, c.Logger)
 	c.dummyCtr.Store(rand.Uint64())
 }
 
@@ -109,7 +128,7 @@ func (c *CameraClient) strCommand(cmd string) (string, error) {
 	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("http status code: %d (expected %d)", res.StatusCode, http.StatusOK)
+		return "", &statusError{res.StatusCode, http.StatusOK}
 	}
 
 	b, err := io.ReadAll(res.Body)
@@ -120,10 +139,35 @@ func (c *CameraClient) strCommand(cmd string) (string, error) {
 	return string(trim(b)), nil
 }
 
+// observe reports a command started at start and failed with *err to Metrics.
+func (c *CameraClient) observe(command string, start time.Time, err *error) {
+	if c.Metrics != nil {
+		c.Metrics.Command(c.Remote.String(), command, time.Since(start), errorKind(*err))
+	}
+}
+
+// observeRequest reports req like observe, naming it only if Metrics is set.
+func (c *CameraClient) observeRequest(req AWRequest, start time.Time, err *error) {
+	if c.Metrics != nil {
+		c.observe(commandName(req), start, err)
+	}
+}
+
+// commandName names a request for metrics by its type, like AWPanTilt.
+func commandName(req AWRequest) string {
+	return strings.TrimPrefix(strings.TrimPrefix(fmt.Sprintf("%T", req), "*"), "panasonic.")
+}
+
 // AWCommand sends the passed AWRequest to the camera
 //
 // AW protocol error responses are returned as errors, not AWResponse objects.
-func (c *CameraClient) AWCommand(req AWRequest) (AWResponse, error) {
+func (c *CameraClient) AWCommand(req AWRequest) (_ AWResponse, err error) {
+	defer c.observeRequest(req, time.Now(), &err)
+	if c.Strict {
+		if err := c.Profile.Validate(req); err != nil {
+			return nil, err
+		}
+	}
 	cmd := req.packRequest()
 
 	ret, err := c.strCommand(cmd)
@@ -145,14 +189,20 @@ func (c *CameraClient) AWCommand(req AWRequest) (AWResponse, error) {
 }
 
 // AWBatch returns the command responses available at the camdata.html page.
-func (c *CameraClient) AWBatch() ([]AWResponse, error) {
+func (c *CameraClient) AWBatch() (_ []AWResponse, err error) {
+	defer c.observe("camdata", time.Now(), &err)
+	if c.Strict {
+		if err := c.Profile.batchError(); err != nil {
+			return nil, err
+		}
+	}
 	data, err := c.httpGet("/live/camdata.html", "", nil)
 	if err != nil {
 		return nil, &SystemError{err}
 	}
 	defer data.Body.Close()
 	if data.StatusCode != http.StatusOK {
-		return nil, &SystemError{fmt.Errorf("http status code: %d (expected %d)", data.StatusCode, http.StatusOK)}
+		return nil, &SystemError{&statusError{data.StatusCode, http.StatusOK}}
 	}
 	scan := bufio.NewScanner(data.Body)
 	res := make([]AWResponse, 0)
@@ -169,7 +219,8 @@ func (c *CameraClient) AWBatch() ([]AWResponse, error) {
 //
 // You can specify an image width in pixels as resolution, which may be honored
 // on a best-effort basis. The returned image size may be different.
-func (c *CameraClient) Screenshot(resolution int) ([]byte, error) {
+func (c *CameraClient) Screenshot(resolution int) (_ []byte, err error) {
+	defer c.observe("screenshot", time.Now(), &err)
 	// The page value is defined by the documentation to defeat caches. Probably not necessary in practice.
 	// httpInit is called here to ensure dummyCtr is initialized to a pseudo-random value.
 	c.httpOnce.Do(c.httpInit)
@@ -182,7 +233,7 @@ func (c *CameraClient) Screenshot(resolution int) ([]byte, error) {
 	defer data.Body.Close()
 
 	if data.StatusCode != http.StatusOK {
-		return nil, &SystemError{fmt.Errorf("http status code: %d (expected %d)", data.StatusCode, http.StatusOK)}
+		return nil, &SystemError{&statusError{data.StatusCode, http.StatusOK}}
 	}
 
 	if ct := data.Header.Get("Content-Type"); ct != "image/jpeg" {
@@ -213,7 +264,8 @@ func (c *CameraClient) GetTitle() (string, error) {
 
 var defaultUserPassword = url.UserPassword("admin", "12345")
 
-func (c *CameraClient) SetTitle(title string, user *url.Userinfo) error {
+func (c *CameraClient) SetTitle(title string, user *url.Userinfo) (err error) {
+	defer c.observe("set_basic", time.Now(), &err)
 	if user == nil {
 		user = defaultUserPassword
 	}
@@ -223,14 +275,14 @@ func (c *CameraClient) SetTitle(title string, user *url.Userinfo) error {
 	}
 	defer res.Body.Close()
 	if res.StatusCode != http.StatusOK {
-		return &SystemError{fmt.Errorf("http status code: %d (expected %d)", res.StatusCode, http.StatusOK)}
+		return &SystemError{&statusError{res.StatusCode, http.StatusOK}}
 	}
 	compBuf := make([]byte, 10+len(title)+2)
 	copy(compBuf, "cam_title=")
 	copy(compBuf[10:], title)
 	copy(compBuf[10+len(title):], "\r\n")
 	checkBuf := make([]byte, len(compBuf))
-	_, err = res.Body.Read(checkBuf)
+	_, err = io.ReadFull(res.Body, checkBuf)
 	if err != nil {
 		return &SystemError{err}
 	}
@@ -266,6 +318,22 @@ type AWHandlerCtx interface {
 	AWBatchCtx(context.Context) ([]AWResponse, error)
 }
 
+// awCommand passes req to h, with ctx if h is an AWHandlerCtx.
+func awCommand(ctx context.Context, h AWHandler, req AWRequest) (AWResponse, error) {
+	if ctxhandler, ok := h.(AWHandlerCtx); ok {
+		return ctxhandler.AWCommandCtx(ctx, req)
+	}
+	return h.AWCommand(req)
+}
+
+// awBatch requests the batch from h, with ctx if h is an AWHandlerCtx.
+func awBatch(ctx context.Context, h AWHandler) ([]AWResponse, error) {
+	if ctxhandler, ok := h.(AWHandlerCtx); ok {
+		return ctxhandler.AWBatchCtx(ctx)
+	}
+	return h.AWBatch()
+}
+
 // CameraServer is an http.Handler that implements an endpoint for AW protocol.
 //
 // This can be used to receive AW protocol commands from a remote panel acting
@@ -275,6 +343,7 @@ type AWHandlerCtx interface {
 // - /cgi-bin/event
 // - /cgi-bin/man_session
 // - /live/camdata.html
+// - /cgi-bin/get_preset_thumbnail
 //
 // The user should provide the AWHandler which will be called to handle received
 // requests. Camera implements AWHandler.
@@ -282,11 +351,26 @@ type AWHandlerCtx interface {
 // Notification subscribers are maintained automatically, but it is the task of
 // the user to send out notifications. See the NotifyForward function to forward
 // notifications from a Camera.
+//
+// Logger, if set, receives the commands and responses at debug level and the
+// failures of the AWHandler. Panics of the AWHandler are logged to
+// slog.Default when Logger is nil.
+//
+// Thumbnails, if set, provides the preset images served at
+// /cgi-bin/get_preset_thumbnail?preset_number=N, with N counted from 1 like
+// the presets in the web interface of cameras. See ThumbnailStore.Camera.
+//
+// Profile, if set, makes the server behave like the model. Requests failing
+// Profile.Validate are answered with their AWError without calling the
+// AWHandler, and models with NoBatch have no camdata page.
 type CameraServer struct {
-	once      sync.Once
-	mux       http.ServeMux
-	AWHandler AWHandler
-	Notify    NotifyServer
+	once       sync.Once
+	mux        http.ServeMux
+	AWHandler  AWHandler
+	Notify     NotifyServer
+	Logger     *slog.Logger
+	Thumbnails PresetImages
+	Profile    *ModelProfile
 }
 
 // setup initializes the CameraServer
@@ -297,6 +381,7 @@ func (c *CameraServer) setup() {
 	c.mux.HandleFunc("/cgi-bin/event", c.serveEvent)
 	c.mux.HandleFunc("/cgi-bin/man_session", c.serveManSession)
 	c.mux.HandleFunc("/live/camdata.html", c.serveCamData)
+	c.mux.HandleFunc("/cgi-bin/get_preset_thumbnail", c.serveThumbnail)
 }
 
 // ServeHTTP implements the http.Handler interface
@@ -306,8 +391,12 @@ func (c *CameraServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// returning 400/500 for anything wrong with the request and the server
 	// respectively.
 	defer func() {
-		if r := recover(); r != nil {
-			fmt.Printf("panic: %v\n", r)
+		if p := recover(); p != nil {
+			log := c.Logger
+			if log == nil {
+				log = slog.Default()
+			}
+			log.ErrorContext(r.Context(), "panic serving AW request", "url", r.URL.String(), "panic", p)
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
 		}
@@ -353,26 +442,32 @@ func (c *CameraServer) wrapAW(mode quirkMode, w http.ResponseWriter, r *http.Req
 		return
 	}
 	awcmd := newRequest(strcmd)
+	log := wirelog.Or(c.Logger)
+	log.DebugContext(r.Context(), "recv", "data", strcmd)
 	var awres AWResponse
 	var err error
-	if ctxhandler, ok := c.AWHandler.(AWHandlerCtx); ok {
-		awres, err = ctxhandler.AWCommandCtx(r.Context(), awcmd)
-	} else {
-		awres, err = c.AWHandler.AWCommand(awcmd)
+	if c.Profile != nil {
+		err = c.Profile.Validate(awcmd)
+	}
+	if err == nil {
+		awres, err = awCommand(r.Context(), c.AWHandler, awcmd)
 	}
 	if errres, ok := err.(AWError); ok {
 		awres = errres
 		err = nil
 	}
 	if err != nil {
+		log.WarnContext(r.Context(), "AW handler failure", "cmd", strcmd, "err", err)
 		http.Error(w, "Bad Gateway", http.StatusBadGateway)
 		return
 	}
 	if q, ok := awres.(awQuirkedPacking); ok {
 		awres = q.packingQuirk(mode)
 	}
+	packed := awres.packResponse()
+	log.DebugContext(r.Context(), "send", "data", packed)
 	w.Header().Set("Content-Type", "text/plain")
-	w.Write([]byte(awres.packResponse()))
+	w.Write([]byte(packed))
 }
 
 // serveEvent is the /cgi-bin/event endpoint handler
@@ -427,14 +522,13 @@ func (c *CameraServer) serveManSession(w http.ResponseWriter, r *http.Request) {
 
 // serveCamData is the /live/camdata.html endpoint handler
 func (c *CameraServer) serveCamData(w http.ResponseWriter, r *http.Request) {
-	var b []AWResponse
-	var err error
-	if ctxhandler, ok := c.AWHandler.(AWHandlerCtx); ok {
-		b, err = ctxhandler.AWBatchCtx(r.Context())
-	} else {
-		b, err = c.AWHandler.AWBatch()
+	if c.Profile.batchError() != nil {
+		http.NotFound(w, r)
+		return
 	}
+	b, err := awBatch(r.Context(), c.AWHandler)
 	if err != nil {
+		wirelog.Or(c.Logger).WarnContext(r.Context(), "AW handler failure", "cmd", "camdata", "err", err)
 		http.Error(w, "Bad Gateway", http.StatusBadGateway)
 		return
 	}
@@ -449,4 +543,18 @@ func (c *CameraServer) serveCamData(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// serveThumbnail is the /cgi-bin/get_preset_thumbnail endpoint handler
+func (c *CameraServer) serveThumbnail(w http.ResponseWriter, r *http.Request) {
+	if c.Thumbnails == nil {
+		http.NotFound(w, r)
+		return
+	}
+	n, err := strconv.Atoi(r.URL.Query().Get("preset_number"))
+	if err != nil || n < 1 || n > 100 {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	serveThumbnail(w, r, c.Thumbnails, Preset(n-1))
+}
+
 var _ http.Handler = (*CameraServer)(nil)
@@ -0,0 +1,216 @@
+package panasonic
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"puzzlekraken.com/broadcastkit/internal/wirelog"
+)
+
+// Thumbnail is a JPEG still of a preset.
+type Thumbnail struct {
+	JPEG []byte
+	Time time.Time
+}
+
+type thumbKey struct {
+	camera string
+	preset Preset
+}
+
+// ThumbnailStore keeps preset thumbnails by camera name and preset.
+//
+// ThumbnailStore is an http.Handler serving the thumbnails at
+// /<camera>/<preset>.jpg, with presets numbered from 0 as Preset. Mount it
+// with http.StripPrefix to serve it under another path.
+//
+// ThumbnailStore is safe to use from multiple goroutines.
+type ThumbnailStore struct {
+	// Dir, if set, is where thumbnails are also saved as
+	// <camera>/<preset>.jpg, so they survive restarts. Thumbnails not in
+	// memory are loaded from Dir.
+	Dir string
+
+	lock   sync.Mutex
+	thumbs map[thumbKey]Thumbnail
+}
+
+// Put stores the thumbnail of a preset, replacing any earlier one.
+func (s *ThumbnailStore) Put(camera string, p Preset, jpeg []byte) error {
+	t := Thumbnail{JPEG: jpeg, Time: time.Now()}
+	s.lock.Lock()
+	if s.thumbs == nil {
+		s.thumbs = make(map[thumbKey]Thumbnail)
+	}
+	s.thumbs[thumbKey{camera, p}] = t
+	s.lock.Unlock()
+	if s.Dir == "" {
+		return nil
+	}
+	path, err := s.path(camera, p)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, jpeg, 0o644)
+}
+
+// Get returns the thumbnail of a preset.
+func (s *ThumbnailStore) Get(camera string, p Preset) (Thumbnail, bool) {
+	s.lock.Lock()
+	t, ok := s.thumbs[thumbKey{camera, p}]
+	s.lock.Unlock()
+	if ok || s.Dir == "" {
+		return t, ok
+	}
+	path, err := s.path(camera, p)
+	if err != nil {
+		return t, false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return t, false
+	}
+	jpeg, err := os.ReadFile(path)
+	if err != nil {
+		return t, false
+	}
+	t = Thumbnail{JPEG: jpeg, Time: info.ModTime()}
+	s.lock.Lock()
+	if s.thumbs == nil {
+		s.thumbs = make(map[thumbKey]Thumbnail)
+	}
+	s.thumbs[thumbKey{camera, p}] = t
+	s.lock.Unlock()
+	return t, true
+}
+
+// path returns the file of a thumbnail in Dir.
+func (s *ThumbnailStore) path(camera string, p Preset) (string, error) {
+	if !fs.ValidPath(camera) || strings.Contains(camera, "/") {
+		return "", fmt.Errorf("broadcastkit/panasonic: invalid camera name for thumbnail: %q", camera)
+	}
+	return filepath.Join(s.Dir, camera, p.toWire()+".jpg"), nil
+}
+
+// Camera returns the thumbnails of the named camera, to be served by a
+// CameraServer.
+func (s *ThumbnailStore) Camera(name string) PresetImages {
+	return cameraThumbnails{s, name}
+}
+
+func (s *ThumbnailStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	camera, file, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	n, err := strconv.Atoi(strings.TrimSuffix(file, ".jpg"))
+	if !ok || err != nil || n < 0 || n > 99 {
+		http.NotFound(w, r)
+		return
+	}
+	serveThumbnail(w, r, s.Camera(camera), Preset(n))
+}
+
+// PresetImages provides the preset thumbnails of a camera.
+type PresetImages interface {
+	PresetImage(p Preset) (Thumbnail, bool)
+}
+
+type cameraThumbnails struct {
+	store *ThumbnailStore
+	name  string
+}
+
+func (c cameraThumbnails) PresetImage(p Preset) (Thumbnail, bool) {
+	return c.store.Get(c.name, p)
+}
+
+// serveThumbnail writes a thumbnail, honoring conditional requests.
+func serveThumbnail(w http.ResponseWriter, r *http.Request, images PresetImages, p Preset) {
+	t, ok := images.PresetImage(p)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "image/jpeg")
+	http.ServeContent(w, r, "", t.Time, bytes.NewReader(t.JPEG))
+}
+
+// ThumbnailProxy is an AWHandler passing requests to a camera and capturing
+// a thumbnail of every preset registered through it.
+//
+// Serve it with a CameraServer to capture the presets stored from remote
+// panels. Pass the notifications of the camera to Update to also capture
+// presets when their recall completes, which keeps thumbnails current after
+// presets are stored by other means.
+type ThumbnailProxy struct {
+	Camera *CameraClient
+	// Name is the camera name in Store.
+	Name  string
+	Store *ThumbnailStore
+	// Resolution is the requested width of thumbnails, 320 by default.
+	Resolution int
+
+	captures sync.WaitGroup
+}
+
+// Capture takes a still of the camera as the thumbnail of p.
+func (t *ThumbnailProxy) Capture(p Preset) error {
+	resolution := t.Resolution
+	if resolution == 0 {
+		resolution = 320
+	}
+	jpeg, err := t.Camera.Screenshot(resolution)
+	if err != nil {
+		return err
+	}
+	return t.Store.Put(t.Name, p, jpeg)
+}
+
+// AWCommand passes req to the camera. After a successful AWPresetRegister,
+// it starts capturing the thumbnail in the background and returns the
+// response right away, as panels time out on slow replies. Capture failures
+// are logged to the Logger of the camera, the preset is stored regardless.
+func (t *ThumbnailProxy) AWCommand(req AWRequest) (AWResponse, error) {
+	res, err := t.Camera.AWCommand(req)
+	if r, ok := req.(AWPresetRegister); ok && err == nil {
+		t.captures.Add(1)
+		go func() {
+			defer t.captures.Done()
+			if err := t.Capture(r.Preset); err != nil {
+				wirelog.Or(t.Camera.Logger).Warn("preset thumbnail", "preset", r.Preset, "err", err)
+			}
+		}()
+	}
+	return res, err
+}
+
+// Wait waits for the captures started by AWCommand to finish.
+func (t *ThumbnailProxy) Wait() {
+	t.captures.Wait()
+}
+
+// AWBatch passes the batch request to the camera.
+func (t *ThumbnailProxy) AWBatch() ([]AWResponse, error) {
+	return t.Camera.AWBatch()
+}
+
+// Update captures the thumbnail of a preset whose recall completed, as
+// signaled by an AWPresetPlayback notification. Other notifications are
+// ignored.
+func (t *ThumbnailProxy) Update(res AWResponse) error {
+	if r, ok := res.(AWPresetPlayback); ok {
+		return t.Capture(r.Preset)
+	}
+	return nil
+}
+
+var _ AWHandler = (*ThumbnailProxy)(nil)
@@ -0,0 +1,124 @@
+package panasonic
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestThumbnailStore(t *testing.T) {
+	dir := t.TempDir()
+	s := &ThumbnailStore{Dir: dir}
+	if err := s.Put("cam1", 12, []byte("jpeg12")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := s.Put("../etc", 1, []byte("x")); err == nil {
+		t.Error("Put(../etc) error = nil, want invalid name")
+	}
+
+	// A new store finds the thumbnail in Dir.
+	s = &ThumbnailStore{Dir: dir}
+	got, ok := s.Get("cam1", 12)
+	if !ok || string(got.JPEG) != "jpeg12" {
+		t.Errorf("Get(cam1, 12) = %q, %v, want jpeg12", got.JPEG, ok)
+	}
+	if _, ok := s.Get("cam1", 13); ok {
+		t.Error("Get(cam1, 13) found a thumbnail never stored")
+	}
+
+	tests := []struct {
+		path   string
+		header string
+		status int
+		body   string
+	}{
+		{"/cam1/12.jpg", "", http.StatusOK, "jpeg12"},
+		{"/cam1/12", "", http.StatusOK, "jpeg12"},
+		{"/cam1/12.jpg", got.Time.Add(time.Second).UTC().Format(http.TimeFormat), http.StatusNotModified, ""},
+		{"/cam1/13.jpg", "", http.StatusNotFound, ""},
+		{"/cam1/100.jpg", "", http.StatusNotFound, ""},
+		{"/cam1", "", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.header != "" {
+			r.Header.Set("If-Modified-Since", tt.header)
+		}
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		if w.Code != tt.status {
+			t.Errorf("GET %s status = %d, want %d", tt.path, w.Code, tt.status)
+			continue
+		}
+		if tt.status == http.StatusOK {
+			if w.Body.String() != tt.body || w.Header().Get("Content-Type") != "image/jpeg" {
+				t.Errorf("GET %s = %q %q, want %q image/jpeg", tt.path, w.Body, w.Header().Get("Content-Type"), tt.body)
+			}
+		}
+	}
+}
+
+func TestThumbnailProxy(t *testing.T) {
+	var shots atomic.Int32
+	head := &fakeHead{presets: make(map[Preset]PresetPosition)}
+	mux := http.NewServeMux()
+	mux.Handle("/", &CameraServer{AWHandler: head})
+	mux.HandleFunc("/cgi-bin/camera", func(w http.ResponseWriter, r *http.Request) {
+		n := shots.Add(1)
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte{'J', byte('0' + n)})
+	})
+	cam := httptest.NewServer(mux)
+	defer cam.Close()
+
+	store := &ThumbnailStore{}
+	proxy := &ThumbnailProxy{
+		Camera: &CameraClient{Remote: netip.MustParseAddrPort(cam.Listener.Addr().String())},
+		Name:   "cam1",
+		Store:  store,
+	}
+	server := httptest.NewServer(&CameraServer{AWHandler: proxy, Thumbnails: store.Camera("cam1")})
+	defer server.Close()
+	panel := &CameraClient{Remote: netip.MustParseAddrPort(server.Listener.Addr().String())}
+
+	if _, err := panel.AWCommand(AWPresetRegister{Preset: 5}); err != nil {
+		t.Fatalf("AWCommand(AWPresetRegister) error = %v", err)
+	}
+	proxy.Wait()
+	if _, err := panel.AWCommand(AWPresetRecall{Preset: 5}); err != nil {
+		t.Fatalf("AWCommand(AWPresetRecall) error = %v", err)
+	}
+	if got, ok := store.Get("cam1", 5); !ok || string(got.JPEG) != "J1" {
+		t.Errorf("thumbnail of preset 5 = %q, %v, want J1", got.JPEG, ok)
+	}
+	if err := proxy.Update(AWPresetPlayback{Preset: 5}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := proxy.Update(AWPower{Power: PowerOn}); err != nil {
+		t.Fatalf("Update(AWPower) error = %v", err)
+	}
+
+	res, err := http.Get(server.URL + "/cgi-bin/get_preset_thumbnail?preset_number=6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || string(body) != "J2" {
+		t.Errorf("get_preset_thumbnail = %d %q, want 200 J2", res.StatusCode, body)
+	}
+	for _, q := range []string{"preset_number=0", "preset_number=x", "preset_number=7"} {
+		res, err := http.Get(server.URL + "/cgi-bin/get_preset_thumbnail?" + q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode == http.StatusOK {
+			t.Errorf("get_preset_thumbnail?%s status = 200, want failure", q)
+		}
+	}
+}
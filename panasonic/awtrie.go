@@ -0,0 +1,200 @@
+package panasonic
+
+// awTrie is a prefix tree of signature patterns, giving the same answers as
+// testing the patterns one by one with match().
+//
+// Each pattern byte is an edge: printable characters are literal edges,
+// \x00-\x04 are charSet edges and \x7F is a stop edge. Since a string byte may
+// follow both a literal and a charSet edge, lookups walk all of them but skip
+// subtrees that cannot hold an earlier pattern than the best one found.
+// Subtrees of a few patterns are kept as a list and tested with match(), which
+// is cheaper than walking them byte by byte.
+type awTrie struct {
+	root *awNode
+}
+
+// awLeafSize is the number of patterns up to which a subtree is a list.
+const awLeafSize = 4
+
+type awNode struct {
+	// lo and hi are the lowest and highest pattern index below this node.
+	lo, hi int
+	// leaf lists the patterns of small subtrees, ascending by index. Nodes
+	// with a leaf list have no edges.
+	leaf []awPattern
+	// end lists the indices of patterns ending at this node, ascending.
+	end []int
+	// lit are the literal edges, sorted by byte.
+	lit []awEdge
+	// set are the charSet edges, the byte is the index into matchSets.
+	set []awEdge
+	// stop lists the indices of patterns ending with \x7F at this node.
+	stop []int
+}
+
+type awEdge struct {
+	c    byte
+	next *awNode
+}
+
+// awPattern is the remainder of a pattern below a node.
+type awPattern struct {
+	i    int
+	rest string
+}
+
+// newAWTrie builds a trie from patterns, the index of each pattern being its
+// position in the list. Subtrees of up to leafSize patterns become lists.
+//
+// Patterns with invalid charSets are dropped, as match() never accepts them.
+// The \x7F terminator must be the last byte of the pattern.
+func newAWTrie(patterns []string, leafSize int) awTrie {
+	list := make([]awPattern, 0, len(patterns))
+	for i, pattern := range patterns {
+		valid := true
+		for p := range len(pattern) {
+			c := pattern[p]
+			if c < 32 && c >= byte(len(matchSets)) {
+				valid = false
+			}
+			if c == '\x7F' && p != len(pattern)-1 {
+				panic("broadcastkit/panasonic: pattern terminator is not last: " + pattern)
+			}
+		}
+		if valid {
+			list = append(list, awPattern{i, pattern})
+		}
+	}
+	if len(list) == 0 {
+		return awTrie{}
+	}
+	return awTrie{root: newAWNode(list, leafSize)}
+}
+
+// newAWNode builds the subtree of patterns, which must be ascending by index.
+func newAWNode(patterns []awPattern, leafSize int) *awNode {
+	n := &awNode{lo: patterns[0].i, hi: patterns[len(patterns)-1].i}
+	if len(patterns) <= leafSize {
+		n.leaf = patterns
+		return n
+	}
+	var lit, set []byte
+	next := make(map[byte][]awPattern)
+	for _, p := range patterns {
+		if len(p.rest) == 0 {
+			n.end = append(n.end, p.i)
+			continue
+		}
+		c := p.rest[0]
+		if c == '\x7F' {
+			n.stop = append(n.stop, p.i)
+			continue
+		}
+		if _, ok := next[c]; !ok {
+			if c < 32 {
+				set = append(set, c)
+			} else {
+				lit = append(lit, c)
+			}
+		}
+		next[c] = append(next[c], awPattern{p.i, p.rest[1:]})
+	}
+	for _, c := range set {
+		n.set = append(n.set, awEdge{c, newAWNode(next[c], leafSize)})
+	}
+	for _, c := range lit {
+		n.lit = append(n.lit, awEdge{c, newAWNode(next[c], leafSize)})
+	}
+	// Lookups search the literal edges by byte.
+	for i := 1; i < len(n.lit); i++ {
+		for j := i; j > 0 && n.lit[j].c < n.lit[j-1].c; j-- {
+			n.lit[j], n.lit[j-1] = n.lit[j-1], n.lit[j]
+		}
+	}
+	return n
+}
+
+// literal returns the node behind the literal edge c, or nil.
+func (n *awNode) literal(c byte) *awNode {
+	lo, hi := 0, len(n.lit)
+	for lo < hi {
+		m := int(uint(lo+hi) >> 1)
+		if n.lit[m].c < c {
+			lo = m + 1
+		} else {
+			hi = m
+		}
+	}
+	if lo < len(n.lit) && n.lit[lo].c == c {
+		return n.lit[lo].next
+	}
+	return nil
+}
+
+// find returns the lowest index of a pattern matching s that is greater than
+// after, or -1 if there is none.
+func (t *awTrie) find(s string, after int) int {
+	if t.root == nil {
+		return -1
+	}
+	const none = int(^uint(0) >> 1)
+	best := t.root.find(s, after, none)
+	if best == none {
+		return -1
+	}
+	return best
+}
+
+// find returns the lowest index below n matching s within (after, best), or
+// best if there is none.
+func (n *awNode) find(s string, after, best int) int {
+	if n.hi <= after || n.lo >= best {
+		return best
+	}
+	if n.leaf != nil {
+		for _, p := range n.leaf {
+			if p.i >= best {
+				break
+			}
+			if p.i > after && match(p.rest, s) {
+				return p.i
+			}
+		}
+		return best
+	}
+	if len(s) == 0 {
+		return firstBetween(n.end, after, best)
+	}
+	c := s[0]
+	if next := n.literal(c); next != nil {
+		best = next.find(s[1:], after, best)
+	}
+	for _, e := range n.set {
+		if matchSets[e.c].contains(c) {
+			best = e.next.find(s[1:], after, best)
+		}
+	}
+	if n.stop != nil {
+		if c != '\x7F' {
+			best = firstBetween(n.stop, after, best)
+		} else if len(s) == 1 {
+			// match() compares the terminator as a literal first.
+			best = firstBetween(n.stop, after, best)
+		}
+	}
+	return best
+}
+
+// firstBetween returns the first index of the ascending list within
+// (after, best), or best.
+func firstBetween(list []int, after, best int) int {
+	for _, i := range list {
+		if i >= best {
+			break
+		}
+		if i > after {
+			return i
+		}
+	}
+	return best
+}
@@ -0,0 +1,211 @@
+package panasonic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// linearRequest is the table scan the trie replaces.
+func linearRequest(cmd string) int {
+	for i, e := range awRequestTable {
+		if match(e.sig, cmd) {
+			return i
+		}
+	}
+	return -1
+}
+
+// linearResponse is the table scan the trie replaces, with the quirk
+// re-matching of newResponse.
+func linearResponse(cmd string, quirks quirkMode) AWResponse {
+	for _, e := range awResponseTable {
+		if match(e.sig, cmd) {
+			res := e.new()
+			if qRes, ok := res.(awQuirkedPacking); quirks != quirkNone && ok {
+				res = qRes.packingQuirk(quirks)
+				if !match(res.responseSignature(), cmd) {
+					continue
+				}
+			}
+			return res.unpackResponse(cmd)
+		}
+	}
+	return AWUnknownResponse{text: cmd}
+}
+
+func requestSigs() []string {
+	sigs := make([]string, len(awRequestTable))
+	for i, e := range awRequestTable {
+		sigs[i] = e.sig
+	}
+	return sigs
+}
+
+func responseSigs() []string {
+	sigs := make([]string, len(awResponseTable))
+	for i, e := range awResponseTable {
+		sigs[i] = e.sig
+	}
+	return sigs
+}
+
+// trieCorpus returns strings matching the signatures of both tables, and
+// near misses of them.
+func trieCorpus() []string {
+	sigs := append(requestSigs(), responseSigs()...)
+	corpus := []string{"", "#", "\x7F", "#\x7F", "XX", "eR1", "ER3:OAS"}
+	for _, sig := range sigs {
+		for _, seed := range []int64{0, -1, 1, 2, 3} {
+			s := generateMatch(sig, seed)
+			corpus = append(corpus, s, s+"0", s+"\x7F", s[:len(s)-1])
+			for p := range len(s) {
+				corpus = append(corpus, s[:p]+"\x7F"+s[p+1:], s[:p]+"g"+s[p+1:])
+			}
+		}
+	}
+	return corpus
+}
+
+func TestAWTrie_Request(t *testing.T) {
+	sigs := requestSigs()
+	corpus := trieCorpus()
+	for _, leafSize := range []int{0, awLeafSize} {
+		trie := newAWTrie(sigs, leafSize)
+		for _, cmd := range corpus {
+			if got, want := trie.find(cmd, -1), linearRequest(cmd); got != want {
+				t.Errorf("leafSize %d: find(%q) = %d, want %d", leafSize, cmd, got, want)
+			}
+		}
+	}
+}
+
+func TestAWTrie_Response(t *testing.T) {
+	for _, cmd := range trieCorpus() {
+		for _, quirks := range []quirkMode{quirkNone, quirkBatch, quirkNotify, quirkCamera, quirkPtz} {
+			got, want := newResponse(cmd, quirks), linearResponse(cmd, quirks)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("newResponse(%q, %d) = %#v, want %#v", cmd, quirks, got, want)
+			}
+		}
+	}
+}
+
+func TestAWTrie_Find(t *testing.T) {
+	sigs := []string{"A\x02", "A1", "\x00\x00", "A\x7F", "A1", "B\x05", "AB"}
+	tests := []struct {
+		s     string
+		after int
+		want  int
+	}{
+		{"A1", -1, 0},
+		{"A1", 0, 1},
+		{"A1", 1, 2},
+		{"A1", 2, 3},
+		{"A1", 3, 4},
+		{"A1", 4, -1},
+		{"AX", -1, 2},
+		{"AB", -1, 2},
+		{"AB", 3, 6},
+		{"A", -1, -1},
+		{"", -1, -1},
+		{"A\x7F", -1, 3},
+		{"AXY", -1, 3},
+		{"A\x7FY", -1, -1},
+		{"B\x05", -1, -1},
+	}
+	for _, leafSize := range []int{0, 2, len(sigs)} {
+		trie := newAWTrie(sigs, leafSize)
+		for _, tt := range tests {
+			if got := trie.find(tt.s, tt.after); got != tt.want {
+				t.Errorf("leafSize %d: find(%q, %d) = %d, want %d", leafSize, tt.s, tt.after, got, tt.want)
+			}
+		}
+	}
+}
+
+// panelLoad is the polling and control traffic of a remote panel driving a
+// camera.
+var panelLoad = []string{
+	"#PTS5050", "#Z50", "#F50", "#I50", "#GZ", "#GF", "#GI", "#AXZ555", "#AXF555",
+	"#AXI555", "#D10", "#D30", "#R12", "#O", "#LPI", "#UPVS", "#APC80008000",
+	"OGU:08", "OGU:", "ORI:096", "ORI:", "OBI:096", "OSJ:0F", "QRV", "QSV", "QID",
+	"OSD:48:03", "XSF:1", "QSA:87",
+}
+
+// withLinearTries runs f with tries degenerated to a single list, which
+// matches the tables one by one like the factory did before the tries.
+func withLinearTries(f func()) {
+	awTrieOnce.Do(buildAWTries)
+	req, res := awRequestTrie, awResponseTrie
+	defer func() { awRequestTrie, awResponseTrie = req, res }()
+	reqSigs, resSigs := requestSigs(), responseSigs()
+	awRequestTrie = newAWTrie(reqSigs, len(reqSigs))
+	awResponseTrie = newAWTrie(resSigs, len(resSigs))
+	f()
+}
+
+func BenchmarkNewRequest(b *testing.B) {
+	run := func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			newRequest(panelLoad[i%len(panelLoad)])
+		}
+	}
+	b.Run("trie", run)
+	withLinearTries(func() { b.Run("linear", run) })
+}
+
+func BenchmarkNewResponse(b *testing.B) {
+	responses := make([]string, len(panelLoad))
+	for i, cmd := range panelLoad {
+		responses[i] = newRequest(cmd).Response().packResponse()
+	}
+	run := func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			newResponse(responses[i%len(responses)], quirkPtz)
+		}
+	}
+	b.Run("trie", run)
+	withLinearTries(func() { b.Run("linear", run) })
+}
+
+// echoHandler answers every request with its default response, like a proxy
+// to an instant camera.
+type echoHandler struct{}
+
+func (echoHandler) AWCommand(req AWRequest) (AWResponse, error) { return req.Response(), nil }
+func (echoHandler) AWBatch() ([]AWResponse, error)              { return nil, nil }
+
+func BenchmarkCameraServer(b *testing.B) {
+	srv := &CameraServer{AWHandler: echoHandler{}}
+	reqs := make([]*http.Request, len(panelLoad))
+	for i, cmd := range panelLoad {
+		path := "/cgi-bin/aw_cam"
+		if cmd[0] == '#' {
+			path = "/cgi-bin/aw_ptz"
+		}
+		reqs[i] = httptest.NewRequest(http.MethodGet, path+"?res=1&cmd="+urlEscape(cmd), nil)
+	}
+	run := func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			srv.ServeHTTP(httptest.NewRecorder(), reqs[i%len(reqs)])
+		}
+	}
+	b.Run("trie", run)
+	withLinearTries(func() { b.Run("linear", run) })
+}
+
+func urlEscape(s string) string {
+	b := make([]byte, 0, len(s)*3)
+	for i := range len(s) {
+		c := s[i]
+		if c == '#' || c == ':' {
+			b = append(b, '%', hexAlphabet[c>>4], hexAlphabet[c&0xF])
+			continue
+		}
+		b = append(b, c)
+	}
+	return string(b)
+}
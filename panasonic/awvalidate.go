@@ -0,0 +1,43 @@
+package panasonic
+
+import "context"
+
+// ValidateHandler returns an AWHandler refusing the requests which fail
+// Profile.Validate with their AWError, and passing the others to h. The batch
+// is refused like by the model if the profile has NoBatch. The context of AWHandlerCtx calls is passed to h if it supports it.
+//
+// Serve it with a CameraServer to keep requests a camera would refuse away
+// from h, like an upstream camera or a bridge.
+func ValidateHandler(h AWHandler, profile *ModelProfile) AWHandler {
+	return &validateHandler{h: h, profile: profile}
+}
+
+type validateHandler struct {
+	h       AWHandler
+	profile *ModelProfile
+}
+
+func (v *validateHandler) AWCommand(req AWRequest) (AWResponse, error) {
+	return v.AWCommandCtx(context.Background(), req)
+}
+
+func (v *validateHandler) AWBatch() ([]AWResponse, error) {
+	return v.AWBatchCtx(context.Background())
+}
+
+func (v *validateHandler) AWCommandCtx(ctx context.Context, req AWRequest) (AWResponse, error) {
+	if err := v.profile.Validate(req); err != nil {
+		return nil, err
+	}
+	return awCommand(ctx, v.h, req)
+}
+
+func (v *validateHandler) AWBatchCtx(ctx context.Context) ([]AWResponse, error) {
+	if err := v.profile.batchError(); err != nil {
+		return nil, err
+	}
+	return awBatch(ctx, v.h)
+}
+
+var _ AWHandler = (*validateHandler)(nil)
+var _ AWHandlerCtx = (*validateHandler)(nil)
@@ -0,0 +1,130 @@
+package panasonic
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+)
+
+// countingHandler answers every request with its default response and counts
+// the requests reaching it.
+type countingHandler struct {
+	lock sync.Mutex
+	n    int
+}
+
+func (h *countingHandler) AWCommand(req AWRequest) (AWResponse, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.n++
+	return req.Response(), nil
+}
+
+func (h *countingHandler) AWBatch() ([]AWResponse, error) {
+	return []AWResponse{AWGain{Gain: 6}}, nil
+}
+
+func (h *countingHandler) count() int {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.n
+}
+
+var wiperless = &ModelProfile{
+	Model:       "AW-HE40",
+	Unsupported: []AWRequest{AWWiperControlBasic{}},
+}
+
+func TestCameraClient_Strict(t *testing.T) {
+	h := &countingHandler{}
+	srv := httptest.NewServer(&CameraServer{AWHandler: h})
+	defer srv.Close()
+	cam := &CameraClient{
+		Remote:  netip.MustParseAddrPort(srv.Listener.Addr().String()),
+		Strict:  true,
+		Profile: wiperless,
+	}
+
+	if _, err := cam.AWCommand(AWGain{Gain: 60}); !errors.As(err, &AWError{}) {
+		t.Errorf("AWCommand(unacceptable) error = %v, want an AWError", err)
+	}
+	if _, err := cam.AWCommand(AWWiperControlBasic{Enabled: On}); !errors.As(err, &AWError{}) {
+		t.Errorf("AWCommand(unsupported) error = %v, want an AWError", err)
+	}
+	if n := h.count(); n != 0 {
+		t.Errorf("camera received %d requests, want none", n)
+	}
+	if _, err := cam.AWCommand(AWGain{Gain: 12}); err != nil {
+		t.Errorf("AWCommand(acceptable) error = %v", err)
+	}
+	if n := h.count(); n != 1 {
+		t.Errorf("camera received %d requests, want 1", n)
+	}
+
+	cam.Strict = false
+	if _, err := cam.AWCommand(AWWiperControlBasic{Enabled: On}); err != nil {
+		t.Errorf("AWCommand() without Strict error = %v", err)
+	}
+	if n := h.count(); n != 2 {
+		t.Errorf("camera received %d requests, want 2", n)
+	}
+}
+
+// ctxHandler records the context of the last request.
+type ctxHandler struct {
+	countingHandler
+	ctx context.Context
+}
+
+func (h *ctxHandler) AWCommandCtx(ctx context.Context, req AWRequest) (AWResponse, error) {
+	h.lock.Lock()
+	h.ctx = ctx
+	h.lock.Unlock()
+	return h.AWCommand(req)
+}
+
+func (h *ctxHandler) AWBatchCtx(ctx context.Context) ([]AWResponse, error) {
+	return h.AWBatch()
+}
+
+func TestValidateHandler(t *testing.T) {
+	h := &ctxHandler{}
+	srv := httptest.NewServer(&CameraServer{AWHandler: ValidateHandler(h, wiperless)})
+	defer srv.Close()
+	cam := &CameraClient{Remote: netip.MustParseAddrPort(srv.Listener.Addr().String())}
+
+	tests := []struct {
+		name    string
+		req     AWRequest
+		wantErr bool
+	}{
+		{"acceptable", AWPedestal{Pedestal: 3}, false},
+		{"unacceptable", AWPedestal{Pedestal: 40}, true},
+		{"unsupported", AWWiperControlBasic{Enabled: Off}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := cam.AWCommand(tt.req)
+			if got := errors.As(err, &AWError{}); got != tt.wantErr {
+				t.Errorf("AWCommand() error = %v, want AWError %v", err, tt.wantErr)
+			}
+		})
+	}
+	if n := h.count(); n != 1 {
+		t.Errorf("handler received %d requests, want 1", n)
+	}
+	if h.ctx == nil {
+		t.Errorf("handler was not called with the request context")
+	}
+	if res, err := cam.AWBatch(); err != nil || len(res) != 1 {
+		t.Errorf("AWBatch() = %v, %v, want the batch of the handler", res, err)
+	}
+
+	noBatch := &ModelProfile{Model: "AW-TEST", NoBatch: true}
+	if _, err := ValidateHandler(h, noBatch).AWBatch(); err == nil {
+		t.Errorf("AWBatch() with NoBatch error = nil, want the missing page")
+	}
+}
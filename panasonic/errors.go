@@ -1,6 +1,10 @@
 package panasonic
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
 
 // AWErrNo is the error number within the Panasonic AW protocol
 type AWErrNo int
@@ -79,3 +83,33 @@ func (e *SystemError) Error() string {
 func (e *SystemError) Unwrap() error {
 	return e.parent
 }
+
+// statusError is an unexpected HTTP status code returned by the device.
+type statusError struct {
+	got  int
+	want int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("http status code: %d (expected %d)", e.got, e.want)
+}
+
+// errorKind classifies err by type for metrics.Recorder.
+func errorKind(err error) string {
+	var status *statusError
+	var sys *SystemError
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &status):
+		return "HTTP " + strconv.Itoa(status.got)
+	case errors.As(err, &AWError{}):
+		return "AWError"
+	case errors.As(err, &SwitcherError{}):
+		return "SwitcherError"
+	case errors.As(err, &sys):
+		return "SystemError"
+	default:
+		return "other"
+	}
+}
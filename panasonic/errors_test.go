@@ -1,6 +1,8 @@
 package panasonic
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -47,3 +49,25 @@ func TestAWErrorResponseSignature(t *testing.T) {
 		})
 	}
 }
+
+func TestErrorKind(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"AW error", AWError{No: AWErrBusy, Flag: "#P5"}, "AWError"},
+		{"switcher error", SwitcherError{code: 2}, "SwitcherError"},
+		{"http status", &SystemError{&statusError{503, 200}}, "HTTP 503"},
+		{"system error", &SystemError{errors.New("connection refused")}, "SystemError"},
+		{"other", fmt.Errorf("unexpected switcher response"), "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorKind(tt.err); got != tt.want {
+				t.Errorf("errorKind() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
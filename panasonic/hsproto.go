@@ -4,12 +4,16 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net"
 	"net/netip"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"puzzlekraken.com/broadcastkit/internal/wirelog"
+	"puzzlekraken.com/broadcastkit/metrics"
 )
 
 type SwitcherError struct {
@@ -34,10 +38,17 @@ func (e SwitcherError) message() string {
 type SwitcherClient struct {
 	Remote    netip.AddrPort
 	KeepAlive bool
-	lock      sync.Mutex
-	thread    sync.Once
-	tcp       *net.TCPConn
-	tcpBuff   *bufio.Reader
+	// Logger, if set, receives the frames exchanged at debug level and the
+	// reconnects and retries at info level.
+	Logger *slog.Logger
+	// Metrics, if set, receives the latency and errors of commands and the
+	// reconnects.
+	Metrics metrics.Recorder
+	dialed  bool
+	lock    sync.Mutex
+	thread  sync.Once
+	tcp     *net.TCPConn
+	tcpBuff *bufio.Reader
 }
 
 const hsPeriod = 15 * time.Second
@@ -65,6 +76,11 @@ func (s *SwitcherClient) dial() error {
 	}
 	s.tcp = tcp
 	s.tcpBuff = bufio.NewReader(tcp)
+	if s.dialed && s.Metrics != nil {
+		s.Metrics.Reconnect(s.Remote.String())
+	}
+	s.dialed = true
+	wirelog.Or(s.Logger).Info("connected", "remote", s.Remote)
 	return nil
 }
 
@@ -76,11 +92,20 @@ func (s *SwitcherClient) close() {
 	}
 }
 
-func (s *SwitcherClient) command(send string) (string, error) {
+func (s *SwitcherClient) command(send string) (_ string, err error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
+	if s.Metrics != nil {
+		// Commands are named by their four letter header, like SBUS.
+		defer func(start time.Time) {
+			s.Metrics.Command(s.Remote.String(), send[:min(len(send), 4)], time.Since(start), errorKind(err))
+		}(time.Now())
+	}
 	var syserr error
 	for retry := 0; retry < 3; retry++ {
+		if syserr != nil {
+			wirelog.Or(s.Logger).Info("retrying", "cmd", send, "err", syserr)
+		}
 		syserr = nil // ignore earlier errors
 
 		if s.tcp == nil { // connect if necesary
@@ -95,6 +120,7 @@ func (s *SwitcherClient) command(send string) (string, error) {
 		buf[0] = '\x02' // STX
 		copy(buf[1:], send)
 		buf[len(buf)-1] = '\x03' // ETX
+		wirelog.Send(s.Logger, buf)
 		_, syserr = s.tcp.Write(buf)
 		if syserr != nil {
 			s.close()
@@ -105,6 +131,7 @@ func (s *SwitcherClient) command(send string) (string, error) {
 		var recv string
 
 		recv, syserr = s.tcpBuff.ReadString('\x03') // messages end in ETX
+		wirelog.Recv(s.Logger, []byte(recv))
 
 		// Panasonic sometimes closes errors with \x00 instead of \x03
 		// we check for these "hidden" errors before checking the error
@@ -124,6 +151,7 @@ func (s *SwitcherClient) command(send string) (string, error) {
 		}
 
 		if recv[0] != '\x02' {
+			wirelog.Or(s.Logger).Warn("missing STX", "data", recv)
 			return "", errors.New("corrupt message from switcher, missing STX")
 		}
 
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/icholy/digest"
+	"puzzlekraken.com/broadcastkit/panasonic"
+)
+
+var panasonicDevice = device{
+	name:  "panasonic",
+	usage: "aw send <command>... | aw batch | aw screenshot [-resolution n] [-o file] | aw notify",
+	commands: map[string]func(context.Context, *env, []string) error{
+		"aw": panasonicAW,
+	},
+}
+
+// panasonicClient returns a client of the camera. With -user, requests
+// authenticate by HTTP digest, for cameras with user authentication enabled.
+func (e *env) panasonicClient() (*panasonic.CameraClient, error) {
+	remote, err := resolve(e.Address, 80)
+	if err != nil {
+		return nil, err
+	}
+	c := &panasonic.CameraClient{Remote: remote, Logger: e.log}
+	if e.Username != "" {
+		c.Http.Transport = &digest.Transport{Username: e.Username, Password: e.Password}
+	} else if e.Password != "" {
+		return nil, errors.New("-password needs -user for panasonic")
+	}
+	return c, nil
+}
+
+// awResult is the JSON form of an AW response.
+type awResult struct {
+	Request  string `json:"request,omitempty"`
+	Response string `json:"response"`
+	Type     string `json:"type"`
+	Value    any    `json:"value,omitempty"`
+}
+
+func (e *env) printAW(req string, res panasonic.AWResponse) error {
+	text := panasonic.FormatAWResponse(res)
+	return e.out.print(awResult{
+		Request:  req,
+		Response: text,
+		Type:     typeName(res),
+		Value:    res,
+	}, text)
+}
+
+func panasonicAW(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: aw send|batch|screenshot|notify")
+	}
+	c, err := e.panasonicClient()
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "send":
+		return awSend(ctx, e, c, args[1:])
+	case "batch":
+		res, err := c.AWBatch()
+		if err != nil {
+			return err
+		}
+		for _, r := range res {
+			if err := e.printAW("", r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "screenshot":
+		return awScreenshot(e, c, args[1:])
+	case "notify":
+		return awNotify(ctx, e, c)
+	default:
+		return fmt.Errorf("unknown aw command: %s", args[0])
+	}
+}
+
+func awSend(ctx context.Context, e *env, c *panasonic.CameraClient, cmds []string) error {
+	if len(cmds) == 0 {
+		return errors.New("usage: aw send <command>..., for example: aw send '#O1' '#APC'")
+	}
+	var errs []error
+	for _, cmd := range cmds {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		req := panasonic.ParseAWRequest(cmd)
+		if !req.Acceptable() {
+			errs = append(errs, fmt.Errorf("%s: value out of range", cmd))
+			continue
+		}
+		res, err := c.AWCommand(req)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", cmd, err))
+			continue
+		}
+		if err := e.printAW(cmd, res); err != nil {
+			return err
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func awScreenshot(e *env, c *panasonic.CameraClient, args []string) error {
+	fs := flag.NewFlagSet("aw screenshot", flag.ContinueOnError)
+	resolution := fs.Int("resolution", 1920, "requested image width in pixels")
+	out := fs.String("o", "", "output file, standard output if empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	img, err := c.Screenshot(*resolution)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err := e.out.w.Write(img)
+		return err
+	}
+	if err := os.WriteFile(*out, img, 0o644); err != nil {
+		return err
+	}
+	return e.out.print(map[string]any{"file": *out, "size": len(img)},
+		fmt.Sprintf("%s: %d bytes", *out, len(img)))
+}
+
+func awNotify(ctx context.Context, e *env, c *panasonic.CameraClient) error {
+	l, err := c.Listener()
+	if err != nil {
+		return err
+	}
+	if err := l.Start(); err != nil {
+		l.Close()
+		return err
+	}
+	// Closing the listener unblocks Accept and stops the notifications.
+	stop := context.AfterFunc(ctx, func() { l.Close() })
+	defer func() {
+		if stop() {
+			l.Close()
+		}
+	}()
+	for {
+		res, err := l.Accept()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return err
+		}
+		if err := e.printAW("", res); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package ptz
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"puzzlekraken.com/broadcastkit/panasonic"
+)
+
+// PanasonicCamera adapts a Panasonic AW protocol camera to the Camera
+// interface.
+//
+// Handler is typically a *panasonic.CameraClient, but any AWHandler works. If
+// it implements panasonic.AWHandlerCtx, the context is passed through.
+//
+// AW cameras have a single (red) tally lamp, TallyPreview switches it off.
+type PanasonicCamera struct {
+	Handler panasonic.AWHandler
+}
+
+func (p *PanasonicCamera) command(ctx context.Context, req panasonic.AWRequest) (panasonic.AWResponse, error) {
+	if !req.Acceptable() {
+		return nil, fmt.Errorf("broadcastkit/ptz: unacceptable AW request: %#v", req)
+	}
+	if h, ok := p.Handler.(panasonic.AWHandlerCtx); ok {
+		return h.AWCommandCtx(ctx, req)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return p.Handler.AWCommand(req)
+}
+
+func (p *PanasonicCamera) degrees(d float64) panasonic.MoveUnit {
+	return panasonic.MoveUnit(math.Round(d * panasonic.MoveUnitByDegree))
+}
+
+// continuous converts a normalized speed to the AW continuous range of ±49.
+func (p *PanasonicCamera) continuous(v float64) panasonic.ContinuousSpeed {
+	return panasonic.ContinuousSpeed(math.Round(clamp(v, -1, 1) * 49))
+}
+
+// speedUnit converts an absolute motion speed to an AW speed of 1 to 30. Zero
+// selects the default speed, slow movements are kept from rounding to it.
+func (p *PanasonicCamera) speedUnit(speed float64) panasonic.SpeedUnit {
+	if speed <= 0 {
+		return panasonic.SpeedUnit{}
+	}
+	return panasonic.SpeedUnit{Speed: max(1, scale(speed, 30))}
+}
+
+func (p *PanasonicCamera) PanTiltTo(ctx context.Context, pan, tilt, speed float64) error {
+	_, err := p.command(ctx, panasonic.AWPanTiltSpeedTo{
+		Pan:   p.degrees(pan),
+		Tilt:  p.degrees(tilt),
+		Speed: p.speedUnit(speed),
+	})
+	return err
+}
+
+func (p *PanasonicCamera) PanTilt(ctx context.Context, pan, tilt float64) error {
+	_, err := p.command(ctx, panasonic.AWPanTilt{
+		Pan:  p.continuous(pan),
+		Tilt: p.continuous(tilt),
+	})
+	return err
+}
+
+func (p *PanasonicCamera) ZoomTo(ctx context.Context, zoom float64) error {
+	_, err := p.command(ctx, panasonic.AWZoomTo{
+		Zoom: panasonic.ScaleUnit(scale(zoom, int(panasonic.ScaleUnitMax))),
+	})
+	return err
+}
+
+func (p *PanasonicCamera) Zoom(ctx context.Context, speed float64) error {
+	_, err := p.command(ctx, panasonic.AWZoom{Zoom: p.continuous(speed)})
+	return err
+}
+
+func (p *PanasonicCamera) FocusTo(ctx context.Context, focus float64) error {
+	_, err := p.command(ctx, panasonic.AWFocusTo{
+		Focus: panasonic.ScaleUnit(scale(focus, int(panasonic.ScaleUnitMax))),
+	})
+	return err
+}
+
+func (p *PanasonicCamera) Focus(ctx context.Context, speed float64) error {
+	_, err := p.command(ctx, panasonic.AWFocus{Focus: p.continuous(speed)})
+	return err
+}
+
+func (p *PanasonicCamera) Position(ctx context.Context) (Position, error) {
+	var pos Position
+	res, err := p.command(ctx, panasonic.AWPanTiltQuery{})
+	if err != nil {
+		return pos, err
+	}
+	pt, ok := res.(panasonic.AWPanTiltTo)
+	if !ok {
+		return pos, fmt.Errorf("broadcastkit/ptz: unexpected AW response: %#v", res)
+	}
+	res, err = p.command(ctx, panasonic.AWZoomQuery{})
+	if err != nil {
+		return pos, err
+	}
+	z, ok := res.(panasonic.AWZoomTo)
+	if !ok {
+		return pos, fmt.Errorf("broadcastkit/ptz: unexpected AW response: %#v", res)
+	}
+	res, err = p.command(ctx, panasonic.AWFocusQuery{})
+	if err != nil {
+		return pos, err
+	}
+	f, ok := res.(panasonic.AWFocusTo)
+	if !ok {
+		return pos, fmt.Errorf("broadcastkit/ptz: unexpected AW response: %#v", res)
+	}
+	pos.Pan = float64(pt.Pan) / panasonic.MoveUnitByDegree
+	pos.Tilt = float64(pt.Tilt) / panasonic.MoveUnitByDegree
+	pos.Zoom = unscale(int(z.Zoom), int(panasonic.ScaleUnitMax))
+	pos.Focus = unscale(int(f.Focus), int(panasonic.ScaleUnitMax))
+	return pos, nil
+}
+
+// preset converts a preset numbered from 1 to the AW numbering from 0.
+func (p *PanasonicCamera) preset(preset int) (panasonic.Preset, error) {
+	if preset < 1 || preset > 100 {
+		return 0, fmt.Errorf("broadcastkit/ptz: invalid preset: %d", preset)
+	}
+	return panasonic.Preset(preset - 1), nil
+}
+
+func (p *PanasonicCamera) RecallPreset(ctx context.Context, preset int) error {
+	n, err := p.preset(preset)
+	if err != nil {
+		return err
+	}
+	_, err = p.command(ctx, panasonic.AWPresetRecall{Preset: n})
+	return err
+}
+
+func (p *PanasonicCamera) StorePreset(ctx context.Context, preset int) error {
+	n, err := p.preset(preset)
+	if err != nil {
+		return err
+	}
+	_, err = p.command(ctx, panasonic.AWPresetRegister{Preset: n})
+	return err
+}
+
+func (p *PanasonicCamera) SetTally(ctx context.Context, tally Tally) error {
+	light := panasonic.Off
+	switch tally {
+	case TallyOff, TallyPreview:
+	case TallyProgram:
+		light = panasonic.On
+	default:
+		return fmt.Errorf("broadcastkit/ptz: invalid tally: %d", tally)
+	}
+	_, err := p.command(ctx, panasonic.AWTallySet{TallyLight: light})
+	return err
+}
+
+func (p *PanasonicCamera) SetPower(ctx context.Context, on bool) error {
+	power := panasonic.PowerStandby
+	if on {
+		power = panasonic.PowerOn
+	}
+	_, err := p.command(ctx, panasonic.AWPower{Power: power})
+	return err
+}
+
+var _ Camera = (*PanasonicCamera)(nil)
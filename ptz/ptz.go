@@ -0,0 +1,91 @@
+// Package ptz is a vendor-neutral interface for remote controlled cameras.
+//
+// Positions are expressed in physical units: pan and tilt in degrees from the
+// home position, positive towards right and up. Zoom and focus are normalized
+// between 0 (wide, near) and 1 (tele, far). Speeds are normalized between -1
+// and 1 for continuous motion, and between 0 and 1 for absolute motion, where
+// 0 selects the default speed of the camera.
+package ptz
+
+import (
+	"context"
+	"errors"
+	"math"
+)
+
+// ErrUnsupported is returned for operations not available on a camera.
+var ErrUnsupported = errors.New("broadcastkit/ptz: operation not supported")
+
+// Position is the absolute position of a camera head and lens.
+type Position struct {
+	Pan   float64 // degrees
+	Tilt  float64 // degrees
+	Zoom  float64 // 0 to 1
+	Focus float64 // 0 to 1
+}
+
+// Tally is the state of the tally lamps of a camera.
+type Tally int
+
+const (
+	TallyOff Tally = iota
+	TallyProgram
+	TallyPreview
+)
+
+func (t Tally) String() string {
+	switch t {
+	case TallyOff:
+		return "off"
+	case TallyProgram:
+		return "program"
+	case TallyPreview:
+		return "preview"
+	default:
+		return "invalid"
+	}
+}
+
+// Camera is a remote controlled pan/tilt/zoom camera.
+//
+// Presets are numbered from 1, as shown to operators. Adapters translate
+// into the numbering of the underlying protocol.
+type Camera interface {
+	// PanTiltTo moves the head to an absolute position in degrees.
+	PanTiltTo(ctx context.Context, pan, tilt, speed float64) error
+	// PanTilt starts a continuous motion, zero speeds stop the motion.
+	PanTilt(ctx context.Context, pan, tilt float64) error
+	// ZoomTo moves the zoom to an absolute position.
+	ZoomTo(ctx context.Context, zoom float64) error
+	// Zoom starts a continuous zoom, positive towards tele, zero stops.
+	Zoom(ctx context.Context, speed float64) error
+	// FocusTo moves the focus to an absolute position.
+	FocusTo(ctx context.Context, focus float64) error
+	// Focus starts a continuous focus motion, positive towards far, zero stops.
+	Focus(ctx context.Context, speed float64) error
+	// Position returns the current absolute position.
+	Position(ctx context.Context) (Position, error)
+	// RecallPreset moves the camera to a stored preset.
+	RecallPreset(ctx context.Context, preset int) error
+	// StorePreset stores the current position as a preset.
+	StorePreset(ctx context.Context, preset int) error
+	// SetTally drives the tally lamps of the camera.
+	SetTally(ctx context.Context, tally Tally) error
+	// SetPower switches the camera on, or into standby.
+	SetPower(ctx context.Context, on bool) error
+}
+
+// clamp limits v between lo and hi.
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
+
+// scale converts a normalized value to an integer scale of 0 to max.
+func scale(v float64, max int) int {
+	return int(math.Round(clamp(v, 0, 1) * float64(max)))
+}
+
+// unscale converts an integer scale of 0 to max to a normalized value.
+func unscale(v int, max int) float64 {
+	return float64(v) / float64(max)
+}
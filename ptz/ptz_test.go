@@ -0,0 +1,139 @@
+package ptz
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+
+	"puzzlekraken.com/broadcastkit/panasonic"
+	"puzzlekraken.com/broadcastkit/sony"
+)
+
+type fakeAW struct {
+	sent []panasonic.AWRequest
+	res  map[panasonic.AWRequest]panasonic.AWResponse
+}
+
+func (f *fakeAW) AWCommand(req panasonic.AWRequest) (panasonic.AWResponse, error) {
+	f.sent = append(f.sent, req)
+	if res, ok := f.res[req]; ok {
+		return res, nil
+	}
+	return req.Response(), nil
+}
+
+func (f *fakeAW) AWBatch() ([]panasonic.AWResponse, error) {
+	return nil, nil
+}
+
+func TestPanasonicCamera(t *testing.T) {
+	tests := []struct {
+		name string
+		do   func(Camera) error
+		want panasonic.AWRequest
+	}{
+		{"pan tilt to", func(c Camera) error { return c.PanTiltTo(context.Background(), 10, -5, 1) },
+			panasonic.AWPanTiltSpeedTo{Pan: 1212, Tilt: -606, Speed: panasonic.SpeedUnit{Speed: 30}}},
+		{"pan tilt to slowly", func(c Camera) error { return c.PanTiltTo(context.Background(), 0, 0, 0.01) },
+			panasonic.AWPanTiltSpeedTo{Speed: panasonic.SpeedUnit{Speed: 1}}},
+		{"pan tilt to default speed", func(c Camera) error { return c.PanTiltTo(context.Background(), 0, 0, 0) },
+			panasonic.AWPanTiltSpeedTo{}},
+		{"pan tilt", func(c Camera) error { return c.PanTilt(context.Background(), 1, -0.5) },
+			panasonic.AWPanTilt{Pan: 49, Tilt: -25}},
+		{"zoom to", func(c Camera) error { return c.ZoomTo(context.Background(), 0.5) },
+			panasonic.AWZoomTo{Zoom: 1365}},
+		{"zoom clamped", func(c Camera) error { return c.Zoom(context.Background(), -3) },
+			panasonic.AWZoom{Zoom: -49}},
+		{"recall preset", func(c Camera) error { return c.RecallPreset(context.Background(), 1) },
+			panasonic.AWPresetRecall{Preset: 0}},
+		{"tally program", func(c Camera) error { return c.SetTally(context.Background(), TallyProgram) },
+			panasonic.AWTallySet{TallyLight: panasonic.On}},
+		{"power", func(c Camera) error { return c.SetPower(context.Background(), true) },
+			panasonic.AWPower{Power: panasonic.PowerOn}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAW{}
+			if err := tt.do(&PanasonicCamera{Handler: f}); err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if len(f.sent) != 1 || f.sent[0] != tt.want {
+				t.Errorf("sent %#v, want %#v", f.sent, tt.want)
+			}
+		})
+	}
+}
+
+func TestPanasonicCameraPosition(t *testing.T) {
+	f := &fakeAW{res: map[panasonic.AWRequest]panasonic.AWResponse{
+		panasonic.AWPanTiltQuery{}: panasonic.AWPanTiltTo{Pan: 12121, Tilt: -1212},
+		panasonic.AWZoomQuery{}:    panasonic.AWZoomTo{Zoom: panasonic.ScaleUnitMax},
+		panasonic.AWFocusQuery{}:   panasonic.AWFocusTo{Focus: 0},
+	}}
+	c := &PanasonicCamera{Handler: f}
+	got, err := c.Position(context.Background())
+	if err != nil {
+		t.Fatalf("Position() error = %v", err)
+	}
+	if math.Abs(got.Pan-100) > 0.01 || math.Abs(got.Tilt+10) > 0.01 || got.Zoom != 1 || got.Focus != 0 {
+		t.Errorf("Position() = %+v", got)
+	}
+}
+
+func TestSonyCamera(t *testing.T) {
+	var got url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/command/inquiry.cgi" {
+			io.WriteString(w, url.Values{"PresetName": {"3,Stage,4,"}}.Encode())
+			return
+		}
+		got = r.URL.Query()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	c := &SonyCamera{Client: &sony.CameraClient{Remote: netip.MustParseAddrPort(srv.Listener.Addr().String())}}
+
+	tests := []struct {
+		name string
+		do   func(Camera) error
+		key  string
+		want string
+	}{
+		{"pan tilt to", func(c Camera) error { return c.PanTiltTo(context.Background(), 10, -10, 0.5) },
+			"AbsolutePanTilt", "00937,ff6c9,25"},
+		{"pan tilt to default speed", func(c Camera) error { return c.PanTiltTo(context.Background(), 10, -10, 0) },
+			"AbsolutePanTilt", "00937,ff6c9,50"},
+		{"pan tilt", func(c Camera) error { return c.PanTilt(context.Background(), -1, 0) },
+			"PanTiltMove", "left,50,0"},
+		{"pan tilt slow", func(c Camera) error { return c.PanTilt(context.Background(), 0, 0.001) },
+			"PanTiltMove", "up,0,1"},
+		{"store named preset", func(c Camera) error { return c.StorePreset(context.Background(), 3) },
+			"PresetSet", "3,Stage,on"},
+		{"store unnamed preset", func(c Camera) error { return c.StorePreset(context.Background(), 4) },
+			"PresetSet", "4,PRESET4,on"},
+		{"zoom", func(c Camera) error { return c.Zoom(context.Background(), 0) },
+			"ZoomMove", "stop,0"},
+		{"tally preview", func(c Camera) error { return c.SetTally(context.Background(), TallyPreview) },
+			"TallyLampGreen", "on"},
+		{"power off", func(c Camera) error { return c.SetPower(context.Background(), false) },
+			"PowerStandby", "on"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.do(c); err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if v := got.Get(tt.key); v != tt.want {
+				t.Errorf("%s = %q, want %q", tt.key, v, tt.want)
+			}
+		})
+	}
+	if err := c.Focus(context.Background(), 1); err != ErrUnsupported {
+		t.Errorf("Focus() error = %v, want ErrUnsupported", err)
+	}
+}
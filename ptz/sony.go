@@ -0,0 +1,209 @@
+package ptz
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"puzzlekraken.com/broadcastkit/sony"
+)
+
+// SonyCamera adapts a sony.CameraClient to the Camera interface.
+//
+// Sony cameras lack continuous focus motion, and absolute zoom is only
+// available combined with pan, tilt and focus. ZoomTo therefore reads the
+// current position first.
+type SonyCamera struct {
+	Client *sony.CameraClient
+}
+
+func (s *SonyCamera) degrees(d float64) sony.SteppedPosition {
+	return sony.SteppedPosition(math.Round(d * sony.SteppedPositionByDegree))
+}
+
+// sonyDefaultSpeed is the fastest speed step, which Sony cameras use when
+// no speed is given.
+const sonyDefaultSpeed sony.SpeedStep = 50
+
+// speedStep converts an absolute motion speed to a Sony speed step. Zero
+// selects the default speed.
+func speedStep(speed float64) sony.SpeedStep {
+	if speed <= 0 {
+		return sonyDefaultSpeed
+	}
+	return sony.SpeedStep(max(1, scale(speed, 50)))
+}
+
+// moveStep converts the speed of one axis of continuous motion to a Sony
+// speed step, keeping slow movements from rounding to a stop.
+func moveStep(speed float64) sony.SpeedStep {
+	if speed == 0 {
+		return 0
+	}
+	return sony.SpeedStep(max(1, scale(math.Abs(speed), 50)))
+}
+
+func (s *SonyCamera) set(ctx context.Context, ps ...sony.Parameter) error {
+	for _, p := range ps {
+		if !p.Valid() {
+			return fmt.Errorf("broadcastkit/ptz: invalid sony parameter: %#v", p)
+		}
+	}
+	return s.Client.SetCtx(ctx, sony.PtzfEndpoint, ps)
+}
+
+func (s *SonyCamera) PanTiltTo(ctx context.Context, pan, tilt, speed float64) error {
+	return s.set(ctx, sony.AbsolutePanTiltParam{
+		Pan:   s.degrees(pan),
+		Tilt:  s.degrees(tilt),
+		Speed: speedStep(speed),
+	})
+}
+
+func (s *SonyCamera) PanTilt(ctx context.Context, pan, tilt float64) error {
+	dir := sony.StopDirection
+	switch {
+	case pan > 0 && tilt > 0:
+		dir = sony.UpRightDirection
+	case pan > 0 && tilt < 0:
+		dir = sony.DownRightDirection
+	case pan < 0 && tilt > 0:
+		dir = sony.UpLeftDirection
+	case pan < 0 && tilt < 0:
+		dir = sony.DownLeftDirection
+	case pan > 0:
+		dir = sony.RightDirection
+	case pan < 0:
+		dir = sony.LeftDirection
+	case tilt > 0:
+		dir = sony.UpDirection
+	case tilt < 0:
+		dir = sony.DownDirection
+	}
+	return s.set(ctx, sony.PanTiltMoveParam{
+		Direction:       dir,
+		HorizontalSpeed: moveStep(pan),
+		VerticalSpeed:   moveStep(tilt),
+	})
+}
+
+func (s *SonyCamera) ptzf(ctx context.Context) (sony.AbsolutePTZFParam, error) {
+	ps, err := s.Client.InqCtx(ctx, sony.PtzfEndpoint)
+	if err != nil {
+		return sony.AbsolutePTZFParam{}, err
+	}
+	for _, p := range ps {
+		if p, ok := p.(sony.AbsolutePTZFParam); ok {
+			return p, nil
+		}
+	}
+	return sony.AbsolutePTZFParam{}, fmt.Errorf("broadcastkit/ptz: camera did not report AbsolutePTZF")
+}
+
+func (s *SonyCamera) ZoomTo(ctx context.Context, zoom float64) error {
+	p, err := s.ptzf(ctx)
+	if err != nil {
+		return err
+	}
+	p.Zoom = sony.SteppedRange(scale(zoom, int(sony.SteppedRangeZoomMax)))
+	return s.set(ctx, p)
+}
+
+func (s *SonyCamera) Zoom(ctx context.Context, speed float64) error {
+	dir := sony.StopZoomDirection
+	switch {
+	case speed > 0:
+		dir = sony.TeleDirection
+	case speed < 0:
+		dir = sony.WideDirection
+	}
+	return s.set(ctx, sony.ZoomMoveParam{
+		Direction: dir,
+		Speed:     sony.ZoomSpeed(scale(math.Abs(speed), int(sony.ZoomSpeedMax))),
+	})
+}
+
+func (s *SonyCamera) FocusTo(ctx context.Context, focus float64) error {
+	return s.set(ctx,
+		sony.FocusModeParam{Mode: sony.FocusModeManual},
+		sony.AbsoluteFocusParam{Position: sony.SteppedRange(scale(focus, int(sony.SteppedRangeFocusMax)))},
+	)
+}
+
+func (s *SonyCamera) Focus(ctx context.Context, speed float64) error {
+	return ErrUnsupported
+}
+
+func (s *SonyCamera) Position(ctx context.Context) (Position, error) {
+	p, err := s.ptzf(ctx)
+	if err != nil {
+		return Position{}, err
+	}
+	return Position{
+		Pan:   float64(p.Pan) / sony.SteppedPositionByDegree,
+		Tilt:  float64(p.Tilt) / sony.SteppedPositionByDegree,
+		Zoom:  unscale(int(p.Zoom), int(sony.SteppedRangeZoomMax)),
+		Focus: unscale(int(p.Focus), int(sony.SteppedRangeFocusMax)),
+	}, nil
+}
+
+func (s *SonyCamera) RecallPreset(ctx context.Context, preset int) error {
+	p := sony.PresetCallParam{Preset: sony.Preset(preset)}
+	if !p.Valid() {
+		return fmt.Errorf("broadcastkit/ptz: invalid preset: %d", preset)
+	}
+	return s.Client.SetCtx(ctx, sony.PresetpositionEndpoint, []sony.Parameter{p})
+}
+
+// StorePreset keeps the name of the preset set on the camera. Presets without
+// a name are named PRESET and their number.
+func (s *SonyCamera) StorePreset(ctx context.Context, preset int) error {
+	name := sony.PresetName(fmt.Sprintf("PRESET%d", preset))
+	ps, err := s.Client.InqCtx(ctx, sony.PresetpositionEndpoint)
+	if err != nil && ps == nil {
+		return err
+	}
+	for _, p := range ps {
+		if p, ok := p.(sony.PresetNameParam); ok && p.Names[sony.Preset(preset)] != "" {
+			name = p.Names[sony.Preset(preset)]
+		}
+	}
+	p := sony.PresetSetParam{
+		Preset:    sony.Preset(preset),
+		Name:      name,
+		Thumbnail: sony.SwitchOn,
+	}
+	if !p.Valid() {
+		return fmt.Errorf("broadcastkit/ptz: invalid preset: %d", preset)
+	}
+	return s.Client.SetCtx(ctx, sony.PresetpositionEndpoint, []sony.Parameter{p})
+}
+
+func (s *SonyCamera) SetTally(ctx context.Context, tally Tally) error {
+	red, green := sony.SwitchOff, sony.SwitchOff
+	switch tally {
+	case TallyOff:
+	case TallyProgram:
+		red = sony.SwitchOn
+	case TallyPreview:
+		green = sony.SwitchOn
+	default:
+		return fmt.Errorf("broadcastkit/ptz: invalid tally: %d", tally)
+	}
+	return s.Client.SetCtx(ctx, sony.SystemEndpoint, []sony.Parameter{
+		sony.TallyLampRedParam{Lamp: red},
+		sony.TallyLampGreenParam{Lamp: green},
+	})
+}
+
+func (s *SonyCamera) SetPower(ctx context.Context, on bool) error {
+	standby := sony.SwitchOn
+	if on {
+		standby = sony.SwitchOff
+	}
+	return s.Client.SetCtx(ctx, sony.SystemEndpoint, []sony.Parameter{
+		sony.PowerStandbyParam{Standby: standby},
+	})
+}
+
+var _ Camera = (*SonyCamera)(nil)
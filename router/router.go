@@ -0,0 +1,62 @@
+// Package router is a vendor-neutral interface for video routers.
+//
+// Sources and destinations are identified by the port numbers native to the
+// device, as used by the vendor packages. Labels are provided for display.
+package router
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrUnsupported is returned for operations not available on a router.
+var ErrUnsupported = errors.New("broadcastkit/router: operation not supported")
+
+// Port is a source or a destination of a router.
+type Port struct {
+	ID    int
+	Label string
+}
+
+// EventKind is the type of change reported by an Event.
+type EventKind int
+
+const (
+	// EventRoute reports Source being routed to Destination.
+	EventRoute EventKind = iota
+	// EventSourceLabel reports Label being assigned to Source.
+	EventSourceLabel
+	// EventDestinationLabel reports Label being assigned to Destination.
+	EventDestinationLabel
+)
+
+// Event is a change of the router state, whether caused by this client or
+// by anyone else.
+type Event struct {
+	Kind        EventKind
+	Destination int
+	Source      int
+	Label       string
+}
+
+// Router is a crosspoint router, switching sources to destinations.
+type Router interface {
+	// Sources lists the inputs of the router.
+	Sources(ctx context.Context) ([]Port, error)
+	// Destinations lists the outputs of the router.
+	Destinations(ctx context.Context) ([]Port, error)
+	// Routes returns the source of every destination.
+	Routes(ctx context.Context) (map[int]int, error)
+	// Route switches source to destination.
+	Route(ctx context.Context, destination, source int) error
+	// SetSourceLabel renames a source.
+	SetSourceLabel(ctx context.Context, source int, label string) error
+	// SetDestinationLabel renames a destination.
+	SetDestinationLabel(ctx context.Context, destination int, label string) error
+	// Watch calls fn for every change until ctx is done or the router fails.
+	// fn is called on the goroutine of Watch and may call the router, like
+	// Route in reaction to an event.
+	//
+	// Watch always returns a non-nil error.
+	Watch(ctx context.Context, fn func(Event)) error
+}
@@ -0,0 +1,115 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"time"
+
+	"puzzlekraken.com/broadcastkit/panasonic"
+)
+
+// Switcher adapts the AUX buses of a panasonic.SwitcherClient to the Router
+// interface.
+//
+// Destinations are the bus numbers, sources are the source numbers of the
+// switcher protocol. The switcher has no change notifications, Watch polls
+// every Interval, or every second if Interval is zero. Labels are fixed.
+type Switcher struct {
+	Client *panasonic.SwitcherClient
+	// Buses routed by this adapter, typically AUX buses like BUS_AUX1.
+	Buses []panasonic.Bus
+	// Inputs selectable on the buses. If empty, all known sources are used.
+	Inputs   []panasonic.Source
+	Interval time.Duration
+}
+
+func (s *Switcher) Sources(ctx context.Context) ([]Port, error) {
+	inputs := s.Inputs
+	if len(inputs) == 0 {
+		inputs = slices.Sorted(maps.Keys(panasonic.SourceNameMap))
+	}
+	ps := make([]Port, 0, len(inputs))
+	for _, src := range inputs {
+		ps = append(ps, Port{ID: int(src), Label: panasonic.SourceNameMap[src]})
+	}
+	return ps, nil
+}
+
+func (s *Switcher) Destinations(ctx context.Context) ([]Port, error) {
+	if len(s.Buses) == 0 {
+		return nil, errors.New("broadcastkit/router: switcher has no buses configured")
+	}
+	ps := make([]Port, 0, len(s.Buses))
+	for _, bus := range s.Buses {
+		ps = append(ps, Port{ID: int(bus), Label: panasonic.BusNameMap[bus]})
+	}
+	return ps, nil
+}
+
+func (s *Switcher) Routes(ctx context.Context) (map[int]int, error) {
+	if len(s.Buses) == 0 {
+		return nil, errors.New("broadcastkit/router: switcher has no buses configured")
+	}
+	r := make(map[int]int, len(s.Buses))
+	for _, bus := range s.Buses {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		src, err := s.Client.QueryBus(bus)
+		if err != nil {
+			return nil, err
+		}
+		r[int(bus)] = int(src)
+	}
+	return r, nil
+}
+
+func (s *Switcher) Route(ctx context.Context, destination, source int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.Client.SwitchBus(panasonic.Bus(destination), panasonic.Source(source))
+}
+
+func (s *Switcher) SetSourceLabel(ctx context.Context, source int, label string) error {
+	return ErrUnsupported
+}
+
+func (s *Switcher) SetDestinationLabel(ctx context.Context, destination int, label string) error {
+	return ErrUnsupported
+}
+
+func (s *Switcher) Watch(ctx context.Context, fn func(Event)) error {
+	interval := s.Interval
+	if interval == 0 {
+		interval = time.Second
+	}
+	last, err := s.Routes(ctx)
+	if err != nil {
+		return err
+	}
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+		}
+		now, err := s.Routes(ctx)
+		if err != nil {
+			return err
+		}
+		for _, bus := range s.Buses {
+			dst := int(bus)
+			if now[dst] != last[dst] {
+				fn(Event{Kind: EventRoute, Destination: dst, Source: now[dst]})
+			}
+		}
+		last = now
+	}
+}
+
+var _ Router = (*Switcher)(nil)
@@ -0,0 +1,128 @@
+package router
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"puzzlekraken.com/broadcastkit/panasonic"
+)
+
+// fakeSwitcher answers bus requests like a switcher with 8 sources.
+type fakeSwitcher struct {
+	lock  sync.Mutex
+	buses map[int]int
+	// queried receives the bus of every answered query.
+	queried chan int
+}
+
+func (f *fakeSwitcher) serve(t *testing.T) netip.AddrPort {
+	t.Helper()
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go f.handle(conn)
+		}
+	}()
+	return netip.MustParseAddrPort(l.Addr().String())
+}
+
+func (f *fakeSwitcher) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		msg, err := r.ReadString('\x03')
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(conn, "\x02%s\x03", f.answer(strings.Trim(msg, "\x02\x03")))
+	}
+}
+
+func (f *fakeSwitcher) answer(cmd string) string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	var bus, src int
+	if n, _ := fmt.Sscanf(cmd, "SBUS:%d:%d", &bus, &src); n == 2 {
+		if src > 8 {
+			return "EROR:1"
+		}
+		f.buses[bus] = src
+		return "A" + cmd[1:]
+	}
+	if n, _ := fmt.Sscanf(cmd, "QBSC:%d", &bus); n == 1 {
+		select {
+		case f.queried <- bus:
+		default:
+		}
+		return fmt.Sprintf("ABSC:%02d:%02d", bus, f.buses[bus])
+	}
+	return "EROR:2"
+}
+
+func TestSwitcher(t *testing.T) {
+	aux1, aux2 := int(panasonic.BUS_AUX1), int(panasonic.BUS_AUX1)+1
+	f := &fakeSwitcher{buses: map[int]int{aux1: 1, aux2: 2}, queried: make(chan int, 16)}
+	s := &Switcher{
+		Client:   &panasonic.SwitcherClient{Remote: f.serve(t)},
+		Buses:    []panasonic.Bus{panasonic.Bus(aux1), panasonic.Bus(aux2)},
+		Interval: 10 * time.Millisecond,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	routes, err := s.Routes(ctx)
+	if err != nil {
+		t.Fatalf("Routes() error = %v", err)
+	}
+	if want := map[int]int{aux1: 1, aux2: 2}; !reflect.DeepEqual(routes, want) {
+		t.Errorf("Routes() = %v, want %v", routes, want)
+	}
+
+	<-f.queried
+	<-f.queried
+
+	// Route once Watch queried the initial routes. The client answers
+	// commands in order, so the switch follows the initial query of aux2.
+	events := make(chan Event, 8)
+	go s.Watch(ctx, func(e Event) { events <- e })
+	for bus := range f.queried {
+		if bus == aux2 {
+			break
+		}
+	}
+
+	if err := s.Route(ctx, aux2, 5); err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	select {
+	case e := <-events:
+		want := Event{Kind: EventRoute, Destination: aux2, Source: 5}
+		if e != want {
+			t.Errorf("Watch() event = %v, want %v", e, want)
+		}
+	case <-ctx.Done():
+		t.Fatal("Watch() got no event")
+	}
+	if err := s.Route(ctx, aux1, 9); err == nil {
+		t.Errorf("Route() to missing source error = nil, want refusal")
+	}
+	if err := s.SetSourceLabel(ctx, 1, "CAM"); err != ErrUnsupported {
+		t.Errorf("SetSourceLabel() error = %v, want %v", err, ErrUnsupported)
+	}
+}
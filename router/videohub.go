@@ -0,0 +1,293 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"puzzlekraken.com/broadcastkit/blackmagicdesign"
+)
+
+// Videohub adapts a blackmagicdesign.VideohubSocket to the Router interface.
+//
+// Videohub reads the socket on a background goroutine from the first call,
+// the socket must not be read by anyone else. Ports are numbered from 0.
+//
+// Videohub must not be copied after first use.
+type Videohub struct {
+	Socket *blackmagicdesign.VideohubSocket
+
+	once     sync.Once
+	ready    chan struct{}
+	done     chan struct{}
+	err      error
+	wlock    sync.Mutex
+	lock     sync.Mutex
+	ack      chan bool
+	inputs   map[int]string
+	outputs  map[int]string
+	routing  map[int]int
+	watchers map[*videohubWatcher]struct{}
+}
+
+// videohubWatcher queues the events of one Watch call, so the read loop never
+// waits for a callback.
+type videohubWatcher struct {
+	lock   sync.Mutex
+	queue  []Event
+	signal chan struct{}
+}
+
+func (w *videohubWatcher) push(events []Event) {
+	w.lock.Lock()
+	w.queue = append(w.queue, events...)
+	w.lock.Unlock()
+	select {
+	case w.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (w *videohubWatcher) pop() []Event {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	q := w.queue
+	w.queue = nil
+	return q
+}
+
+func (v *Videohub) start() {
+	v.once.Do(func() {
+		v.ready = make(chan struct{})
+		v.done = make(chan struct{})
+		v.inputs = make(map[int]string)
+		v.outputs = make(map[int]string)
+		v.routing = make(map[int]int)
+		v.watchers = make(map[*videohubWatcher]struct{})
+		go v.readLoop()
+	})
+}
+
+func (v *Videohub) readLoop() {
+	var prelude bool
+	for {
+		block, err := v.Socket.Read()
+		if err != nil && block == nil {
+			v.lock.Lock()
+			v.err = err
+			v.lock.Unlock()
+			close(v.done)
+			return
+		}
+		// Partially valid blocks are still applied, see Routing.parse.
+		var events []Event
+		v.lock.Lock()
+		switch b := block.(type) {
+		case *blackmagicdesign.EndPreludeBlock:
+			if !prelude {
+				prelude = true
+				close(v.ready)
+			}
+		case *blackmagicdesign.VideohubDeviceBlock:
+			// Ports are numbered densely, labels fill in upon arrival.
+			for i := range b.VideoInputs {
+				if _, ok := v.inputs[i]; !ok {
+					v.inputs[i] = ""
+				}
+			}
+			for i := range b.VideoOutputs {
+				if _, ok := v.outputs[i]; !ok {
+					v.outputs[i] = ""
+				}
+			}
+		case *blackmagicdesign.InputLabelsBlock:
+			for n, l := range b.Labels {
+				if old, ok := v.inputs[n]; !ok || old != l {
+					v.inputs[n] = l
+					events = append(events, Event{Kind: EventSourceLabel, Source: n, Label: l})
+				}
+			}
+		case *blackmagicdesign.OutputLabelsBlock:
+			for n, l := range b.Labels {
+				if old, ok := v.outputs[n]; !ok || old != l {
+					v.outputs[n] = l
+					events = append(events, Event{Kind: EventDestinationLabel, Destination: n, Label: l})
+				}
+			}
+		case *blackmagicdesign.VideoOutputRoutingBlock:
+			for out, in := range b.Routing {
+				if old, ok := v.routing[out]; !ok || old != in {
+					v.routing[out] = in
+					events = append(events, Event{Kind: EventRoute, Destination: out, Source: in})
+				}
+			}
+		case *blackmagicdesign.AckBlock:
+			if v.ack != nil {
+				v.ack <- true
+				v.ack = nil
+			}
+		case *blackmagicdesign.NakBlock:
+			if v.ack != nil {
+				v.ack <- false
+				v.ack = nil
+			}
+		}
+		watchers := slices.Collect(maps.Keys(v.watchers))
+		v.lock.Unlock()
+
+		if !prelude || len(events) == 0 {
+			// The initial state dump is not a change.
+			continue
+		}
+		for _, w := range watchers {
+			w.push(events)
+		}
+	}
+}
+
+// wait blocks until the initial state has been received.
+func (v *Videohub) wait(ctx context.Context) error {
+	v.start()
+	select {
+	case <-v.ready:
+		return nil
+	case <-v.done:
+		return v.failure()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (v *Videohub) failure() error {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	return v.err
+}
+
+// request writes a change request and waits for the ACK or NAK.
+func (v *Videohub) request(ctx context.Context, block blackmagicdesign.VideohubBlock) error {
+	if err := v.wait(ctx); err != nil {
+		return err
+	}
+	v.wlock.Lock()
+	defer v.wlock.Unlock()
+	ack := make(chan bool, 1)
+	v.lock.Lock()
+	v.ack = ack
+	v.lock.Unlock()
+	defer func() {
+		v.lock.Lock()
+		v.ack = nil
+		v.lock.Unlock()
+	}()
+	if err := v.Socket.Write(block); err != nil {
+		return err
+	}
+	select {
+	case ok := <-ack:
+		if !ok {
+			return errors.New("broadcastkit/router: videohub refused request")
+		}
+		return nil
+	case <-v.done:
+		return v.failure()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func ports(m map[int]string) []Port {
+	ps := make([]Port, 0, len(m))
+	for _, id := range slices.Sorted(maps.Keys(m)) {
+		ps = append(ps, Port{ID: id, Label: m[id]})
+	}
+	return ps
+}
+
+func (v *Videohub) Sources(ctx context.Context) ([]Port, error) {
+	if err := v.wait(ctx); err != nil {
+		return nil, err
+	}
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	return ports(v.inputs), nil
+}
+
+func (v *Videohub) Destinations(ctx context.Context) ([]Port, error) {
+	if err := v.wait(ctx); err != nil {
+		return nil, err
+	}
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	return ports(v.outputs), nil
+}
+
+func (v *Videohub) Routes(ctx context.Context) (map[int]int, error) {
+	if err := v.wait(ctx); err != nil {
+		return nil, err
+	}
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	return maps.Clone(v.routing), nil
+}
+
+func (v *Videohub) Route(ctx context.Context, destination, source int) error {
+	return v.request(ctx, &blackmagicdesign.VideoOutputRoutingBlock{
+		Routing: blackmagicdesign.Routing{destination: source},
+	})
+}
+
+func (v *Videohub) SetSourceLabel(ctx context.Context, source int, label string) error {
+	return v.request(ctx, &blackmagicdesign.InputLabelsBlock{
+		Labels: blackmagicdesign.Labels{source: label},
+	})
+}
+
+func (v *Videohub) SetDestinationLabel(ctx context.Context, destination int, label string) error {
+	return v.request(ctx, &blackmagicdesign.OutputLabelsBlock{
+		Labels: blackmagicdesign.Labels{destination: label},
+	})
+}
+
+func (v *Videohub) Watch(ctx context.Context, fn func(Event)) error {
+	if err := v.wait(ctx); err != nil {
+		return err
+	}
+	return v.watch(ctx, v.subscribe(), fn)
+}
+
+// subscribe registers a watcher for the changes from now on.
+func (v *Videohub) subscribe() *videohubWatcher {
+	w := &videohubWatcher{signal: make(chan struct{}, 1)}
+	v.lock.Lock()
+	v.watchers[w] = struct{}{}
+	v.lock.Unlock()
+	return w
+}
+
+// watch calls fn for the events queued to w until ctx is done or the socket
+// fails, then unregisters w.
+func (v *Videohub) watch(ctx context.Context, w *videohubWatcher, fn func(Event)) error {
+	defer func() {
+		v.lock.Lock()
+		delete(v.watchers, w)
+		v.lock.Unlock()
+	}()
+	for {
+		select {
+		case <-v.done:
+			return fmt.Errorf("broadcastkit/router: videohub watch: %w", v.failure())
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-w.signal:
+		}
+		for _, e := range w.pop() {
+			fn(e)
+		}
+	}
+}
+
+var _ Router = (*Videohub)(nil)
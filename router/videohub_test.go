@@ -0,0 +1,102 @@
+package router
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"puzzlekraken.com/broadcastkit/blackmagicdesign"
+)
+
+// fakeVideohub answers route requests like a 4x2 Videohub device.
+func fakeVideohub(t *testing.T, conn net.Conn) {
+	dev := &blackmagicdesign.VideohubSocket{Conn: conn}
+	prelude := []blackmagicdesign.VideohubBlock{
+		&blackmagicdesign.VideohubDeviceBlock{DevicePresent: blackmagicdesign.DevicePresentTrue, VideoInputs: 4, VideoOutputs: 2},
+		&blackmagicdesign.InputLabelsBlock{Labels: blackmagicdesign.Labels{0: "CAM 1", 1: "CAM 2", 2: "CAM 3", 3: "CAM 4"}},
+		&blackmagicdesign.OutputLabelsBlock{Labels: blackmagicdesign.Labels{0: "REC", 1: "MON"}},
+		&blackmagicdesign.VideoOutputRoutingBlock{Routing: blackmagicdesign.Routing{0: 0, 1: 1}},
+		&blackmagicdesign.EndPreludeBlock{},
+	}
+	for _, b := range prelude {
+		if err := dev.Write(b); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	for {
+		b, err := dev.Read()
+		if err != nil {
+			return
+		}
+		r, ok := b.(*blackmagicdesign.VideoOutputRoutingBlock)
+		if !ok || r.Routing[1] > 3 {
+			dev.Write(&blackmagicdesign.NakBlock{})
+			continue
+		}
+		dev.Write(&blackmagicdesign.AckBlock{})
+		dev.Write(r)
+	}
+}
+
+func TestVideohub(t *testing.T) {
+	client, device := net.Pipe()
+	defer client.Close()
+	go fakeVideohub(t, device)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	v := &Videohub{Socket: &blackmagicdesign.VideohubSocket{Conn: client}}
+
+	src, err := v.Sources(ctx)
+	if err != nil {
+		t.Fatalf("Sources() error = %v", err)
+	}
+	if len(src) != 4 || src[2] != (Port{ID: 2, Label: "CAM 3"}) {
+		t.Errorf("Sources() = %v", src)
+	}
+
+	// Subscribe before routing, so the change cannot be missed. The callback
+	// routes in turn, which must not wait for the reader it runs beside.
+	events := make(chan Event, 8)
+	routed := make(chan error, 1)
+	w := v.subscribe()
+	go v.watch(ctx, w, func(e Event) {
+		events <- e
+		if e.Destination == 1 {
+			routed <- v.Route(ctx, 0, 2)
+		}
+	})
+
+	if err := v.Route(ctx, 1, 3); err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	for _, want := range []Event{
+		{Kind: EventRoute, Destination: 1, Source: 3},
+		{Kind: EventRoute, Destination: 0, Source: 2},
+	} {
+		select {
+		case e := <-events:
+			if e != want {
+				t.Errorf("Watch() event = %v, want %v", e, want)
+			}
+		case <-ctx.Done():
+			t.Fatalf("Watch() got no event %v", want)
+		}
+	}
+	if err := <-routed; err != nil {
+		t.Errorf("Route() from Watch callback error = %v", err)
+	}
+	routes, err := v.Routes(ctx)
+	if err != nil {
+		t.Fatalf("Routes() error = %v", err)
+	}
+	if want := map[int]int{0: 2, 1: 3}; !reflect.DeepEqual(routes, want) {
+		t.Errorf("Routes() = %v, want %v", routes, want)
+	}
+	if err := v.Route(ctx, 1, 9); err == nil {
+		t.Errorf("Route() to missing input error = nil, want refusal")
+	}
+}
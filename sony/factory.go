@@ -49,3 +49,16 @@ func createParameter(key string, val string) (Parameter, error) {
 	}
 	return p, nil
 }
+
+// ParseParameter creates a Parameter from its CGI key and value.
+//
+// Keys unknown to this package are returned as UnknownParameter. The returned
+// parameter is not checked for validity, see Parameter.Valid.
+func ParseParameter(key string, val string) (Parameter, error) {
+	return createParameter(key, val)
+}
+
+// FormatParameter returns the CGI key and value of p.
+func FormatParameter(p Parameter) (string, string) {
+	return p.parameterKey(), p.parameterValue()
+}
@@ -2,6 +2,7 @@ package sony
 
 import (
 	"fmt"
+	"net/netip"
 	"net/url"
 	"slices"
 	"strconv"
@@ -153,3 +154,26 @@ func hex16Encoder(i int) string {
 	e := hexEncoder(i)
 	return e[len(e)-4:]
 }
+
+// addrDecode parses an IP address, where an empty string is the zero Addr.
+func addrDecode(s string) (netip.Addr, error) {
+	if s == "" {
+		return netip.Addr{}, nil
+	}
+	return netip.ParseAddr(s)
+}
+
+// addrEncode formats an IP address, where the zero Addr is an empty string.
+func addrEncode(a netip.Addr) string {
+	if !a.IsValid() {
+		return ""
+	}
+	return a.String()
+}
+
+// maskValid reports whether a is a contiguous IPv4 network mask.
+func maskValid(a netip.Addr) bool {
+	b := a.As4()
+	m := uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+	return m&(^m>>1) == 0
+}
@@ -1,6 +1,7 @@
 package sony
 
 import (
+	"net/netip"
 	"testing"
 )
 
@@ -110,3 +111,26 @@ func TestHex20Encoder(t *testing.T) {
 		})
 	}
 }
+
+func TestMaskValid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{"class c", "255.255.255.0", true},
+		{"slash 20", "255.255.240.0", true},
+		{"all ones", "255.255.255.255", true},
+		{"zero", "0.0.0.0", true},
+		{"hole", "255.0.255.0", false},
+		{"trailing bit", "255.255.255.1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maskValid(netip.MustParseAddr(tt.input)); got != tt.want {
+				t.Errorf("maskValid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
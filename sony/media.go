@@ -0,0 +1,275 @@
+package sony
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const cliplistEndpoint Endpoint = "cliplist"
+
+// clipSlotParam selects the slot of the cliplist endpoint.
+type clipSlotParam MediaSlot
+
+func (clipSlotParam) parameterKey() string {
+	return "Slot"
+}
+func (s clipSlotParam) parameterValue() string {
+	return string(s)
+}
+func (clipSlotParam) parameterParse(s string) (Parameter, error) {
+	return clipSlotParam(s), nil
+}
+func (s clipSlotParam) Valid() bool {
+	return MediaSlot(s).Valid()
+}
+
+// clipNameValid reports whether s is usable within a clip name and URL path.
+func clipNameValid(s string) bool {
+	for _, c := range []byte(s) {
+		switch {
+		case c >= '0' && c <= '9':
+		case c >= 'A' && c <= 'Z':
+		case c >= 'a' && c <= 'z':
+		case c == '_' || c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// MediaSlotInfo is the state of a memory card slot.
+type MediaSlotInfo struct {
+	Slot      MediaSlot
+	Status    MediaStatus
+	Remaining time.Duration
+}
+
+// Clip is a recorded clip on the media of the camera.
+type Clip struct {
+	Slot        MediaSlot
+	Name        string
+	Size        int64
+	Duration    time.Duration
+	Created     time.Time
+	Format      string
+	Creator     string
+	Description string
+}
+
+// MediaSlots returns the status and the remaining capacity of all slots.
+func (c *CameraClient) MediaSlots(ctx context.Context) ([]MediaSlotInfo, error) {
+	ps, err := c.InqCtx(ctx, CameraoperationEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	a := MediaSlotInfo{Slot: MediaSlotA, Status: MediaStatusNone}
+	b := MediaSlotInfo{Slot: MediaSlotB, Status: MediaStatusNone}
+	for _, p := range ps {
+		switch p := p.(type) {
+		case MediaSlotAStatusParam:
+			a.Status = p.Status
+		case MediaSlotARemainParam:
+			a.Remaining = time.Duration(p.Minutes) * time.Minute
+		case MediaSlotBStatusParam:
+			b.Status = p.Status
+		case MediaSlotBRemainParam:
+			b.Remaining = time.Duration(p.Minutes) * time.Minute
+		}
+	}
+	return []MediaSlotInfo{a, b}, nil
+}
+
+// Clips lists the clips recorded on the media in slot.
+func (c *CameraClient) Clips(ctx context.Context, slot MediaSlot) ([]Clip, error) {
+	if !slot.Valid() {
+		return nil, fmt.Errorf("invalid media slot: %q", slot)
+	}
+	c.httpOnce.Do(c.httpInit)
+	res, err := c.http.Do(c.httpReq(ctx, cliplistEndpoint, clipSlotParam(slot)))
+	if err != nil {
+		return nil, fmt.Errorf("clip list error: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, &statusError{"unexpected status: " + res.Status, res.StatusCode}
+	}
+	raw, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("clip list read error: %w", err)
+	}
+	return parseClipList(slot, raw)
+}
+
+func parseClipList(slot MediaSlot, raw []byte) ([]Clip, error) {
+	data := struct {
+		Clips []struct {
+			Name        string `json:"name"`
+			Size        int64  `json:"size"`
+			Duration    int    `json:"duration"` // frames
+			FrameRate   int    `json:"frame_rate"`
+			Created     string `json:"creation_date"`
+			Format      string `json:"format"`
+			Creator     string `json:"creator"`
+			Description string `json:"description"`
+		} `json:"clips"`
+	}{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("clip list parse error: %w", err)
+	}
+	clips := make([]Clip, 0, len(data.Clips))
+	var errs []error
+	for _, d := range data.Clips {
+		if !clipNameValid(d.Name) || d.Name == "" {
+			errs = append(errs, fmt.Errorf("invalid clip name: %q", d.Name))
+			continue
+		}
+		clip := Clip{
+			Slot:        slot,
+			Name:        d.Name,
+			Size:        d.Size,
+			Format:      d.Format,
+			Creator:     d.Creator,
+			Description: d.Description,
+		}
+		if d.FrameRate > 0 {
+			clip.Duration = time.Duration(d.Duration) * time.Second / time.Duration(d.FrameRate)
+		}
+		if d.Created != "" {
+			t, err := time.Parse(time.RFC3339, d.Created)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			clip.Created = t
+		}
+		clips = append(clips, clip)
+	}
+	return clips, errors.Join(errs...)
+}
+
+// mediaGet fetches a file of the media without the overall client timeout.
+//
+// Clips may be gigabytes in size, the transfer is bounded by ctx only.
+func (c *CameraClient) mediaGet(ctx context.Context, ep Endpoint) (*http.Response, error) {
+	c.httpOnce.Do(c.httpInit)
+	client := http.Client{Transport: c.http.Transport}
+	res, err := client.Do(c.httpReq(ctx, ep))
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, &statusError{"unexpected status: " + res.Status, res.StatusCode}
+	}
+	return res, nil
+}
+
+// DownloadClip writes the essence file of clip to w.
+//
+// It returns the number of bytes written.
+func (c *CameraClient) DownloadClip(ctx context.Context, clip Clip, w io.Writer) (int64, error) {
+	if !clip.Slot.Valid() || !clipNameValid(clip.Name) || clip.Name == "" {
+		return 0, fmt.Errorf("invalid clip: %s/%q", clip.Slot, clip.Name)
+	}
+	res, err := c.mediaGet(ctx, Endpoint("/media/"+string(clip.Slot)+"/"+clip.Name+".MP4"))
+	if err != nil {
+		return 0, fmt.Errorf("clip download error: %w", err)
+	}
+	defer res.Body.Close()
+	n, err := io.Copy(w, res.Body)
+	if err != nil {
+		return n, fmt.Errorf("clip download error: %w", err)
+	}
+	return n, nil
+}
+
+// ClipThumbnail downloads the JPEG thumbnail of clip.
+func (c *CameraClient) ClipThumbnail(ctx context.Context, clip Clip) ([]byte, error) {
+	if !clip.Slot.Valid() || !clipNameValid(clip.Name) || clip.Name == "" {
+		return nil, fmt.Errorf("invalid clip: %s/%q", clip.Slot, clip.Name)
+	}
+	res, err := c.mediaGet(ctx, Endpoint("/media/"+string(clip.Slot)+"/"+clip.Name+"T01.JPG"))
+	if err != nil {
+		return nil, fmt.Errorf("thumbnail download error: %w", err)
+	}
+	defer res.Body.Close()
+	return io.ReadAll(res.Body)
+}
+
+// SetClipNaming sets the title prefix and the counter for the next clip.
+//
+// Metadata recorded into the new clips can be set by MediaClipCreatorParam and
+// MediaClipDescriptionParam.
+func (c *CameraClient) SetClipNaming(ctx context.Context, title string, number int) error {
+	t := MediaClipTitleParam{Title: title}
+	n := MediaClipNumberParam{Number: number}
+	if !t.Valid() || !n.Valid() {
+		return fmt.Errorf("invalid clip naming: %q %d", title, number)
+	}
+	return c.SetCtx(ctx, CameraoperationEndpoint, []Parameter{t, n})
+}
+
+// ErrFormatRefused is returned by FormatMedia when a safeguard prevents
+// formatting.
+var ErrFormatRefused = errors.New("broadcastkit/sony: format refused")
+
+// FormatMedia erases every clip on the media in slot.
+//
+// As a safeguard, the caller must state the names of the clips expected to be
+// lost, as returned by Clips. Formatting is refused if the clips on the media
+// differ, if the camera is recording, or if the media is not ready.
+func (c *CameraClient) FormatMedia(ctx context.Context, slot MediaSlot, expect []string) error {
+	if !slot.Valid() {
+		return fmt.Errorf("invalid media slot: %q", slot)
+	}
+	ps, err := c.InqCtx(ctx, CameraoperationEndpoint)
+	if err != nil {
+		return err
+	}
+	status := MediaStatusNone
+	for _, p := range ps {
+		switch p := p.(type) {
+		case MediaRecordingStatusParam:
+			if p.Status == RecordingRec {
+				return fmt.Errorf("%w: camera is recording", ErrFormatRefused)
+			}
+		case MediaSlotAStatusParam:
+			if slot == MediaSlotA {
+				status = p.Status
+			}
+		case MediaSlotBStatusParam:
+			if slot == MediaSlotB {
+				status = p.Status
+			}
+		}
+	}
+	if status != MediaStatusReady {
+		return fmt.Errorf("%w: media is %s", ErrFormatRefused, status)
+	}
+
+	clips, err := c.Clips(ctx, slot)
+	if err != nil {
+		return err
+	}
+	have := make(map[string]bool, len(clips))
+	for _, clip := range clips {
+		have[clip.Name] = true
+	}
+	want := make(map[string]bool, len(expect))
+	for _, name := range expect {
+		if !have[name] {
+			return fmt.Errorf("%w: clip %q not found on media", ErrFormatRefused, name)
+		}
+		want[name] = true
+	}
+	if len(have) != len(want) {
+		return fmt.Errorf("%w: media holds %d clips, expected %d", ErrFormatRefused, len(have), len(want))
+	}
+
+	return c.SetCtx(ctx, CameraoperationEndpoint, []Parameter{MediaFormatParam{Slot: slot}})
+}
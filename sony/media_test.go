@@ -0,0 +1,84 @@
+package sony
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestParseClipList(t *testing.T) {
+	raw := []byte(`{"clips":[
+		{"name":"CAM1_0001","size":1048576,"duration":250,"frame_rate":25,"creation_date":"2024-05-01T10:00:00Z","format":"XAVC-HS"},
+		{"name":"../etc","size":1}
+	]}`)
+	clips, err := parseClipList(MediaSlotA, raw)
+	if err == nil {
+		t.Errorf("parseClipList() error = nil, want invalid clip name")
+	}
+	if len(clips) != 1 {
+		t.Fatalf("parseClipList() returned %d clips, want 1", len(clips))
+	}
+	want := Clip{
+		Slot:     MediaSlotA,
+		Name:     "CAM1_0001",
+		Size:     1048576,
+		Duration: 10 * time.Second,
+		Created:  time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		Format:   "XAVC-HS",
+	}
+	if clips[0] != want {
+		t.Errorf("parseClipList() = %+v, want %+v", clips[0], want)
+	}
+}
+
+func TestFormatMedia(t *testing.T) {
+	var formatted bool
+	var recording string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/command/inquiry.cgi":
+			w.Write([]byte("MediaRecordingStatus=" + recording + "&MediaSlotAStatus=ready&MediaSlotBStatus=none"))
+		case "/command/cliplist.cgi":
+			w.Write([]byte(`{"clips":[{"name":"C0001"},{"name":"C0002"}]}`))
+		case "/command/cameraoperation.cgi":
+			formatted = r.URL.Query().Get("MediaFormat") != ""
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	c := &CameraClient{Remote: netip.MustParseAddrPort(srv.Listener.Addr().String())}
+
+	tests := []struct {
+		name      string
+		recording string
+		slot      MediaSlot
+		expect    []string
+		refused   bool
+	}{
+		{"matching clips", "standby", MediaSlotA, []string{"C0002", "C0001"}, false},
+		{"missing clip", "standby", MediaSlotA, []string{"C0001"}, true},
+		{"duplicate clip", "standby", MediaSlotA, []string{"C0001", "C0001"}, true},
+		{"unknown clip", "standby", MediaSlotA, []string{"C0001", "C0003"}, true},
+		{"recording", "rec", MediaSlotA, []string{"C0001", "C0002"}, true},
+		{"no media", "standby", MediaSlotB, []string{"C0001", "C0002"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatted = false
+			recording = tt.recording
+			err := c.FormatMedia(context.Background(), tt.slot, tt.expect)
+			if refused := errors.Is(err, ErrFormatRefused); refused != tt.refused {
+				t.Errorf("FormatMedia() error = %v, want refused %v", err, tt.refused)
+			}
+			if formatted == tt.refused {
+				t.Errorf("FormatMedia() formatted = %v, want %v", formatted, !tt.refused)
+			}
+		})
+	}
+}
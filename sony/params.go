@@ -3,6 +3,8 @@ package sony
 import (
 	"errors"
 	"fmt"
+	"net"
+	"net/netip"
 	"slices"
 	"strings"
 )
@@ -677,6 +679,113 @@ func init() {
 	registerParameter(func() Parameter { return FocusPushAFMFParam{} })
 }
 
+// FocusArea is the region of the image used by auto focus.
+type FocusArea string
+
+const (
+	FocusAreaWide          FocusArea = "wide"
+	FocusAreaZone          FocusArea = "zone"
+	FocusAreaFlexibleSpotS FocusArea = "flexible-spot-s"
+	FocusAreaFlexibleSpotM FocusArea = "flexible-spot-m"
+	FocusAreaFlexibleSpotL FocusArea = "flexible-spot-l"
+)
+
+func (f FocusArea) Valid() bool {
+	switch f {
+	case FocusAreaWide,
+		FocusAreaZone,
+		FocusAreaFlexibleSpotS,
+		FocusAreaFlexibleSpotM,
+		FocusAreaFlexibleSpotL:
+		return true
+	default:
+		return false
+	}
+}
+
+type FocusAreaParam struct {
+	Area FocusArea
+}
+
+func (FocusAreaParam) parameterKey() string {
+	return "FocusArea"
+}
+func (p FocusAreaParam) parameterValue() string {
+	return string(p.Area)
+}
+func (FocusAreaParam) parameterParse(s string) (Parameter, error) {
+	return FocusAreaParam{
+		Area: FocusArea(s),
+	}, nil
+}
+func (p FocusAreaParam) Valid() bool {
+	return p.Area.Valid()
+}
+func (FocusAreaParam) _ptzfParameter() {}
+func init() {
+	registerParameter(func() Parameter { return FocusAreaParam{} })
+}
+
+// FaceDetection selects how detected faces and eyes drive auto focus.
+type FaceDetection string
+
+const (
+	FaceDetectionOff      FaceDetection = "off"
+	FaceDetectionPriority FaceDetection = "face-eye-priority"
+	FaceDetectionOnly     FaceDetection = "face-eye-only"
+)
+
+func (f FaceDetection) Valid() bool {
+	return f == FaceDetectionOff || f == FaceDetectionPriority || f == FaceDetectionOnly
+}
+
+type FocusFaceEyeDetectionParam struct {
+	Detection FaceDetection
+}
+
+func (FocusFaceEyeDetectionParam) parameterKey() string {
+	return "FocusFaceEyeDetection"
+}
+func (p FocusFaceEyeDetectionParam) parameterValue() string {
+	return string(p.Detection)
+}
+func (FocusFaceEyeDetectionParam) parameterParse(s string) (Parameter, error) {
+	return FocusFaceEyeDetectionParam{
+		Detection: FaceDetection(s),
+	}, nil
+}
+func (p FocusFaceEyeDetectionParam) Valid() bool {
+	return p.Detection.Valid()
+}
+func (FocusFaceEyeDetectionParam) _ptzfParameter() {}
+func init() {
+	registerParameter(func() Parameter { return FocusFaceEyeDetectionParam{} })
+}
+
+// FocusTrackingParam keeps focus on the subject under the focus area.
+type FocusTrackingParam struct {
+	Tracking Switch
+}
+
+func (FocusTrackingParam) parameterKey() string {
+	return "FocusTracking"
+}
+func (p FocusTrackingParam) parameterValue() string {
+	return string(p.Tracking)
+}
+func (FocusTrackingParam) parameterParse(s string) (Parameter, error) {
+	return FocusTrackingParam{
+		Tracking: Switch(s),
+	}, nil
+}
+func (p FocusTrackingParam) Valid() bool {
+	return p.Tracking.Valid()
+}
+func (FocusTrackingParam) _ptzfParameter() {}
+func init() {
+	registerParameter(func() Parameter { return FocusTrackingParam{} })
+}
+
 //
 // Parameters for presetpositionEndpoint
 //
@@ -1054,6 +1163,192 @@ func init() {
 	registerParameter(func() Parameter { return CameraNameParam{} })
 }
 
+type IPAddressParam struct {
+	Addr netip.Addr
+}
+
+func (IPAddressParam) parameterKey() string {
+	return "IPAddress"
+}
+func (p IPAddressParam) parameterValue() string {
+	return addrEncode(p.Addr)
+}
+func (IPAddressParam) parameterParse(s string) (Parameter, error) {
+	v, err := addrDecode(s)
+	if err != nil {
+		return nil, err
+	}
+	return IPAddressParam{
+		Addr: v,
+	}, nil
+}
+func (p IPAddressParam) Valid() bool {
+	return p.Addr.Is4() && !p.Addr.IsUnspecified()
+}
+func (IPAddressParam) _networkParameter() {}
+func init() {
+	registerParameter(func() Parameter { return IPAddressParam{} })
+}
+
+type SubnetMaskParam struct {
+	Mask netip.Addr
+}
+
+func (SubnetMaskParam) parameterKey() string {
+	return "SubnetMask"
+}
+func (p SubnetMaskParam) parameterValue() string {
+	return addrEncode(p.Mask)
+}
+func (SubnetMaskParam) parameterParse(s string) (Parameter, error) {
+	v, err := addrDecode(s)
+	if err != nil {
+		return nil, err
+	}
+	return SubnetMaskParam{
+		Mask: v,
+	}, nil
+}
+func (p SubnetMaskParam) Valid() bool {
+	return p.Mask.Is4() && maskValid(p.Mask)
+}
+func (SubnetMaskParam) _networkParameter() {}
+func init() {
+	registerParameter(func() Parameter { return SubnetMaskParam{} })
+}
+
+type DefaultGatewayParam struct {
+	Gateway netip.Addr
+}
+
+func (DefaultGatewayParam) parameterKey() string {
+	return "DefaultGateway"
+}
+func (p DefaultGatewayParam) parameterValue() string {
+	return addrEncode(p.Gateway)
+}
+func (DefaultGatewayParam) parameterParse(s string) (Parameter, error) {
+	v, err := addrDecode(s)
+	if err != nil {
+		return nil, err
+	}
+	return DefaultGatewayParam{
+		Gateway: v,
+	}, nil
+}
+func (p DefaultGatewayParam) Valid() bool {
+	return p.Gateway.Is4()
+}
+func (DefaultGatewayParam) _networkParameter() {}
+func init() {
+	registerParameter(func() Parameter { return DefaultGatewayParam{} })
+}
+
+type DhcpEnableParam struct {
+	Enable Switch
+}
+
+func (DhcpEnableParam) parameterKey() string {
+	return "DhcpEnable"
+}
+func (p DhcpEnableParam) parameterValue() string {
+	return string(p.Enable)
+}
+func (DhcpEnableParam) parameterParse(s string) (Parameter, error) {
+	return DhcpEnableParam{
+		Enable: Switch(s),
+	}, nil
+}
+func (p DhcpEnableParam) Valid() bool {
+	return p.Enable.Valid()
+}
+func (DhcpEnableParam) _networkParameter() {}
+func init() {
+	registerParameter(func() Parameter { return DhcpEnableParam{} })
+}
+
+type PrimaryDnsParam struct {
+	Addr netip.Addr
+}
+
+func (PrimaryDnsParam) parameterKey() string {
+	return "PrimaryDns"
+}
+func (p PrimaryDnsParam) parameterValue() string {
+	return addrEncode(p.Addr)
+}
+func (PrimaryDnsParam) parameterParse(s string) (Parameter, error) {
+	v, err := addrDecode(s)
+	if err != nil {
+		return nil, err
+	}
+	return PrimaryDnsParam{
+		Addr: v,
+	}, nil
+}
+func (p PrimaryDnsParam) Valid() bool {
+	return p.Addr.Is4()
+}
+func (PrimaryDnsParam) _networkParameter() {}
+func init() {
+	registerParameter(func() Parameter { return PrimaryDnsParam{} })
+}
+
+type SecondaryDnsParam struct {
+	Addr netip.Addr
+}
+
+func (SecondaryDnsParam) parameterKey() string {
+	return "SecondaryDns"
+}
+func (p SecondaryDnsParam) parameterValue() string {
+	return addrEncode(p.Addr)
+}
+func (SecondaryDnsParam) parameterParse(s string) (Parameter, error) {
+	v, err := addrDecode(s)
+	if err != nil {
+		return nil, err
+	}
+	return SecondaryDnsParam{
+		Addr: v,
+	}, nil
+}
+func (p SecondaryDnsParam) Valid() bool {
+	return p.Addr.Is4()
+}
+func (SecondaryDnsParam) _networkParameter() {}
+func init() {
+	registerParameter(func() Parameter { return SecondaryDnsParam{} })
+}
+
+// MacAddressParam is read-only, the camera rejects attempts to set it.
+type MacAddressParam struct {
+	Mac net.HardwareAddr
+}
+
+func (MacAddressParam) parameterKey() string {
+	return "MacAddress"
+}
+func (p MacAddressParam) parameterValue() string {
+	return p.Mac.String()
+}
+func (MacAddressParam) parameterParse(s string) (Parameter, error) {
+	v, err := net.ParseMAC(s)
+	if err != nil {
+		return nil, err
+	}
+	return MacAddressParam{
+		Mac: v,
+	}, nil
+}
+func (p MacAddressParam) Valid() bool {
+	return len(p.Mac) == 6
+}
+func (MacAddressParam) _networkParameter() {}
+func init() {
+	registerParameter(func() Parameter { return MacAddressParam{} })
+}
+
 //
 // Parameters for ImagingEndpoint
 //
@@ -1598,192 +1893,1444 @@ func init() {
 	registerParameter(func() Parameter { return ExposureExposureTimeParam{} })
 }
 
-//
-// Parameters for project endpoint
-//
-
-type Frequency int
+// PictureProfile is one of the stored picture profiles PP1 to PP11.
+type PictureProfile string
 
 const (
-	Freq_59_94_Hz Frequency = 5994
-	Freq_50_00_Hz Frequency = 5000
-	Freq_29_97_Hz Frequency = 2997
-	Freq_25_00_Hz Frequency = 2500
-	Freq_24_00_Hz Frequency = 2400
-	Freq_23_98_Hz Frequency = 2398
+	PictureProfileOff PictureProfile = "off"
+	PictureProfile1   PictureProfile = "pp1"
+	PictureProfile2   PictureProfile = "pp2"
+	PictureProfile3   PictureProfile = "pp3"
+	PictureProfile4   PictureProfile = "pp4"
+	PictureProfile5   PictureProfile = "pp5"
+	PictureProfile6   PictureProfile = "pp6"
+	PictureProfile7   PictureProfile = "pp7"
+	PictureProfile8   PictureProfile = "pp8"
+	PictureProfile9   PictureProfile = "pp9"
+	PictureProfile10  PictureProfile = "pp10"
+	PictureProfile11  PictureProfile = "pp11"
 )
 
-func (p Frequency) Valid() bool {
-	return p == Freq_59_94_Hz || p == Freq_50_00_Hz || p == Freq_29_97_Hz || p == Freq_25_00_Hz || p == Freq_24_00_Hz || p == Freq_23_98_Hz
+func (p PictureProfile) Valid() bool {
+	if p == PictureProfileOff {
+		return true
+	}
+	n, ok := strings.CutPrefix(string(p), "pp")
+	if !ok {
+		return false
+	}
+	i, err := atoi(n)
+	return err == nil && i >= 1 && i <= 11
 }
 
-type RecFormatFrequencyParam struct {
-	Frequency Frequency
+type PictureProfileParam struct {
+	Profile PictureProfile
 }
 
-func (p RecFormatFrequencyParam) parameterKey() string {
-	return "RecFormatFrequency"
+func (PictureProfileParam) parameterKey() string {
+	return "PictureProfile"
 }
-
-func (p RecFormatFrequencyParam) parameterValue() string {
-	return itoa(int(p.Frequency))
+func (p PictureProfileParam) parameterValue() string {
+	return string(p.Profile)
 }
-
-func (RecFormatFrequencyParam) parameterParse(s string) (Parameter, error) {
-	i, err := atoi(s)
-	if err != nil {
-		return nil, err
-	}
-	return RecFormatFrequencyParam{
-		Frequency: Frequency(i),
+func (PictureProfileParam) parameterParse(s string) (Parameter, error) {
+	return PictureProfileParam{
+		Profile: PictureProfile(s),
 	}, nil
 }
-
-func (p RecFormatFrequencyParam) Valid() bool {
-	return p.Frequency.Valid()
+func (p PictureProfileParam) Valid() bool {
+	return p.Profile.Valid()
 }
-
-func (p RecFormatFrequencyParam) _projectParameter() {}
+func (PictureProfileParam) _imagingParameter() {}
 
 func init() {
-	registerParameter(func() Parameter { return RecFormatFrequencyParam{} })
+	registerParameter(func() Parameter { return PictureProfileParam{} })
 }
 
-//
-// Parameters for CameraoperationEndpoint
-//
-
-type Activator string
+// Gamma is the transfer curve applied by the active picture profile.
+type Gamma string
 
 const (
-	Inactive Activator = "inactive"
-	Active   Activator = "active"
+	GammaStandard  Gamma = "standard"
+	GammaStill     Gamma = "still"
+	GammaSCinetone Gamma = "s-cinetone"
+	GammaCine1     Gamma = "cine1"
+	GammaCine2     Gamma = "cine2"
+	GammaCine3     Gamma = "cine3"
+	GammaCine4     Gamma = "cine4"
+	GammaITU709    Gamma = "itu709"
+	GammaITU709x   Gamma = "itu709x"
+	GammaSLog2     Gamma = "s-log2"
+	GammaSLog3     Gamma = "s-log3"
+	GammaHLG       Gamma = "hlg"
+	GammaHLG1      Gamma = "hlg1"
+	GammaHLG2      Gamma = "hlg2"
+	GammaHLG3      Gamma = "hlg3"
 )
 
-type CamMenuParam struct {
-	On Activator
+func (g Gamma) Valid() bool {
+	return slices.Contains([]Gamma{
+		GammaStandard, GammaStill, GammaSCinetone,
+		GammaCine1, GammaCine2, GammaCine3, GammaCine4,
+		GammaITU709, GammaITU709x, GammaSLog2, GammaSLog3,
+		GammaHLG, GammaHLG1, GammaHLG2, GammaHLG3,
+	}, g)
 }
 
-func (p CamMenuParam) parameterKey() string {
-	return "CamMenu"
+type PictureProfileGammaParam struct {
+	Gamma Gamma
 }
 
-func (p CamMenuParam) parameterValue() string {
-	return string(p.On)
+func (PictureProfileGammaParam) parameterKey() string {
+	return "PictureProfileGamma"
 }
-
-func (CamMenuParam) parameterParse(s string) (Parameter, error) {
-	return CamMenuParam{
-		On: Activator(s),
+func (p PictureProfileGammaParam) parameterValue() string {
+	return string(p.Gamma)
+}
+func (PictureProfileGammaParam) parameterParse(s string) (Parameter, error) {
+	return PictureProfileGammaParam{
+		Gamma: Gamma(s),
 	}, nil
 }
-
-func (p CamMenuParam) Valid() bool {
-	return p.On == Active || p.On == Inactive
+func (p PictureProfileGammaParam) Valid() bool {
+	return p.Gamma.Valid()
 }
-
-func (p CamMenuParam) _cameraoperationParameter() {}
+func (PictureProfileGammaParam) _imagingParameter() {}
 
 func init() {
-	registerParameter(func() Parameter { return CamMenuParam{} })
-}
-
-type CamMenuSelectorParam struct {
-	Dir   Direction
-	State Button
+	registerParameter(func() Parameter { return PictureProfileGammaParam{} })
 }
 
-func (p CamMenuSelectorParam) parameterKey() string {
-	return "CamMenuSelector"
-}
+// ColorSpace is the color gamut applied by the active picture profile.
+type ColorSpace string
 
-func (p CamMenuSelectorParam) parameterValue() string {
-	return commaJoin(string(p.Dir), string(p.State))
-}
+const (
+	ColorSpaceBT709       ColorSpace = "bt.709"
+	ColorSpaceBT2020      ColorSpace = "bt.2020"
+	ColorSpaceSGamut3     ColorSpace = "s-gamut3"
+	ColorSpaceSGamut3Cine ColorSpace = "s-gamut3.cine"
+)
 
-func (p CamMenuSelectorParam) parameterParse(s string) (Parameter, error) {
-	sp := commaSplit(s)
-	if len(sp) != 2 {
-		return nil, fmt.Errorf("invalid comma-joined-list length: %d, expects 2", len(sp))
+func (c ColorSpace) Valid() bool {
+	switch c {
+	case ColorSpaceBT709, ColorSpaceBT2020, ColorSpaceSGamut3, ColorSpaceSGamut3Cine:
+		return true
+	default:
+		return false
 	}
-	p.Dir = Direction(sp[0])
-	p.State = Button(sp[1])
-	return p, nil
 }
 
-func (p CamMenuSelectorParam) Valid() bool {
-	return p.Dir.Valid() && p.State.Valid()
+type PictureProfileColorSpaceParam struct {
+	Space ColorSpace
 }
 
-func (p CamMenuSelectorParam) _cameraoperationParameter() {}
+func (PictureProfileColorSpaceParam) parameterKey() string {
+	return "PictureProfileColorSpace"
+}
+func (p PictureProfileColorSpaceParam) parameterValue() string {
+	return string(p.Space)
+}
+func (PictureProfileColorSpaceParam) parameterParse(s string) (Parameter, error) {
+	return PictureProfileColorSpaceParam{
+		Space: ColorSpace(s),
+	}, nil
+}
+func (p PictureProfileColorSpaceParam) Valid() bool {
+	return p.Space.Valid()
+}
+func (PictureProfileColorSpaceParam) _imagingParameter() {}
 
 func init() {
-	registerParameter(func() Parameter { return CamMenuSelectorParam{} })
+	registerParameter(func() Parameter { return PictureProfileColorSpaceParam{} })
 }
 
-type MediaRecordingParam struct {
-	Recording Button
+type PictureProfileBlackLevelParam struct {
+	Level int
 }
 
-func (p MediaRecordingParam) parameterKey() string {
-	return "MediaRecording"
+func (PictureProfileBlackLevelParam) parameterKey() string {
+	return "PictureProfileBlackLevel"
 }
-
-func (p MediaRecordingParam) parameterValue() string {
-	return string(p.Recording)
+func (p PictureProfileBlackLevelParam) parameterValue() string {
+	return itoa(p.Level)
 }
-
-func (MediaRecordingParam) parameterParse(s string) (Parameter, error) {
-	return MediaRecordingParam{
-		Recording: Button(s),
+func (PictureProfileBlackLevelParam) parameterParse(s string) (Parameter, error) {
+	v, err := atoi(s)
+	if err != nil {
+		return nil, err
+	}
+	return PictureProfileBlackLevelParam{
+		Level: v,
 	}, nil
 }
-
-func (p MediaRecordingParam) Valid() bool {
-	return p.Recording.Valid()
+func (p PictureProfileBlackLevelParam) Valid() bool {
+	return p.Level >= -99 && p.Level <= 99
 }
-
-func (p MediaRecordingParam) _cameraoperationParameter() {}
+func (PictureProfileBlackLevelParam) _imagingParameter() {}
 
 func init() {
-	registerParameter(func() Parameter { return MediaRecordingParam{} })
+	registerParameter(func() Parameter { return PictureProfileBlackLevelParam{} })
 }
 
-type RecordingStatus string
+type PictureProfileDetailLevelParam struct {
+	Level int
+}
 
-const (
+func (PictureProfileDetailLevelParam) parameterKey() string {
+	return "PictureProfileDetailLevel"
+}
+func (p PictureProfileDetailLevelParam) parameterValue() string {
+	return itoa(p.Level)
+}
+func (PictureProfileDetailLevelParam) parameterParse(s string) (Parameter, error) {
+	v, err := atoi(s)
+	if err != nil {
+		return nil, err
+	}
+	return PictureProfileDetailLevelParam{
+		Level: v,
+	}, nil
+}
+func (p PictureProfileDetailLevelParam) Valid() bool {
+	return p.Level >= -7 && p.Level <= 7
+}
+func (PictureProfileDetailLevelParam) _imagingParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return PictureProfileDetailLevelParam{} })
+}
+
+// NDMode selects between the three ND presets and the variable ND.
+type NDMode string
+
+const (
+	NDModePreset   NDMode = "preset"
+	NDModeVariable NDMode = "variable"
+)
+
+func (n NDMode) Valid() bool {
+	return n == NDModePreset || n == NDModeVariable
+}
+
+type ExposureNDModeParam struct {
+	Mode NDMode
+}
+
+func (ExposureNDModeParam) parameterKey() string {
+	return "ExposureNDMode"
+}
+func (p ExposureNDModeParam) parameterValue() string {
+	return string(p.Mode)
+}
+func (ExposureNDModeParam) parameterParse(s string) (Parameter, error) {
+	return ExposureNDModeParam{
+		Mode: NDMode(s),
+	}, nil
+}
+func (p ExposureNDModeParam) Valid() bool {
+	return p.Mode.Valid()
+}
+func (ExposureNDModeParam) _imagingParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return ExposureNDModeParam{} })
+}
+
+// NDPreset is the number of an ND preset slot, valid between 1 and 3.
+type NDPreset int
+
+func (n NDPreset) Valid() bool {
+	return n >= 1 && n <= 3
+}
+
+type ExposureNDPresetSelectParam struct {
+	Preset NDPreset
+}
+
+func (ExposureNDPresetSelectParam) parameterKey() string {
+	return "ExposureNDPresetSelect"
+}
+func (p ExposureNDPresetSelectParam) parameterValue() string {
+	return itoa(int(p.Preset))
+}
+func (ExposureNDPresetSelectParam) parameterParse(s string) (Parameter, error) {
+	i, err := atoi(s)
+	if err != nil {
+		return nil, err
+	}
+	v := NDPreset(i)
+	return ExposureNDPresetSelectParam{
+		Preset: v,
+	}, nil
+}
+func (p ExposureNDPresetSelectParam) Valid() bool {
+	return p.Preset.Valid()
+}
+func (ExposureNDPresetSelectParam) _imagingParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return ExposureNDPresetSelectParam{} })
+}
+
+type ExposureNDPreset1Param struct {
+	Level NDLevel
+}
+
+func (ExposureNDPreset1Param) parameterKey() string {
+	return "ExposureNDPreset1"
+}
+func (p ExposureNDPreset1Param) parameterValue() string {
+	return itoa(int(p.Level))
+}
+func (ExposureNDPreset1Param) parameterParse(s string) (Parameter, error) {
+	i, err := atoi(s)
+	if err != nil {
+		return nil, err
+	}
+	v := NDLevel(i)
+	return ExposureNDPreset1Param{
+		Level: v,
+	}, nil
+}
+func (p ExposureNDPreset1Param) Valid() bool {
+	return p.Level.Valid()
+}
+func (ExposureNDPreset1Param) _imagingParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return ExposureNDPreset1Param{} })
+}
+
+type ExposureNDPreset2Param struct {
+	Level NDLevel
+}
+
+func (ExposureNDPreset2Param) parameterKey() string {
+	return "ExposureNDPreset2"
+}
+func (p ExposureNDPreset2Param) parameterValue() string {
+	return itoa(int(p.Level))
+}
+func (ExposureNDPreset2Param) parameterParse(s string) (Parameter, error) {
+	i, err := atoi(s)
+	if err != nil {
+		return nil, err
+	}
+	v := NDLevel(i)
+	return ExposureNDPreset2Param{
+		Level: v,
+	}, nil
+}
+func (p ExposureNDPreset2Param) Valid() bool {
+	return p.Level.Valid()
+}
+func (ExposureNDPreset2Param) _imagingParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return ExposureNDPreset2Param{} })
+}
+
+type ExposureNDPreset3Param struct {
+	Level NDLevel
+}
+
+func (ExposureNDPreset3Param) parameterKey() string {
+	return "ExposureNDPreset3"
+}
+func (p ExposureNDPreset3Param) parameterValue() string {
+	return itoa(int(p.Level))
+}
+func (ExposureNDPreset3Param) parameterParse(s string) (Parameter, error) {
+	i, err := atoi(s)
+	if err != nil {
+		return nil, err
+	}
+	v := NDLevel(i)
+	return ExposureNDPreset3Param{
+		Level: v,
+	}, nil
+}
+func (p ExposureNDPreset3Param) Valid() bool {
+	return p.Level.Valid()
+}
+func (ExposureNDPreset3Param) _imagingParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return ExposureNDPreset3Param{} })
+}
+
+//
+// Parameters for project endpoint
+//
+
+type Frequency int
+
+const (
+	Freq_59_94_Hz Frequency = 5994
+	Freq_50_00_Hz Frequency = 5000
+	Freq_29_97_Hz Frequency = 2997
+	Freq_25_00_Hz Frequency = 2500
+	Freq_24_00_Hz Frequency = 2400
+	Freq_23_98_Hz Frequency = 2398
+)
+
+func (p Frequency) Valid() bool {
+	return p == Freq_59_94_Hz || p == Freq_50_00_Hz || p == Freq_29_97_Hz || p == Freq_25_00_Hz || p == Freq_24_00_Hz || p == Freq_23_98_Hz
+}
+
+type RecFormatFrequencyParam struct {
+	Frequency Frequency
+}
+
+func (p RecFormatFrequencyParam) parameterKey() string {
+	return "RecFormatFrequency"
+}
+
+func (p RecFormatFrequencyParam) parameterValue() string {
+	return itoa(int(p.Frequency))
+}
+
+func (RecFormatFrequencyParam) parameterParse(s string) (Parameter, error) {
+	i, err := atoi(s)
+	if err != nil {
+		return nil, err
+	}
+	return RecFormatFrequencyParam{
+		Frequency: Frequency(i),
+	}, nil
+}
+
+func (p RecFormatFrequencyParam) Valid() bool {
+	return p.Frequency.Valid()
+}
+
+func (p RecFormatFrequencyParam) _projectParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return RecFormatFrequencyParam{} })
+}
+
+//
+// Parameters for CameraoperationEndpoint
+//
+
+type Activator string
+
+const (
+	Inactive Activator = "inactive"
+	Active   Activator = "active"
+)
+
+type CamMenuParam struct {
+	On Activator
+}
+
+func (p CamMenuParam) parameterKey() string {
+	return "CamMenu"
+}
+
+func (p CamMenuParam) parameterValue() string {
+	return string(p.On)
+}
+
+func (CamMenuParam) parameterParse(s string) (Parameter, error) {
+	return CamMenuParam{
+		On: Activator(s),
+	}, nil
+}
+
+func (p CamMenuParam) Valid() bool {
+	return p.On == Active || p.On == Inactive
+}
+
+func (p CamMenuParam) _cameraoperationParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return CamMenuParam{} })
+}
+
+type CamMenuSelectorParam struct {
+	Dir   Direction
+	State Button
+}
+
+func (p CamMenuSelectorParam) parameterKey() string {
+	return "CamMenuSelector"
+}
+
+func (p CamMenuSelectorParam) parameterValue() string {
+	return commaJoin(string(p.Dir), string(p.State))
+}
+
+func (p CamMenuSelectorParam) parameterParse(s string) (Parameter, error) {
+	sp := commaSplit(s)
+	if len(sp) != 2 {
+		return nil, fmt.Errorf("invalid comma-joined-list length: %d, expects 2", len(sp))
+	}
+	p.Dir = Direction(sp[0])
+	p.State = Button(sp[1])
+	return p, nil
+}
+
+func (p CamMenuSelectorParam) Valid() bool {
+	return p.Dir.Valid() && p.State.Valid()
+}
+
+func (p CamMenuSelectorParam) _cameraoperationParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return CamMenuSelectorParam{} })
+}
+
+type MediaRecordingParam struct {
+	Recording Button
+}
+
+func (p MediaRecordingParam) parameterKey() string {
+	return "MediaRecording"
+}
+
+func (p MediaRecordingParam) parameterValue() string {
+	return string(p.Recording)
+}
+
+func (MediaRecordingParam) parameterParse(s string) (Parameter, error) {
+	return MediaRecordingParam{
+		Recording: Button(s),
+	}, nil
+}
+
+func (p MediaRecordingParam) Valid() bool {
+	return p.Recording.Valid()
+}
+
+func (p MediaRecordingParam) _cameraoperationParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return MediaRecordingParam{} })
+}
+
+type RecordingStatus string
+
+const (
 	RecordingRec     RecordingStatus = "rec"
 	RecordingStandby RecordingStatus = "standby"
 )
 
-func (p RecordingStatus) Valid() bool {
-	return p == RecordingRec || p == RecordingStandby
+func (p RecordingStatus) Valid() bool {
+	return p == RecordingRec || p == RecordingStandby
+}
+
+type MediaRecordingStatusParam struct {
+	Status RecordingStatus
+}
+
+func (p MediaRecordingStatusParam) parameterKey() string {
+	return "MediaRecordingStatus"
+}
+
+func (p MediaRecordingStatusParam) parameterValue() string {
+	return string(p.Status)
+}
+
+func (MediaRecordingStatusParam) parameterParse(s string) (Parameter, error) {
+	return MediaRecordingStatusParam{
+		Status: RecordingStatus(s),
+	}, nil
+}
+
+func (p MediaRecordingStatusParam) Valid() bool {
+	return p.Status.Valid()
+}
+
+func (p MediaRecordingStatusParam) _cameraoperationParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return MediaRecordingStatusParam{} })
+}
+
+// MediaSlot is a memory card slot of the camera.
+type MediaSlot string
+
+const (
+	MediaSlotA MediaSlot = "a"
+	MediaSlotB MediaSlot = "b"
+)
+
+func (m MediaSlot) Valid() bool {
+	return m == MediaSlotA || m == MediaSlotB
+}
+
+// MediaStatus is the state of the media inserted into a slot.
+type MediaStatus string
+
+const (
+	MediaStatusReady          MediaStatus = "ready"
+	MediaStatusNone           MediaStatus = "none"
+	MediaStatusWriteProtected MediaStatus = "write-protected"
+	MediaStatusFormatting     MediaStatus = "formatting"
+	MediaStatusError          MediaStatus = "error"
+)
+
+func (m MediaStatus) Valid() bool {
+	switch m {
+	case MediaStatusReady,
+		MediaStatusNone,
+		MediaStatusWriteProtected,
+		MediaStatusFormatting,
+		MediaStatusError:
+		return true
+	default:
+		return false
+	}
+}
+
+type MediaSlotAStatusParam struct {
+	Status MediaStatus
+}
+
+func (MediaSlotAStatusParam) parameterKey() string {
+	return "MediaSlotAStatus"
+}
+
+func (p MediaSlotAStatusParam) parameterValue() string {
+	return string(p.Status)
+}
+
+func (MediaSlotAStatusParam) parameterParse(s string) (Parameter, error) {
+	return MediaSlotAStatusParam{
+		Status: MediaStatus(s),
+	}, nil
+}
+
+func (p MediaSlotAStatusParam) Valid() bool {
+	return p.Status.Valid()
+}
+
+func (MediaSlotAStatusParam) _cameraoperationParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return MediaSlotAStatusParam{} })
+}
+
+// MediaSlotARemainParam is the remaining recording time in minutes.
+type MediaSlotARemainParam struct {
+	Minutes int
+}
+
+func (MediaSlotARemainParam) parameterKey() string {
+	return "MediaSlotARemain"
+}
+
+func (p MediaSlotARemainParam) parameterValue() string {
+	return itoa(p.Minutes)
+}
+
+func (MediaSlotARemainParam) parameterParse(s string) (Parameter, error) {
+	v, err := atoi(s)
+	if err != nil {
+		return nil, err
+	}
+	return MediaSlotARemainParam{
+		Minutes: v,
+	}, nil
+}
+
+func (p MediaSlotARemainParam) Valid() bool {
+	return p.Minutes >= 0
+}
+
+func (MediaSlotARemainParam) _cameraoperationParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return MediaSlotARemainParam{} })
+}
+
+type MediaSlotBStatusParam struct {
+	Status MediaStatus
+}
+
+func (MediaSlotBStatusParam) parameterKey() string {
+	return "MediaSlotBStatus"
+}
+
+func (p MediaSlotBStatusParam) parameterValue() string {
+	return string(p.Status)
+}
+
+func (MediaSlotBStatusParam) parameterParse(s string) (Parameter, error) {
+	return MediaSlotBStatusParam{
+		Status: MediaStatus(s),
+	}, nil
+}
+
+func (p MediaSlotBStatusParam) Valid() bool {
+	return p.Status.Valid()
+}
+
+func (MediaSlotBStatusParam) _cameraoperationParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return MediaSlotBStatusParam{} })
+}
+
+// MediaSlotBRemainParam is the remaining recording time in minutes.
+type MediaSlotBRemainParam struct {
+	Minutes int
+}
+
+func (MediaSlotBRemainParam) parameterKey() string {
+	return "MediaSlotBRemain"
+}
+
+func (p MediaSlotBRemainParam) parameterValue() string {
+	return itoa(p.Minutes)
+}
+
+func (MediaSlotBRemainParam) parameterParse(s string) (Parameter, error) {
+	v, err := atoi(s)
+	if err != nil {
+		return nil, err
+	}
+	return MediaSlotBRemainParam{
+		Minutes: v,
+	}, nil
+}
+
+func (p MediaSlotBRemainParam) Valid() bool {
+	return p.Minutes >= 0
+}
+
+func (MediaSlotBRemainParam) _cameraoperationParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return MediaSlotBRemainParam{} })
+}
+
+// MediaClipTitleParam is the prefix of the names of new clips.
+type MediaClipTitleParam struct {
+	Title string
+}
+
+func (MediaClipTitleParam) parameterKey() string {
+	return "MediaClipTitle"
+}
+
+func (p MediaClipTitleParam) parameterValue() string {
+	return p.Title
+}
+
+func (MediaClipTitleParam) parameterParse(s string) (Parameter, error) {
+	return MediaClipTitleParam{
+		Title: s,
+	}, nil
+}
+
+func (p MediaClipTitleParam) Valid() bool {
+	return len(p.Title) >= 1 && len(p.Title) <= 8 && clipNameValid(p.Title)
+}
+
+func (MediaClipTitleParam) _cameraoperationParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return MediaClipTitleParam{} })
+}
+
+// MediaClipNumberParam is the counter appended to the name of the next clip.
+type MediaClipNumberParam struct {
+	Number int
+}
+
+func (MediaClipNumberParam) parameterKey() string {
+	return "MediaClipNumber"
+}
+
+func (p MediaClipNumberParam) parameterValue() string {
+	return itoa(p.Number)
+}
+
+func (MediaClipNumberParam) parameterParse(s string) (Parameter, error) {
+	v, err := atoi(s)
+	if err != nil {
+		return nil, err
+	}
+	return MediaClipNumberParam{
+		Number: v,
+	}, nil
+}
+
+func (p MediaClipNumberParam) Valid() bool {
+	return p.Number >= 1 && p.Number <= 9999
+}
+
+func (MediaClipNumberParam) _cameraoperationParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return MediaClipNumberParam{} })
+}
+
+// MediaClipCreatorParam is recorded into the metadata of new clips.
+type MediaClipCreatorParam struct {
+	Creator string
+}
+
+func (MediaClipCreatorParam) parameterKey() string {
+	return "MediaClipCreator"
+}
+
+func (p MediaClipCreatorParam) parameterValue() string {
+	return p.Creator
+}
+
+func (MediaClipCreatorParam) parameterParse(s string) (Parameter, error) {
+	return MediaClipCreatorParam{
+		Creator: s,
+	}, nil
+}
+
+func (p MediaClipCreatorParam) Valid() bool {
+	return len(p.Creator) <= 32 && !strings.ContainsAny(p.Creator, ",")
+}
+
+func (MediaClipCreatorParam) _cameraoperationParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return MediaClipCreatorParam{} })
+}
+
+// MediaClipDescriptionParam is recorded into the metadata of new clips.
+type MediaClipDescriptionParam struct {
+	Description string
+}
+
+func (MediaClipDescriptionParam) parameterKey() string {
+	return "MediaClipDescription"
+}
+
+func (p MediaClipDescriptionParam) parameterValue() string {
+	return p.Description
+}
+
+func (MediaClipDescriptionParam) parameterParse(s string) (Parameter, error) {
+	return MediaClipDescriptionParam{
+		Description: s,
+	}, nil
+}
+
+func (p MediaClipDescriptionParam) Valid() bool {
+	return len(p.Description) <= 64 && !strings.ContainsAny(p.Description, ",")
+}
+
+func (MediaClipDescriptionParam) _cameraoperationParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return MediaClipDescriptionParam{} })
+}
+
+// MediaFormatParam erases all clips on a slot. See CameraClient.FormatMedia.
+type MediaFormatParam struct {
+	Slot MediaSlot
+}
+
+func (MediaFormatParam) parameterKey() string {
+	return "MediaFormat"
+}
+
+func (p MediaFormatParam) parameterValue() string {
+	return string(p.Slot)
+}
+
+func (MediaFormatParam) parameterParse(s string) (Parameter, error) {
+	return MediaFormatParam{
+		Slot: MediaSlot(s),
+	}, nil
+}
+
+func (p MediaFormatParam) Valid() bool {
+	return p.Slot.Valid()
+}
+
+func (MediaFormatParam) _cameraoperationParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return MediaFormatParam{} })
+}
+
+//
+// Parameters for AudioEndpoint
+//
+
+// AudioLevel is the recording level of an audio channel, between 0 and 99.
+type AudioLevel int
+
+func (a AudioLevel) Valid() bool {
+	return a >= 0 && a <= 99
+}
+
+// AudioLevelMode selects automatic or manual level control of a channel.
+type AudioLevelMode string
+
+const (
+	AudioLevelAuto   AudioLevelMode = "auto"
+	AudioLevelManual AudioLevelMode = "manual"
+)
+
+func (a AudioLevelMode) Valid() bool {
+	return a == AudioLevelAuto || a == AudioLevelManual
+}
+
+type AudioCH1LevelParam struct {
+	Level AudioLevel
+}
+
+func (AudioCH1LevelParam) parameterKey() string {
+	return "AudioCH1Level"
+}
+func (p AudioCH1LevelParam) parameterValue() string {
+	return itoa(int(p.Level))
+}
+func (AudioCH1LevelParam) parameterParse(s string) (Parameter, error) {
+	i, err := atoi(s)
+	if err != nil {
+		return nil, err
+	}
+	v := AudioLevel(i)
+	return AudioCH1LevelParam{
+		Level: v,
+	}, nil
+}
+func (p AudioCH1LevelParam) Valid() bool {
+	return p.Level.Valid()
+}
+func (AudioCH1LevelParam) _audioParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return AudioCH1LevelParam{} })
+}
+
+type AudioCH1LevelModeParam struct {
+	Mode AudioLevelMode
+}
+
+func (AudioCH1LevelModeParam) parameterKey() string {
+	return "AudioCH1LevelMode"
+}
+func (p AudioCH1LevelModeParam) parameterValue() string {
+	return string(p.Mode)
+}
+func (AudioCH1LevelModeParam) parameterParse(s string) (Parameter, error) {
+	return AudioCH1LevelModeParam{
+		Mode: AudioLevelMode(s),
+	}, nil
+}
+func (p AudioCH1LevelModeParam) Valid() bool {
+	return p.Mode.Valid()
+}
+func (AudioCH1LevelModeParam) _audioParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return AudioCH1LevelModeParam{} })
+}
+
+type AudioCH2LevelParam struct {
+	Level AudioLevel
+}
+
+func (AudioCH2LevelParam) parameterKey() string {
+	return "AudioCH2Level"
+}
+func (p AudioCH2LevelParam) parameterValue() string {
+	return itoa(int(p.Level))
+}
+func (AudioCH2LevelParam) parameterParse(s string) (Parameter, error) {
+	i, err := atoi(s)
+	if err != nil {
+		return nil, err
+	}
+	v := AudioLevel(i)
+	return AudioCH2LevelParam{
+		Level: v,
+	}, nil
+}
+func (p AudioCH2LevelParam) Valid() bool {
+	return p.Level.Valid()
+}
+func (AudioCH2LevelParam) _audioParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return AudioCH2LevelParam{} })
+}
+
+type AudioCH2LevelModeParam struct {
+	Mode AudioLevelMode
+}
+
+func (AudioCH2LevelModeParam) parameterKey() string {
+	return "AudioCH2LevelMode"
+}
+func (p AudioCH2LevelModeParam) parameterValue() string {
+	return string(p.Mode)
+}
+func (AudioCH2LevelModeParam) parameterParse(s string) (Parameter, error) {
+	return AudioCH2LevelModeParam{
+		Mode: AudioLevelMode(s),
+	}, nil
+}
+func (p AudioCH2LevelModeParam) Valid() bool {
+	return p.Mode.Valid()
+}
+func (AudioCH2LevelModeParam) _audioParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return AudioCH2LevelModeParam{} })
+}
+
+type AudioCH3LevelParam struct {
+	Level AudioLevel
+}
+
+func (AudioCH3LevelParam) parameterKey() string {
+	return "AudioCH3Level"
+}
+func (p AudioCH3LevelParam) parameterValue() string {
+	return itoa(int(p.Level))
+}
+func (AudioCH3LevelParam) parameterParse(s string) (Parameter, error) {
+	i, err := atoi(s)
+	if err != nil {
+		return nil, err
+	}
+	v := AudioLevel(i)
+	return AudioCH3LevelParam{
+		Level: v,
+	}, nil
+}
+func (p AudioCH3LevelParam) Valid() bool {
+	return p.Level.Valid()
+}
+func (AudioCH3LevelParam) _audioParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return AudioCH3LevelParam{} })
+}
+
+type AudioCH3LevelModeParam struct {
+	Mode AudioLevelMode
+}
+
+func (AudioCH3LevelModeParam) parameterKey() string {
+	return "AudioCH3LevelMode"
+}
+func (p AudioCH3LevelModeParam) parameterValue() string {
+	return string(p.Mode)
+}
+func (AudioCH3LevelModeParam) parameterParse(s string) (Parameter, error) {
+	return AudioCH3LevelModeParam{
+		Mode: AudioLevelMode(s),
+	}, nil
+}
+func (p AudioCH3LevelModeParam) Valid() bool {
+	return p.Mode.Valid()
+}
+func (AudioCH3LevelModeParam) _audioParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return AudioCH3LevelModeParam{} })
+}
+
+type AudioCH4LevelParam struct {
+	Level AudioLevel
+}
+
+func (AudioCH4LevelParam) parameterKey() string {
+	return "AudioCH4Level"
+}
+func (p AudioCH4LevelParam) parameterValue() string {
+	return itoa(int(p.Level))
+}
+func (AudioCH4LevelParam) parameterParse(s string) (Parameter, error) {
+	i, err := atoi(s)
+	if err != nil {
+		return nil, err
+	}
+	v := AudioLevel(i)
+	return AudioCH4LevelParam{
+		Level: v,
+	}, nil
+}
+func (p AudioCH4LevelParam) Valid() bool {
+	return p.Level.Valid()
+}
+func (AudioCH4LevelParam) _audioParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return AudioCH4LevelParam{} })
+}
+
+type AudioCH4LevelModeParam struct {
+	Mode AudioLevelMode
+}
+
+func (AudioCH4LevelModeParam) parameterKey() string {
+	return "AudioCH4LevelMode"
+}
+func (p AudioCH4LevelModeParam) parameterValue() string {
+	return string(p.Mode)
+}
+func (AudioCH4LevelModeParam) parameterParse(s string) (Parameter, error) {
+	return AudioCH4LevelModeParam{
+		Mode: AudioLevelMode(s),
+	}, nil
+}
+func (p AudioCH4LevelModeParam) Valid() bool {
+	return p.Mode.Valid()
+}
+func (AudioCH4LevelModeParam) _audioParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return AudioCH4LevelModeParam{} })
+}
+
+//
+// Parameters for StreamingEndpoint
+//
+
+// StreamingParam starts and stops the stream configured by StreamMode.
+type StreamingParam struct {
+	Enable Switch
+}
+
+func (StreamingParam) parameterKey() string {
+	return "Streaming"
+}
+func (p StreamingParam) parameterValue() string {
+	return string(p.Enable)
+}
+func (StreamingParam) parameterParse(s string) (Parameter, error) {
+	return StreamingParam{
+		Enable: Switch(s),
+	}, nil
+}
+func (p StreamingParam) Valid() bool {
+	return p.Enable.Valid()
+}
+func (StreamingParam) _streamingParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return StreamingParam{} })
+}
+
+// StreamMode is the protocol used for live streaming.
+type StreamMode string
+
+const (
+	StreamModeRTMP        StreamMode = "rtmp"
+	StreamModeRTMPS       StreamMode = "rtmps"
+	StreamModeSRTCaller   StreamMode = "srt-caller"
+	StreamModeSRTListener StreamMode = "srt-listener"
+)
+
+func (s StreamMode) Valid() bool {
+	switch s {
+	case StreamModeRTMP, StreamModeRTMPS, StreamModeSRTCaller, StreamModeSRTListener:
+		return true
+	default:
+		return false
+	}
 }
 
-type MediaRecordingStatusParam struct {
-	Status RecordingStatus
+type StreamModeParam struct {
+	Mode StreamMode
 }
 
-func (p MediaRecordingStatusParam) parameterKey() string {
-	return "MediaRecordingStatus"
+func (StreamModeParam) parameterKey() string {
+	return "StreamMode"
+}
+func (p StreamModeParam) parameterValue() string {
+	return string(p.Mode)
+}
+func (StreamModeParam) parameterParse(s string) (Parameter, error) {
+	return StreamModeParam{
+		Mode: StreamMode(s),
+	}, nil
 }
+func (p StreamModeParam) Valid() bool {
+	return p.Mode.Valid()
+}
+func (StreamModeParam) _streamingParameter() {}
 
-func (p MediaRecordingStatusParam) parameterValue() string {
-	return string(p.Status)
+func init() {
+	registerParameter(func() Parameter { return StreamModeParam{} })
 }
 
-func (MediaRecordingStatusParam) parameterParse(s string) (Parameter, error) {
-	return MediaRecordingStatusParam{
-		Status: RecordingStatus(s),
+type StreamRtmpUrlParam struct {
+	URL string
+}
+
+func (StreamRtmpUrlParam) parameterKey() string {
+	return "StreamRtmpUrl"
+}
+func (p StreamRtmpUrlParam) parameterValue() string {
+	return p.URL
+}
+func (StreamRtmpUrlParam) parameterParse(s string) (Parameter, error) {
+	return StreamRtmpUrlParam{
+		URL: s,
 	}, nil
 }
+func (p StreamRtmpUrlParam) Valid() bool {
+	return (strings.HasPrefix(p.URL, "rtmp://") || strings.HasPrefix(p.URL, "rtmps://")) && len(p.URL) <= 256
+}
+func (StreamRtmpUrlParam) _streamingParameter() {}
 
-func (p MediaRecordingStatusParam) Valid() bool {
-	return p.Status.Valid()
+func init() {
+	registerParameter(func() Parameter { return StreamRtmpUrlParam{} })
 }
 
-func (p MediaRecordingStatusParam) _cameraoperationParameter() {}
+type StreamRtmpKeyParam struct {
+	Key string
+}
+
+func (StreamRtmpKeyParam) parameterKey() string {
+	return "StreamRtmpKey"
+}
+func (p StreamRtmpKeyParam) parameterValue() string {
+	return p.Key
+}
+func (StreamRtmpKeyParam) parameterParse(s string) (Parameter, error) {
+	return StreamRtmpKeyParam{
+		Key: s,
+	}, nil
+}
+func (p StreamRtmpKeyParam) Valid() bool {
+	return len(p.Key) <= 256
+}
+func (StreamRtmpKeyParam) _streamingParameter() {}
 
 func init() {
-	registerParameter(func() Parameter { return MediaRecordingStatusParam{} })
+	registerParameter(func() Parameter { return StreamRtmpKeyParam{} })
+}
+
+// StreamSrtHostParam is the destination of the SRT caller, either a host name
+// or an IP address.
+type StreamSrtHostParam struct {
+	Host string
+}
+
+func (StreamSrtHostParam) parameterKey() string {
+	return "StreamSrtHost"
+}
+func (p StreamSrtHostParam) parameterValue() string {
+	return p.Host
+}
+func (StreamSrtHostParam) parameterParse(s string) (Parameter, error) {
+	return StreamSrtHostParam{
+		Host: s,
+	}, nil
+}
+func (p StreamSrtHostParam) Valid() bool {
+	return len(p.Host) > 0 && len(p.Host) <= 256 && !strings.ContainsAny(p.Host, ",: ")
+}
+func (StreamSrtHostParam) _streamingParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return StreamSrtHostParam{} })
+}
+
+type StreamSrtPortParam struct {
+	Port int
+}
+
+func (StreamSrtPortParam) parameterKey() string {
+	return "StreamSrtPort"
+}
+func (p StreamSrtPortParam) parameterValue() string {
+	return itoa(p.Port)
+}
+func (StreamSrtPortParam) parameterParse(s string) (Parameter, error) {
+	v, err := atoi(s)
+	if err != nil {
+		return nil, err
+	}
+	return StreamSrtPortParam{
+		Port: v,
+	}, nil
+}
+func (p StreamSrtPortParam) Valid() bool {
+	return p.Port >= 1 && p.Port <= 65535
+}
+func (StreamSrtPortParam) _streamingParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return StreamSrtPortParam{} })
+}
+
+// StreamSrtPassphraseParam enables SRT encryption, an empty passphrase disables.
+type StreamSrtPassphraseParam struct {
+	Passphrase string
+}
+
+func (StreamSrtPassphraseParam) parameterKey() string {
+	return "StreamSrtPassphrase"
+}
+func (p StreamSrtPassphraseParam) parameterValue() string {
+	return p.Passphrase
+}
+func (StreamSrtPassphraseParam) parameterParse(s string) (Parameter, error) {
+	return StreamSrtPassphraseParam{
+		Passphrase: s,
+	}, nil
+}
+func (p StreamSrtPassphraseParam) Valid() bool {
+	return len(p.Passphrase) == 0 || (len(p.Passphrase) >= 10 && len(p.Passphrase) <= 79)
+}
+func (StreamSrtPassphraseParam) _streamingParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return StreamSrtPassphraseParam{} })
+}
+
+// StreamSrtLatencyParam is the SRT receive latency in milliseconds.
+type StreamSrtLatencyParam struct {
+	Latency int
+}
+
+func (StreamSrtLatencyParam) parameterKey() string {
+	return "StreamSrtLatency"
+}
+func (p StreamSrtLatencyParam) parameterValue() string {
+	return itoa(p.Latency)
+}
+func (StreamSrtLatencyParam) parameterParse(s string) (Parameter, error) {
+	v, err := atoi(s)
+	if err != nil {
+		return nil, err
+	}
+	return StreamSrtLatencyParam{
+		Latency: v,
+	}, nil
+}
+func (p StreamSrtLatencyParam) Valid() bool {
+	return p.Latency >= 20 && p.Latency <= 8000
+}
+func (StreamSrtLatencyParam) _streamingParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return StreamSrtLatencyParam{} })
+}
+
+//
+// Parameters for SystemEndpoint
+//
+
+// TallyLampRedParam lights the red (program) tally lamp.
+type TallyLampRedParam struct {
+	Lamp Switch
+}
+
+func (TallyLampRedParam) parameterKey() string {
+	return "TallyLampRed"
+}
+func (p TallyLampRedParam) parameterValue() string {
+	return string(p.Lamp)
+}
+func (TallyLampRedParam) parameterParse(s string) (Parameter, error) {
+	return TallyLampRedParam{
+		Lamp: Switch(s),
+	}, nil
+}
+func (p TallyLampRedParam) Valid() bool {
+	return p.Lamp.Valid()
+}
+func (TallyLampRedParam) _systemParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return TallyLampRedParam{} })
+}
+
+// TallyLampGreenParam lights the green (preview) tally lamp.
+type TallyLampGreenParam struct {
+	Lamp Switch
+}
+
+func (TallyLampGreenParam) parameterKey() string {
+	return "TallyLampGreen"
+}
+func (p TallyLampGreenParam) parameterValue() string {
+	return string(p.Lamp)
+}
+func (TallyLampGreenParam) parameterParse(s string) (Parameter, error) {
+	return TallyLampGreenParam{
+		Lamp: Switch(s),
+	}, nil
+}
+func (p TallyLampGreenParam) Valid() bool {
+	return p.Lamp.Valid()
+}
+func (TallyLampGreenParam) _systemParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return TallyLampGreenParam{} })
+}
+
+// TallyBrightness is the brightness of the tally lamps.
+type TallyBrightness string
+
+const (
+	TallyBrightnessHigh TallyBrightness = "high"
+	TallyBrightnessLow  TallyBrightness = "low"
+	TallyBrightnessOff  TallyBrightness = "off"
+)
+
+func (t TallyBrightness) Valid() bool {
+	return t == TallyBrightnessHigh || t == TallyBrightnessLow || t == TallyBrightnessOff
+}
+
+type TallyLampBrightnessParam struct {
+	Brightness TallyBrightness
+}
+
+func (TallyLampBrightnessParam) parameterKey() string {
+	return "TallyLampBrightness"
+}
+func (p TallyLampBrightnessParam) parameterValue() string {
+	return string(p.Brightness)
+}
+func (TallyLampBrightnessParam) parameterParse(s string) (Parameter, error) {
+	return TallyLampBrightnessParam{
+		Brightness: TallyBrightness(s),
+	}, nil
+}
+func (p TallyLampBrightnessParam) Valid() bool {
+	return p.Brightness.Valid()
+}
+func (TallyLampBrightnessParam) _systemParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return TallyLampBrightnessParam{} })
+}
+
+// PowerStandbyParam puts the camera into standby, or wakes it up.
+type PowerStandbyParam struct {
+	Standby Switch
+}
+
+func (PowerStandbyParam) parameterKey() string {
+	return "PowerStandby"
+}
+func (p PowerStandbyParam) parameterValue() string {
+	return string(p.Standby)
+}
+func (PowerStandbyParam) parameterParse(s string) (Parameter, error) {
+	return PowerStandbyParam{
+		Standby: Switch(s),
+	}, nil
+}
+func (p PowerStandbyParam) Valid() bool {
+	return p.Standby.Valid()
+}
+func (PowerStandbyParam) _systemParameter() {}
+
+func init() {
+	registerParameter(func() Parameter { return PowerStandbyParam{} })
 }
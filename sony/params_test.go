@@ -1,6 +1,8 @@
 package sony
 
 import (
+	"net"
+	"net/netip"
 	"reflect"
 	"strings"
 	"testing"
@@ -13,6 +15,11 @@ func init() {
 	implements[AssignableEndpoint] = reflect.TypeFor[AssignableParameter]()
 	implements[PtzfEndpoint] = reflect.TypeFor[PtzfParameter]()
 	implements[PresetpositionEndpoint] = reflect.TypeFor[PresetpositionParameter]()
+	implements[NetworkEndpoint] = reflect.TypeFor[NetworkParameter]()
+	implements[ImagingEndpoint] = reflect.TypeFor[ImagingParameter]()
+	implements[AudioEndpoint] = reflect.TypeFor[AudioParameter]()
+	implements[StreamingEndpoint] = reflect.TypeFor[StreamingParameter]()
+	implements[SystemEndpoint] = reflect.TypeFor[SystemParameter]()
 }
 
 func TestParamNames(t *testing.T) {
@@ -40,3 +47,53 @@ func TestInterfaceNames(t *testing.T) {
 		})
 	}
 }
+
+func TestParamRoundTrip(t *testing.T) {
+	mac, _ := net.ParseMAC("00:1a:2b:3c:4d:5e")
+	tests := []struct {
+		key   string
+		value string
+		want  Parameter
+	}{
+		{"IPAddress", "192.168.0.100", IPAddressParam{Addr: netip.MustParseAddr("192.168.0.100")}},
+		{"SubnetMask", "255.255.255.0", SubnetMaskParam{Mask: netip.MustParseAddr("255.255.255.0")}},
+		{"DefaultGateway", "192.168.0.1", DefaultGatewayParam{Gateway: netip.MustParseAddr("192.168.0.1")}},
+		{"DhcpEnable", "off", DhcpEnableParam{Enable: SwitchOff}},
+		{"PrimaryDns", "192.168.0.53", PrimaryDnsParam{Addr: netip.MustParseAddr("192.168.0.53")}},
+		{"SecondaryDns", "1.1.1.1", SecondaryDnsParam{Addr: netip.MustParseAddr("1.1.1.1")}},
+		{"MacAddress", "00:1a:2b:3c:4d:5e", MacAddressParam{Mac: mac}},
+		{"ExposureNDMode", "preset", ExposureNDModeParam{Mode: NDModePreset}},
+		{"ExposureNDPresetSelect", "2", ExposureNDPresetSelectParam{Preset: 2}},
+		{"ExposureNDPreset1", "4", ExposureNDPreset1Param{Level: ND1o8}},
+		{"ExposureNDPreset3", "0", ExposureNDPreset3Param{Level: ND1o4}},
+		{"AudioCH1Level", "42", AudioCH1LevelParam{Level: 42}},
+		{"AudioCH2LevelMode", "manual", AudioCH2LevelModeParam{Mode: AudioLevelManual}},
+		{"AudioCH4Level", "0", AudioCH4LevelParam{Level: 0}},
+		{"Streaming", "on", StreamingParam{Enable: SwitchOn}},
+		{"StreamMode", "srt-caller", StreamModeParam{Mode: StreamModeSRTCaller}},
+		{"StreamRtmpUrl", "rtmp://live.example.com/app", StreamRtmpUrlParam{URL: "rtmp://live.example.com/app"}},
+		{"StreamSrtHost", "srt.example.com", StreamSrtHostParam{Host: "srt.example.com"}},
+		{"StreamSrtPort", "9000", StreamSrtPortParam{Port: 9000}},
+		{"StreamSrtLatency", "120", StreamSrtLatencyParam{Latency: 120}},
+		{"TallyLampRed", "on", TallyLampRedParam{Lamp: SwitchOn}},
+		{"TallyLampGreen", "off", TallyLampGreenParam{Lamp: SwitchOff}},
+		{"TallyLampBrightness", "low", TallyLampBrightnessParam{Brightness: TallyBrightnessLow}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := createParameter(tt.key, tt.value)
+			if err != nil {
+				t.Fatalf("createParameter(%q, %q) error = %v", tt.key, tt.value, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("createParameter(%q, %q) = %#v, want %#v", tt.key, tt.value, got, tt.want)
+			}
+			if !got.Valid() {
+				t.Errorf("%#v.Valid() = false, want true", got)
+			}
+			if v := tt.want.parameterValue(); v != tt.value {
+				t.Errorf("%#v.parameterValue() = %q, want %q", tt.want, v, tt.value)
+			}
+		})
+	}
+}
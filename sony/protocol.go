@@ -6,14 +6,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
 	"net/http"
 	"net/netip"
 	"net/url"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/icholy/digest"
+	"puzzlekraken.com/broadcastkit/internal/wirelog"
+	"puzzlekraken.com/broadcastkit/metrics"
 )
 
 type Endpoint string
@@ -22,6 +26,14 @@ type CameraClient struct {
 	Remote   netip.AddrPort
 	Username string
 	Password string
+	// Transport, if set, carries the requests below the digest authentication
+	// instead of the default transport. It must be set before the first call.
+	Transport http.RoundTripper
+	// Logger, if set, receives the HTTP traffic at debug level and parameters
+	// the library fails to parse as warnings.
+	Logger *slog.Logger
+	// Metrics, if set, receives the latency and errors of requests.
+	Metrics metrics.Recorder
 
 	http     http.Client
 	httpOnce sync.Once
@@ -36,15 +48,19 @@ func (c *CameraClient) httpInit() {
 		Timeout:   networkTimeout,
 		KeepAlive: 30 * time.Second,
 	}
-	c.http.Transport = &digest.Transport{
-		Username: c.Username,
-		Password: c.Password,
-		Transport: &http.Transport{
+	transport := c.Transport
+	if transport == nil {
+		transport = &http.Transport{
 			Proxy:           http.ProxyFromEnvironment,
 			DialContext:     dialer.DialContext,
 			MaxIdleConns:    10,
 			IdleConnTimeout: pullPeriod,
-		},
+		}
+	}
+	c.http.Transport = &digest.Transport{
+		Username:  c.Username,
+		Password:  c.Password,
+		Transport: wirelog.Transport(transport, c.Logger),
 	}
 
 	c.http.Timeout = pullPeriod + networkTimeout
@@ -91,11 +107,47 @@ func (c *CameraClient) httpReq(ctx context.Context, ep Endpoint, ps ...Parameter
 	return r
 }
 
+// statusError is an unexpected HTTP status returned by the camera.
+type statusError struct {
+	msg  string
+	code int
+}
+
+func (e *statusError) Error() string {
+	return e.msg
+}
+
+// errorKind classifies err by type for metrics.Recorder.
+func errorKind(err error) string {
+	var status *statusError
+	var transport *url.Error
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &status):
+		return "HTTP " + strconv.Itoa(status.code)
+	case errors.Is(err, context.Canceled):
+		return "Canceled"
+	case errors.As(err, &transport):
+		return "SystemError"
+	default:
+		return "ParseError"
+	}
+}
+
+// observe reports a request started at start and failed with *err to Metrics.
+func (c *CameraClient) observe(command string, start time.Time, err *error) {
+	if c.Metrics != nil {
+		c.Metrics.Command(c.Remote.String(), command, time.Since(start), errorKind(*err))
+	}
+}
+
 func (c *CameraClient) Set(ep Endpoint, ps []Parameter) error {
 	return c.SetCtx(context.Background(), ep, ps)
 }
 
-func (c *CameraClient) SetCtx(ctx context.Context, ep Endpoint, ps []Parameter) error {
+func (c *CameraClient) SetCtx(ctx context.Context, ep Endpoint, ps []Parameter) (err error) {
+	defer c.observe("set", time.Now(), &err)
 	c.httpOnce.Do(c.httpInit)
 	res, err := c.http.Do(c.httpReq(ctx, ep, ps...))
 
@@ -105,7 +157,7 @@ func (c *CameraClient) SetCtx(ctx context.Context, ep Endpoint, ps []Parameter)
 	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("unexpected status: %s", res.Status)
+		return &statusError{"unexpected status: " + res.Status, res.StatusCode}
 	}
 
 	return nil
@@ -115,7 +167,8 @@ func (c *CameraClient) Inq(ep ...Endpoint) ([]Parameter, error) {
 	return c.InqCtx(context.Background(), ep...)
 }
 
-func (c *CameraClient) InqCtx(ctx context.Context, ep ...Endpoint) ([]Parameter, error) {
+func (c *CameraClient) InqCtx(ctx context.Context, ep ...Endpoint) (_ []Parameter, err error) {
+	defer c.observe("inquiry", time.Now(), &err)
 	c.httpOnce.Do(c.httpInit)
 	params := make([]Parameter, len(ep))
 	for i, ep := range ep {
@@ -151,10 +204,19 @@ func (c *CameraClient) InqCtx(ctx context.Context, ep ...Endpoint) ([]Parameter,
 		}
 	}
 
+	c.logParseErrors(ctx, errs)
 	return parameters, errors.Join(errs...)
 }
 
-func (c *CameraClient) Subscribe(ep ...Endpoint) (SubscriptionIdParam, error) {
+// logParseErrors reports parameters which were dropped from a reply.
+func (c *CameraClient) logParseErrors(ctx context.Context, errs []error) {
+	for _, err := range errs {
+		wirelog.Or(c.Logger).WarnContext(ctx, "parameter parse", "err", err)
+	}
+}
+
+func (c *CameraClient) Subscribe(ep ...Endpoint) (_ SubscriptionIdParam, err error) {
+	defer c.observe("subscribe", time.Now(), &err)
 	c.httpOnce.Do(c.httpInit)
 
 	params := make([]Parameter, 0, len(ep)+1)
@@ -170,7 +232,7 @@ func (c *CameraClient) Subscribe(ep ...Endpoint) (SubscriptionIdParam, error) {
 	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unexpected http status: %s", res.Status)
+		return "", &statusError{"unexpected http status: " + res.Status, res.StatusCode}
 	}
 
 	raw, err := io.ReadAll(res.Body)
@@ -198,7 +260,7 @@ func (c *CameraClient) doPull(ctx context.Context, id SubscriptionIdParam) ([]by
 		return nil, nil
 	}
 	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected http status: %s", res.Status)
+		return nil, &statusError{"unexpected http status: " + res.Status, res.StatusCode}
 	}
 	return io.ReadAll(res.Body)
 }
@@ -223,7 +285,8 @@ func parsePull(raw []byte) ([]Parameter, error) {
 	return parameters, errors.Join(errs...)
 }
 
-func (c *CameraClient) PullInq(ctx context.Context, id SubscriptionIdParam) ([]Parameter, error) {
+func (c *CameraClient) PullInq(ctx context.Context, id SubscriptionIdParam) (_ []Parameter, err error) {
+	defer c.observe("pull", time.Now(), &err)
 	c.httpOnce.Do(c.httpInit)
 	for {
 		data, err := c.doPull(ctx, id)
@@ -236,11 +299,16 @@ func (c *CameraClient) PullInq(ctx context.Context, id SubscriptionIdParam) ([]P
 		if data == nil {
 			continue
 		}
-		return parsePull(data)
+		params, err := parsePull(data)
+		if err != nil {
+			c.logParseErrors(ctx, []error{err})
+		}
+		return params, err
 	}
 }
 
-func (c *CameraClient) Unsubscribe(id SubscriptionIdParam) error {
+func (c *CameraClient) Unsubscribe(id SubscriptionIdParam) (err error) {
+	defer c.observe("unsubscribe", time.Now(), &err)
 	c.httpOnce.Do(c.httpInit)
 	res, err := c.http.Do(c.httpReq(context.Background(), unsubscribeEndpoint, id))
 	if err != nil {
@@ -248,7 +316,7 @@ func (c *CameraClient) Unsubscribe(id SubscriptionIdParam) error {
 	}
 	defer res.Body.Close()
 	if res.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("unexpected status: %s", res.Status)
+		return &statusError{"unexpected status: " + res.Status, res.StatusCode}
 	}
 	return nil
 }
@@ -360,7 +428,56 @@ func (c *CameraClient) InqProject() ([]ProjectParameter, error) {
 	return castSpecific[ProjectParameter](gs), err
 }
 
-func (c *CameraClient) ScreenshotOfPreset(p Preset) ([]byte, error) {
+const AudioEndpoint Endpoint = "audio"
+
+type AudioParameter interface {
+	Parameter
+	_audioParameter()
+}
+
+func (c *CameraClient) SetAudio(p ...AudioParameter) error {
+	return c.Set(AudioEndpoint, castGeneric(p))
+}
+
+func (c *CameraClient) InqAudio() ([]AudioParameter, error) {
+	gs, err := c.Inq(AudioEndpoint)
+	return castSpecific[AudioParameter](gs), err
+}
+
+const StreamingEndpoint Endpoint = "streaming"
+
+type StreamingParameter interface {
+	Parameter
+	_streamingParameter()
+}
+
+func (c *CameraClient) SetStreaming(p ...StreamingParameter) error {
+	return c.Set(StreamingEndpoint, castGeneric(p))
+}
+
+func (c *CameraClient) InqStreaming() ([]StreamingParameter, error) {
+	gs, err := c.Inq(StreamingEndpoint)
+	return castSpecific[StreamingParameter](gs), err
+}
+
+const SystemEndpoint Endpoint = "system"
+
+type SystemParameter interface {
+	Parameter
+	_systemParameter()
+}
+
+func (c *CameraClient) SetSystem(p ...SystemParameter) error {
+	return c.Set(SystemEndpoint, castGeneric(p))
+}
+
+func (c *CameraClient) InqSystem() ([]SystemParameter, error) {
+	gs, err := c.Inq(SystemEndpoint)
+	return castSpecific[SystemParameter](gs), err
+}
+
+func (c *CameraClient) ScreenshotOfPreset(p Preset) (_ []byte, err error) {
+	defer c.observe("presetimg", time.Now(), &err)
 	c.httpOnce.Do(c.httpInit)
 
 	ep := Endpoint(fmt.Sprintf("/preset/presetimg%d.jpg", p))
@@ -370,7 +487,7 @@ func (c *CameraClient) ScreenshotOfPreset(p Preset) ([]byte, error) {
 	}
 	defer res.Body.Close()
 	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status: %s", res.Status)
+		return nil, &statusError{"unexpected status: " + res.Status, res.StatusCode}
 	}
 
 	return io.ReadAll(res.Body)
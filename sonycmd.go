@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"puzzlekraken.com/broadcastkit/sony"
+)
+
+var sonyDevice = device{
+	name:  "sony",
+	usage: "inq <endpoint>... | set <endpoint> <key=value>... | watch <endpoint>...",
+	commands: map[string]func(context.Context, *env, []string) error{
+		"inq":   sonyInq,
+		"set":   sonySet,
+		"watch": sonyWatch,
+	},
+}
+
+func (e *env) sonyClient() (*sony.CameraClient, error) {
+	remote, err := resolve(e.Address, 80)
+	if err != nil {
+		return nil, err
+	}
+	return &sony.CameraClient{
+		Remote:   remote,
+		Username: e.Username,
+		Password: e.Password,
+		Logger:   e.log,
+	}, nil
+}
+
+func sonyEndpoints(args []string) ([]sony.Endpoint, error) {
+	if len(args) == 0 {
+		return nil, errors.New("no endpoint given, for example: ptzf imaging")
+	}
+	eps := make([]sony.Endpoint, len(args))
+	for i, a := range args {
+		eps[i] = sony.Endpoint(a)
+	}
+	return eps, nil
+}
+
+// printParameters writes ps as key=value lines, or as one JSON object. The
+// values of repeated keys are collected into an array.
+func (e *env) printParameters(ps []sony.Parameter) error {
+	obj := make(map[string]any, len(ps))
+	lines := make([]string, len(ps))
+	for i, p := range ps {
+		k, v := sony.FormatParameter(p)
+		switch prev := obj[k].(type) {
+		case nil:
+			obj[k] = v
+		case string:
+			obj[k] = []string{prev, v}
+		case []string:
+			obj[k] = append(prev, v)
+		}
+		lines[i] = k + "=" + v
+	}
+	return e.out.print(obj, strings.Join(lines, "\n"))
+}
+
+func sonyInq(ctx context.Context, e *env, args []string) error {
+	eps, err := sonyEndpoints(args)
+	if err != nil {
+		return err
+	}
+	c, err := e.sonyClient()
+	if err != nil {
+		return err
+	}
+	// Parameters which failed to parse are reported after the valid ones.
+	ps, err := c.InqCtx(ctx, eps...)
+	if perr := e.printParameters(ps); perr != nil {
+		return perr
+	}
+	return err
+}
+
+func sonySet(ctx context.Context, e *env, args []string) error {
+	if len(args) < 2 {
+		return errors.New("usage: set <endpoint> <key=value>...")
+	}
+	ps := make([]sony.Parameter, 0, len(args)-1)
+	for _, a := range args[1:] {
+		k, v, ok := strings.Cut(a, "=")
+		if !ok {
+			return fmt.Errorf("parameter not in key=value form: %s", a)
+		}
+		p, err := sony.ParseParameter(k, v)
+		if err != nil {
+			return fmt.Errorf("parameter %s: %w", k, err)
+		}
+		if !p.Valid() {
+			return fmt.Errorf("parameter %s: invalid value: %s", k, v)
+		}
+		ps = append(ps, p)
+	}
+	c, err := e.sonyClient()
+	if err != nil {
+		return err
+	}
+	return c.SetCtx(ctx, sony.Endpoint(args[0]), ps)
+}
+
+func sonyWatch(ctx context.Context, e *env, args []string) error {
+	eps, err := sonyEndpoints(args)
+	if err != nil {
+		return err
+	}
+	c, err := e.sonyClient()
+	if err != nil {
+		return err
+	}
+	id, err := c.Subscribe(eps...)
+	if err != nil {
+		return err
+	}
+	defer c.Unsubscribe(id)
+	for {
+		ps, err := c.PullInq(ctx, id)
+		if len(ps) > 0 {
+			if perr := e.printParameters(ps); perr != nil {
+				return perr
+			}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil && ps == nil {
+			return err
+		}
+	}
+}
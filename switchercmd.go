@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"puzzlekraken.com/broadcastkit/panasonic"
+)
+
+var switcherDevice = device{
+	name:  "switcher",
+	usage: "bus get <bus>... | bus set <bus> <source>",
+	commands: map[string]func(context.Context, *env, []string) error{
+		"bus": switcherBus,
+	},
+}
+
+// switcherPort is the control port of AV-HS series switchers.
+const switcherPort = 62000
+
+// lookupName finds s in names by number or by normalized name.
+func lookupName[K ~int](names map[K]string, s string) (K, bool) {
+	if n, err := strconv.Atoi(s); err == nil {
+		_, ok := names[K(n)]
+		return K(n), ok
+	}
+	want := normalizeName(s)
+	for k, name := range names {
+		if normalizeName(name) == want {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
+// busResult is the JSON form of a bus crosspoint.
+type busResult struct {
+	Bus        int    `json:"bus"`
+	BusName    string `json:"bus_name"`
+	Source     int    `json:"source"`
+	SourceName string `json:"source_name"`
+}
+
+func (e *env) printBus(bus panasonic.Bus, src panasonic.Source) error {
+	r := busResult{
+		Bus:        int(bus),
+		BusName:    panasonic.BusNameMap[bus],
+		Source:     int(src),
+		SourceName: panasonic.SourceNameMap[src],
+	}
+	return e.out.print(r, fmt.Sprintf("%s=%s", r.BusName, r.SourceName))
+}
+
+func switcherBus(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: bus get <bus>... | bus set <bus> <source>")
+	}
+	remote, err := resolve(e.Address, switcherPort)
+	if err != nil {
+		return err
+	}
+	c := &panasonic.SwitcherClient{Remote: remote, Logger: e.log}
+	switch args[0] {
+	case "get":
+		if len(args) < 2 {
+			return errors.New("usage: bus get <bus>...")
+		}
+		for _, a := range args[1:] {
+			bus, ok := lookupName(panasonic.BusNameMap, a)
+			if !ok {
+				return fmt.Errorf("unknown bus: %s", a)
+			}
+			src, err := c.QueryBus(bus)
+			if err != nil {
+				return err
+			}
+			if err := e.printBus(bus, src); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "set":
+		if len(args) != 3 {
+			return errors.New("usage: bus set <bus> <source>")
+		}
+		bus, ok := lookupName(panasonic.BusNameMap, args[1])
+		if !ok {
+			return fmt.Errorf("unknown bus: %s", args[1])
+		}
+		src, ok := lookupName(panasonic.SourceNameMap, args[2])
+		if !ok {
+			return fmt.Errorf("unknown source: %s", args[2])
+		}
+		if err := c.SwitchBus(bus, src); err != nil {
+			return err
+		}
+		return e.printBus(bus, src)
+	default:
+		return fmt.Errorf("unknown bus command: %s", args[0])
+	}
+}
@@ -0,0 +1,190 @@
+// Package tally drives camera tally lamps from the state of a switcher or
+// router.
+package tally
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"time"
+
+	"puzzlekraken.com/broadcastkit/ptz"
+	"puzzlekraken.com/broadcastkit/router"
+)
+
+const (
+	defaultDebounce = 100 * time.Millisecond
+	defaultReassert = 5 * time.Second
+	commandTimeout  = 3 * time.Second
+)
+
+// Bus derives program and preview tally from a router and drives the tally
+// lamps of cameras accordingly.
+//
+// A switcher is represented by a router.Switcher whose buses are the program
+// and preview buses, for example BUS_ME1PGM and BUS_ME1PVW. Any source routed
+// to one of the Program destinations is on program (red), any source routed
+// to one of the Preview destinations is on preview (green). Program takes
+// precedence over preview.
+//
+// Changes are debounced, so cuts through several sources in quick succession
+// do not flash tally lamps. Tally is re-asserted on every camera periodically,
+// which also restores tally on cameras which were restarted or reconnected.
+//
+// Bus must not be copied after first use.
+type Bus struct {
+	Router  router.Router
+	Program []int
+	Preview []int
+	// Cameras maps router source IDs to the camera connected to them.
+	Cameras map[int]ptz.Camera
+	// Debounce is the quiet time after a change before tally is updated.
+	Debounce time.Duration
+	// Reassert is the interval tally is sent to all cameras regardless of
+	// changes, and failed cameras are retried.
+	Reassert time.Duration
+	// OnError, if set, is called for every failed camera update.
+	OnError func(source int, err error)
+
+	lock  sync.Mutex
+	state map[int]ptz.Tally
+}
+
+// State returns the current tally of every mapped source.
+func (b *Bus) State() map[int]ptz.Tally {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return maps.Clone(b.state)
+}
+
+// compute derives the tally of every mapped camera from routes.
+func (b *Bus) compute(routes map[int]int) map[int]ptz.Tally {
+	state := make(map[int]ptz.Tally, len(b.Cameras))
+	for src := range b.Cameras {
+		state[src] = ptz.TallyOff
+	}
+	for _, dst := range b.Preview {
+		if src, ok := routes[dst]; ok {
+			if _, ok := state[src]; ok {
+				state[src] = ptz.TallyPreview
+			}
+		}
+	}
+	for _, dst := range b.Program {
+		if src, ok := routes[dst]; ok {
+			if _, ok := state[src]; ok {
+				state[src] = ptz.TallyProgram
+			}
+		}
+	}
+	return state
+}
+
+// apply sends the tally of the given sources to their cameras concurrently.
+//
+// It returns the sources which failed.
+func (b *Bus) apply(ctx context.Context, state map[int]ptz.Tally, sources []int) map[int]bool {
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	failed := make(map[int]bool)
+	for _, src := range sources {
+		cam := b.Cameras[src]
+		tally := state[src]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+			defer cancel()
+			if err := cam.SetTally(ctx, tally); err != nil {
+				lock.Lock()
+				failed[src] = true
+				lock.Unlock()
+				if b.OnError != nil {
+					b.OnError(src, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return failed
+}
+
+// Run drives tally until ctx is done or the router fails.
+//
+// Run always returns a non-nil error.
+func (b *Bus) Run(ctx context.Context) error {
+	if b.Router == nil {
+		return errors.New("broadcastkit/tally: no router")
+	}
+	debounce := b.Debounce
+	if debounce == 0 {
+		debounce = defaultDebounce
+	}
+	reassert := b.Reassert
+	if reassert == 0 {
+		reassert = defaultReassert
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	changed := make(chan struct{}, 1)
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- b.Router.Watch(ctx, func(router.Event) {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		})
+	}()
+
+	var state map[int]ptz.Tally
+	failed := make(map[int]bool)
+	update := func(all bool) error {
+		routes, err := b.Router.Routes(ctx)
+		if err != nil {
+			return err
+		}
+		next := b.compute(routes)
+		var sources []int
+		for src, t := range next {
+			if all || failed[src] || state == nil || state[src] != t {
+				sources = append(sources, src)
+			}
+		}
+		state = next
+		b.lock.Lock()
+		b.state = maps.Clone(next)
+		b.lock.Unlock()
+		failed = b.apply(ctx, next, sources)
+		return nil
+	}
+
+	if err := update(true); err != nil {
+		return err
+	}
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+	tick := time.NewTicker(reassert)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-watchErr:
+			return err
+		case <-changed:
+			timer.Reset(debounce)
+		case <-timer.C:
+			if err := update(false); err != nil {
+				return err
+			}
+		case <-tick.C:
+			if err := update(true); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package tally
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"testing"
+	"time"
+
+	"puzzlekraken.com/broadcastkit/ptz"
+	"puzzlekraken.com/broadcastkit/router"
+)
+
+type fakeRouter struct {
+	router.Router
+	lock   sync.Mutex
+	routes map[int]int
+	fn     func(router.Event)
+	ready  chan struct{}
+}
+
+func (r *fakeRouter) Routes(ctx context.Context) (map[int]int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return maps.Clone(r.routes), nil
+}
+
+func (r *fakeRouter) Watch(ctx context.Context, fn func(router.Event)) error {
+	r.lock.Lock()
+	r.fn = fn
+	r.lock.Unlock()
+	close(r.ready)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (r *fakeRouter) route(dst, src int) {
+	<-r.ready
+	r.lock.Lock()
+	r.routes[dst] = src
+	fn := r.fn
+	r.lock.Unlock()
+	fn(router.Event{Kind: router.EventRoute, Destination: dst, Source: src})
+}
+
+type fakeCamera struct {
+	ptz.Camera
+	lock  sync.Mutex
+	fail  bool
+	tally []ptz.Tally
+}
+
+func (c *fakeCamera) SetTally(ctx context.Context, t ptz.Tally) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.fail {
+		return errors.New("unreachable")
+	}
+	c.tally = append(c.tally, t)
+	return nil
+}
+
+func (c *fakeCamera) last() (ptz.Tally, int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.tally) == 0 {
+		return -1, 0
+	}
+	return c.tally[len(c.tally)-1], len(c.tally)
+}
+
+func TestCompute(t *testing.T) {
+	b := &Bus{
+		Program: []int{100},
+		Preview: []int{101},
+		Cameras: map[int]ptz.Camera{1: nil, 2: nil, 3: nil},
+	}
+	tests := []struct {
+		name   string
+		routes map[int]int
+		want   map[int]ptz.Tally
+	}{
+		{"program and preview", map[int]int{100: 1, 101: 2},
+			map[int]ptz.Tally{1: ptz.TallyProgram, 2: ptz.TallyPreview, 3: ptz.TallyOff}},
+		{"program wins", map[int]int{100: 1, 101: 1},
+			map[int]ptz.Tally{1: ptz.TallyProgram, 2: ptz.TallyOff, 3: ptz.TallyOff}},
+		{"unmapped source", map[int]int{100: 9, 101: 3},
+			map[int]ptz.Tally{1: ptz.TallyOff, 2: ptz.TallyOff, 3: ptz.TallyPreview}},
+		{"no routes", map[int]int{},
+			map[int]ptz.Tally{1: ptz.TallyOff, 2: ptz.TallyOff, 3: ptz.TallyOff}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.compute(tt.routes); !maps.Equal(got, tt.want) {
+				t.Errorf("compute() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRun(t *testing.T) {
+	r := &fakeRouter{routes: map[int]int{100: 1, 101: 2}, ready: make(chan struct{})}
+	cam1, cam2 := &fakeCamera{}, &fakeCamera{fail: true}
+	b := &Bus{
+		Router:   r,
+		Program:  []int{100},
+		Preview:  []int{101},
+		Cameras:  map[int]ptz.Camera{1: cam1, 2: cam2},
+		Debounce: 20 * time.Millisecond,
+		Reassert: 50 * time.Millisecond,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx) }()
+
+	waitFor(t, "initial tally", func() bool {
+		got, _ := cam1.last()
+		return got == ptz.TallyProgram
+	})
+
+	// A burst of cuts settles into a single update after the debounce.
+	_, before := cam1.last()
+	r.route(100, 2)
+	r.route(100, 1)
+	r.route(100, 2)
+	waitFor(t, "debounced tally", func() bool {
+		got, _ := cam1.last()
+		return got == ptz.TallyOff
+	})
+	if _, n := cam1.last(); n > before+2 {
+		t.Errorf("SetTally calls after burst = %d, want at most %d", n-before, 2)
+	}
+	if got := b.State()[2]; got != ptz.TallyProgram {
+		t.Errorf("State()[2] = %v, want %v", got, ptz.TallyProgram)
+	}
+
+	// A camera coming back receives its tally without a change.
+	cam2.lock.Lock()
+	cam2.fail = false
+	cam2.lock.Unlock()
+	waitFor(t, "re-asserted tally", func() bool {
+		got, _ := cam2.last()
+		return got == ptz.TallyProgram
+	})
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"puzzlekraken.com/broadcastkit/blackmagicdesign"
+)
+
+var videohubDevice = device{
+	name:  "videohub",
+	usage: "dump | route <output> <input> | label input|output <n> <label>",
+	commands: map[string]func(context.Context, *env, []string) error{
+		"dump":  videohubDump,
+		"route": videohubRoute,
+		"label": videohubLabel,
+	},
+}
+
+// videohubTimeout bounds the whole exchange with the Videohub.
+const videohubTimeout = 10 * time.Second
+
+func (e *env) videohubSocket(ctx context.Context) (*blackmagicdesign.VideohubSocket, func(), error) {
+	s, err := blackmagicdesign.DialVideohub(e.Address)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.Logger = e.log
+	if c, ok := s.Conn.(net.Conn); ok {
+		c.SetDeadline(time.Now().Add(videohubTimeout))
+	}
+	stop := context.AfterFunc(ctx, func() { s.Close() })
+	return s, func() { stop(); s.Close() }, nil
+}
+
+// textConn renders blocks in the protocol text form through a VideohubSocket.
+type textConn struct {
+	io.Writer
+}
+
+func (textConn) Read([]byte) (int, error) { return 0, io.EOF }
+func (textConn) Close() error             { return nil }
+
+// blockResult is the JSON form of a Videohub block.
+type blockResult struct {
+	Block string `json:"block"`
+	Value any    `json:"value,omitempty"`
+}
+
+func (e *env) printBlock(b blackmagicdesign.VideohubBlock) error {
+	if e.out.json {
+		return e.out.print(blockResult{Block: typeName(b), Value: b}, "")
+	}
+	return (&blackmagicdesign.VideohubSocket{Conn: textConn{e.out.w}}).Write(b)
+}
+
+func videohubDump(ctx context.Context, e *env, args []string) error {
+	s, done, err := e.videohubSocket(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+	for {
+		b, err := s.Read()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return err
+		}
+		if _, ok := b.(*blackmagicdesign.EndPreludeBlock); ok {
+			return nil
+		}
+		if err := e.printBlock(b); err != nil {
+			return err
+		}
+	}
+}
+
+// videohubRequest sends a change request and waits for the reply.
+func videohubRequest(ctx context.Context, e *env, req blackmagicdesign.VideohubBlock) error {
+	s, done, err := e.videohubSocket(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+	if err := s.Write(req); err != nil {
+		return err
+	}
+	// The prelude is sent upon connection, the reply follows it.
+	for {
+		b, err := s.Read()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return err
+		}
+		switch b.(type) {
+		case *blackmagicdesign.AckBlock:
+			return e.printBlock(req)
+		case *blackmagicdesign.NakBlock:
+			return errors.New("videohub refused request")
+		}
+	}
+}
+
+func videohubRoute(ctx context.Context, e *env, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: route <output> <input>")
+	}
+	out, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("output: %w", err)
+	}
+	in, err := strconv.Atoi(args[1])
+	if err != nil {
+		return fmt.Errorf("input: %w", err)
+	}
+	return videohubRequest(ctx, e, &blackmagicdesign.VideoOutputRoutingBlock{
+		Routing: blackmagicdesign.Routing{out: in},
+	})
+}
+
+func videohubLabel(ctx context.Context, e *env, args []string) error {
+	if len(args) < 3 {
+		return errors.New("usage: label input|output <n> <label>")
+	}
+	n, err := strconv.Atoi(args[1])
+	if err != nil {
+		return fmt.Errorf("port: %w", err)
+	}
+	labels := blackmagicdesign.Labels{n: strings.Join(args[2:], " ")}
+	switch args[0] {
+	case "input":
+		return videohubRequest(ctx, e, &blackmagicdesign.InputLabelsBlock{Labels: labels})
+	case "output":
+		return videohubRequest(ctx, e, &blackmagicdesign.OutputLabelsBlock{Labels: labels})
+	default:
+		return fmt.Errorf("unknown label kind: %s", args[0])
+	}
+}
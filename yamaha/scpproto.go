@@ -6,9 +6,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
 	"strings"
 	"sync"
+	"time"
+
+	"puzzlekraken.com/broadcastkit/internal/wirelog"
+	"puzzlekraken.com/broadcastkit/metrics"
 )
 
 // Message interface is implemented by all messages sendable to a socket.
@@ -68,15 +73,59 @@ func parseLine(line []byte) (bool, Message, error) {
 // Conn must not be used directly after the first call to ScpSocket.
 type ScpSocket struct {
 	Conn io.ReadWriteCloser
+	// Logger, if set, receives every line at debug level and the lines that
+	// failed to parse as warnings. Error replies of the mixer are not logged.
+	Logger *slog.Logger
+	// Metrics, if set, receives every written message as a command, named by
+	// its action and timed until written, and every line read as a
+	// notification. Replies arrive through Read, so their latency is not
+	// included.
+	Metrics metrics.Recorder
 
 	rlock sync.Mutex
 	scan  *bufio.Scanner
 }
 
-func (c *ScpSocket) Write(msg Message) error {
+// device names the remote end of Conn for Metrics.
+func (c *ScpSocket) device() string {
+	if conn, ok := c.Conn.(net.Conn); ok {
+		return conn.RemoteAddr().String()
+	}
+	return ""
+}
+
+// messageName names msg for Metrics, like set or devinfo.
+func messageName(msg Message) string {
+	switch msg := msg.(type) {
+	case *HeartbeatMessage:
+		return "heartbeat"
+	case *StringParam:
+		if msg.Set {
+			return "set"
+		}
+	case *IntParam:
+		if msg.Set {
+			return "set"
+		}
+	case *InfoMessage:
+		return msg.Action
+	}
+	return "get"
+}
+
+func (c *ScpSocket) Write(msg Message) (err error) {
 	if c.Conn == nil {
 		return errors.New("broadcastkit/yamaha: connection not established")
 	}
+	if c.Metrics != nil {
+		defer func(start time.Time) {
+			var kind string
+			if err != nil {
+				kind = "SystemError"
+			}
+			c.Metrics.Command(c.device(), messageName(msg), time.Since(start), kind)
+		}(time.Now())
+	}
 	var buf bytes.Buffer
 	switch msg := msg.(type) {
 	case *HeartbeatMessage:
@@ -103,7 +152,8 @@ func (c *ScpSocket) Write(msg Message) error {
 		// This should be impossible due to the interface constraints.
 		panic(fmt.Sprintf("broadcastkit/yamaha: invalid Message type: %T", msg))
 	}
-	_, err := c.Conn.Write(buf.Bytes())
+	wirelog.Send(c.Logger, buf.Bytes())
+	_, err = c.Conn.Write(buf.Bytes())
 	return err
 }
 
@@ -131,12 +181,20 @@ func (c *ScpSocket) Read() (bool, Message, error) {
 	}
 
 	l := c.scan.Bytes()
+	wirelog.Recv(c.Logger, l)
+	if c.Metrics != nil {
+		c.Metrics.Notification(c.device(), metrics.Received)
+	}
 	bytes.Trim(l, whitespaces)
 	if len(l) == 0 {
 		return false, &HeartbeatMessage{}, nil
 	}
 
-	return parseLine(l)
+	reply, msg, err := parseLine(l)
+	if err != nil && msg == nil && !bytes.HasPrefix(trimSpace(l), []byte("ERROR")) {
+		wirelog.Or(c.Logger).Warn("scp parse", "line", string(l), "err", err)
+	}
+	return reply, msg, err
 }
 
 func (c *ScpSocket) Close() error {
@@ -0,0 +1,72 @@
+package yamaha
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"puzzlekraken.com/broadcastkit/capture"
+)
+
+func TestScpSocket_Capture(t *testing.T) {
+	events, err := capture.ReadFile("testdata/scp.capture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &ScpSocket{Conn: capture.Conn(events)}
+
+	tests := []struct {
+		send    Message
+		reply   bool
+		want    Message
+		wantErr bool
+	}{
+		{
+			send:  &InfoMessage{Action: "devinfo", Address: "productname"},
+			reply: true,
+			want:  &InfoMessage{Action: "devinfo", Address: "productname", Value: "QL5"},
+		},
+		{
+			send:  &IntParam{Address: "MIXER:Current/InCh/Fader/Level"},
+			reply: true,
+			want:  &IntParam{Address: "MIXER:Current/InCh/Fader/Level", Value: -1200},
+		},
+		{
+			send:  &StringParam{Set: true, Address: "MIXER:Current/InCh/Label/Name", AddressX: 3, Value: "VOX"},
+			reply: true,
+			want:  &StringParam{Set: true, Address: "MIXER:Current/InCh/Label/Name", AddressX: 3, Value: "VOX"},
+		},
+		{
+			want: &IntParam{Set: true, Address: "MIXER:Current/InCh/Fader/Level", AddressX: 1, Value: DbMin},
+		},
+		{
+			want: &IntParam{Set: true, Address: "MIXER:Current/St/Fader/Level", Value: DbMax},
+		},
+		{
+			send:    &IntParam{Address: "MIXER:Current/Bogus"},
+			reply:   true,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		if tt.send != nil {
+			if err := s.Write(tt.send); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+		}
+		reply, msg, err := s.Read()
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("Read() error = %v, wantErr %v", err, tt.wantErr)
+		}
+		if reply != tt.reply {
+			t.Errorf("Read() reply = %v, want %v", reply, tt.reply)
+		}
+		if !tt.wantErr && !reflect.DeepEqual(msg, tt.want) {
+			t.Errorf("Read() = %#v, want %#v", msg, tt.want)
+		}
+	}
+	if _, _, err := s.Read(); !errors.Is(err, io.EOF) {
+		t.Errorf("Read() error = %v, want EOF", err)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"puzzlekraken.com/broadcastkit/yamaha"
+)
+
+var yamahaDevice = device{
+	name:  "yamaha",
+	usage: "get <address> [x [y]] | set [-string] <address> <x> <y> <value> | monitor",
+	commands: map[string]func(context.Context, *env, []string) error{
+		"get":     yamahaGet,
+		"set":     yamahaSet,
+		"monitor": yamahaMonitor,
+	},
+}
+
+// yamahaTimeout bounds waiting for the reply of a single command.
+const yamahaTimeout = 5 * time.Second
+
+func (e *env) scpSocket(ctx context.Context) (*yamaha.ScpSocket, func(), error) {
+	s, err := yamaha.DialSCP(e.Address)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.Logger = e.log
+	stop := context.AfterFunc(ctx, func() { s.Close() })
+	return s, func() { stop(); s.Close() }, nil
+}
+
+// messageResult is the JSON form of an SCP message.
+type messageResult struct {
+	Reply bool   `json:"reply"`
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+func (e *env) printMessage(reply bool, msg yamaha.Message) error {
+	if e.out.json {
+		return e.out.print(messageResult{Reply: reply, Type: typeName(msg), Value: msg}, "")
+	}
+	var buf bytes.Buffer
+	if reply {
+		buf.WriteString("OK ")
+	} else {
+		buf.WriteString("NOTIFY ")
+	}
+	if err := (&yamaha.ScpSocket{Conn: textConn{&buf}}).Write(msg); err != nil {
+		return err
+	}
+	return e.out.print(nil, strings.TrimSuffix(buf.String(), "\n"))
+}
+
+// scpRequest sends msg and prints the reply, skipping notifications.
+func scpRequest(ctx context.Context, e *env, msg yamaha.Message) error {
+	s, done, err := e.scpSocket(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+	if c, ok := s.Conn.(net.Conn); ok {
+		c.SetDeadline(time.Now().Add(yamahaTimeout))
+	}
+	if err := s.Write(msg); err != nil {
+		return err
+	}
+	for {
+		reply, res, err := s.Read()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return err
+		}
+		if reply {
+			return e.printMessage(true, res)
+		}
+	}
+}
+
+// scpIndex parses the optional x and y indices of an address.
+func scpIndex(args []string) ([2]int, error) {
+	var xy [2]int
+	if len(args) > 2 {
+		return xy, errors.New("too many indices")
+	}
+	for i, a := range args {
+		n, err := strconv.Atoi(a)
+		if err != nil {
+			return xy, fmt.Errorf("index: %w", err)
+		}
+		xy[i] = n
+	}
+	return xy, nil
+}
+
+func yamahaGet(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: get <address> [x [y]], for example: get MIXER:Current/InCh/Fader/Level 0 0")
+	}
+	xy, err := scpIndex(args[1:])
+	if err != nil {
+		return err
+	}
+	// The wire format of a get is the same for every parameter type, the
+	// reply carries the type of the value.
+	return scpRequest(ctx, e, &yamaha.IntParam{
+		Address:  yamaha.AddressString(args[0]),
+		AddressX: xy[0],
+		AddressY: xy[1],
+	})
+}
+
+func yamahaSet(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("set", flag.ContinueOnError)
+	str := fs.Bool("string", false, "send the value as a string")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+	if len(args) != 4 {
+		return errors.New("usage: set [-string] <address> <x> <y> <value>")
+	}
+	xy, err := scpIndex(args[1:3])
+	if err != nil {
+		return err
+	}
+	addr := yamaha.AddressString(args[0])
+	if *str {
+		return scpRequest(ctx, e, &yamaha.StringParam{
+			Set: true, Address: addr, AddressX: xy[0], AddressY: xy[1], Value: args[3],
+		})
+	}
+	v, err := strconv.Atoi(args[3])
+	if err != nil {
+		return fmt.Errorf("value is not a number, use -string for text: %w", err)
+	}
+	return scpRequest(ctx, e, &yamaha.IntParam{
+		Set: true, Address: addr, AddressX: xy[0], AddressY: xy[1], Value: v,
+	})
+}
+
+func yamahaMonitor(ctx context.Context, e *env, args []string) error {
+	s, done, err := e.scpSocket(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+	for {
+		reply, msg, err := s.Read()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return err
+		}
+		if _, ok := msg.(*yamaha.HeartbeatMessage); ok {
+			continue
+		}
+		if err := e.printMessage(reply, msg); err != nil {
+			return err
+		}
+	}
+}